@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/middleware/operation_cost"
+)
+
+const (
+	EstimatedCostTooExpensiveCode = "COST_ESTIMATED_TOO_EXPENSIVE"
+	InvalidListSizeCode           = "COST_INVALID_LIST_SIZE"
+)
+
+// CostControl configures the demand control of the engine, the cost of operations is calculated
+// from the @cost and @listSize directives of the schema
+type CostControl struct {
+	// MaxEstimatedCost rejects operations with a higher estimated cost before they get planned, 0 disables the limit
+	MaxEstimatedCost int
+	// DefaultListSize is the assumed size of lists without @listSize
+	DefaultListSize int
+	// ComputeActualCost enables the calculation of the actual cost from the resolved response
+	ComputeActualCost bool
+}
+
+// OperationCost is the cost of an executed operation, Actual is only set when CostControl.ComputeActualCost is enabled
+type OperationCost struct {
+	Estimated int
+	Actual    int
+}
+
+// WithOperationCost reports the estimated and actual cost of the operation into cost, it requires Configuration.SetCostControl
+func WithOperationCost(cost *OperationCost) ExecutionOptions {
+	return func(ctx *internalExecutionContext) {
+		ctx.operationCost = cost
+	}
+}
+
+type costController struct {
+	config     CostControl
+	calculator *operation_cost.Calculator
+}
+
+func newCostController(config CostControl, schema *graphql.Schema) *costController {
+	return &costController{
+		config: config,
+		calculator: operation_cost.NewCalculator(schema.Document(), operation_cost.Options{
+			DefaultListSize: config.DefaultListSize,
+		}),
+	}
+}
+
+// checkEstimatedCost calculates the estimated cost of the operation and rejects it when it exceeds the budget
+func (c *costController) checkEstimatedCost(ctx *internalExecutionContext, operation *graphql.Request) error {
+	estimated, err := c.calculator.EstimatedCost(operation.Document(), operation.OperationName, operation.Variables)
+	if err != nil {
+		return graphqlerrors.RequestErrors{
+			{
+				Message:    err.Error(),
+				Extensions: &graphqlerrors.Extensions{Code: InvalidListSizeCode},
+			},
+		}
+	}
+
+	if ctx.operationCost != nil {
+		ctx.operationCost.Estimated = estimated
+	}
+
+	if c.config.MaxEstimatedCost > 0 && estimated > c.config.MaxEstimatedCost {
+		return graphqlerrors.RequestErrors{
+			{
+				Message:    fmt.Sprintf("The estimated query cost %d exceeds the maximum allowed cost %d", estimated, c.config.MaxEstimatedCost),
+				Extensions: &graphqlerrors.Extensions{Code: EstimatedCostTooExpensiveCode},
+			},
+		}
+	}
+
+	return nil
+}
+
+// actualCostWriter keeps a copy of the response to calculate the actual cost once the response is resolved
+// Incremental responses are kept as the list of flushed payloads
+type actualCostWriter struct {
+	resolve.SubscriptionResponseWriter
+	buf      bytes.Buffer
+	payloads [][]byte
+}
+
+func (w *actualCostWriter) Write(p []byte) (n int, err error) {
+	w.buf.Write(p)
+	return w.SubscriptionResponseWriter.Write(p)
+}
+
+func (w *actualCostWriter) Flush() error {
+	w.payloads = append(w.payloads, bytes.Clone(w.buf.Bytes()))
+	w.buf.Reset()
+	return w.SubscriptionResponseWriter.Flush()
+}
+
+func (c *costController) reportActualCost(ctx *internalExecutionContext, operation *graphql.Request, writer *actualCostWriter) error {
+	payloads := writer.payloads
+	if writer.buf.Len() != 0 {
+		payloads = append(payloads, writer.buf.Bytes())
+	}
+
+	var (
+		actual int
+		err    error
+	)
+	if len(payloads) > 1 {
+		actual, err = c.calculator.ActualIncrementalCost(operation.Document(), operation.OperationName, operation.Variables, payloads)
+	} else {
+		actual, err = c.calculator.ActualCost(operation.Document(), operation.OperationName, operation.Variables, bytes.Join(payloads, nil))
+	}
+	if err != nil {
+		return err
+	}
+	ctx.operationCost.Actual = actual
+	return nil
+}
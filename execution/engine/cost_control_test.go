@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/staticdatasource"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+)
+
+func TestExecutionEngine_CostControl(t *testing.T) {
+	newEngine := func(t *testing.T, costControl CostControl) *ExecutionEngine {
+		t.Helper()
+		schema, err := graphql.NewSchemaFromString(`
+			directive @listSize(assumedSize: Int, slicingArguments: [String!], sizedFields: [String!], requireOneSlicingArgument: Boolean = true) on FIELD_DEFINITION
+			directive @cost(weight: Int!) on ARGUMENT_DEFINITION | ENUM | FIELD_DEFINITION | INPUT_FIELD_DEFINITION | OBJECT | SCALAR
+			directive @defer(label: String, if: Boolean! = true) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+			type Query { users(first: Int): [User!]! @listSize(slicingArguments: ["first"]) }
+			type User @cost(weight: 2) { id: ID! address: Address }
+			type Address { city: String! }
+		`)
+		require.NoError(t, err)
+
+		dsCfg, err := plan.NewDataSourceConfiguration[staticdatasource.Configuration](
+			"users",
+			&staticdatasource.Factory[staticdatasource.Configuration]{},
+			&plan.DataSourceMetadata{
+				RootNodes: []plan.TypeField{
+					{TypeName: "Query", FieldNames: []string{"users"}},
+				},
+				ChildNodes: []plan.TypeField{
+					{TypeName: "User", FieldNames: []string{"id", "address"}},
+					{TypeName: "Address", FieldNames: []string{"city"}},
+				},
+			},
+			staticdatasource.Configuration{
+				Data: `{"users":[{"id":"1","address":{"city":"Berlin"}},{"id":"2","address":null}]}`,
+			},
+		)
+		require.NoError(t, err)
+
+		engineConf := NewConfiguration(schema)
+		engineConf.SetDataSources([]plan.DataSource{dsCfg})
+		engineConf.SetCostControl(costControl)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		engine, err := NewExecutionEngine(ctx, abstractlogger.Noop{}, engineConf, resolve.ResolverOptions{
+			MaxConcurrency: 1024,
+		})
+		require.NoError(t, err)
+		return engine
+	}
+
+	execute := func(engine *ExecutionEngine, operation *graphql.Request, options ...ExecutionOptions) (string, error) {
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), operation, &resultWriter, options...)
+		return resultWriter.String(), err
+	}
+
+	t.Run("reports estimated and actual cost", func(t *testing.T) {
+		engine := newEngine(t, CostControl{MaxEstimatedCost: 100, ComputeActualCost: true})
+
+		var cost OperationCost
+		response, err := execute(engine, &graphql.Request{Query: `{ users(first: 10) { id } }`}, WithOperationCost(&cost))
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"users":[{"id":"1"},{"id":"2"}]}}`, response)
+		assert.Equal(t, OperationCost{Estimated: 20, Actual: 4}, cost)
+	})
+
+	t.Run("reports actual cost of incremental responses", func(t *testing.T) {
+		engine := newEngine(t, CostControl{ComputeActualCost: true})
+
+		var payloads []string
+		resultWriter := graphql.NewEngineResultWriter()
+		resultWriter.SetFlushCallback(func(data []byte) {
+			payloads = append(payloads, string(data))
+		})
+		var cost OperationCost
+		err := engine.Execute(context.Background(), &graphql.Request{Query: `{ users(first: 10) { id ... @defer { address { city } } } }`}, &resultWriter, WithOperationCost(&cost), WithIncrementalDelivery())
+		require.NoError(t, err)
+		assert.Greater(t, len(payloads), 1)
+		// users weigh 2 each and the deferred address of the first user weighs 1
+		assert.Equal(t, OperationCost{Estimated: 30, Actual: 5}, cost)
+	})
+
+	t.Run("rejects operations over budget", func(t *testing.T) {
+		engine := newEngine(t, CostControl{MaxEstimatedCost: 10})
+
+		response, err := execute(engine, &graphql.Request{Query: `query Users($first: Int) { users(first: $first) { id } }`, Variables: []byte(`{"first":50}`)})
+		assert.Empty(t, response)
+		var requestErrors graphqlerrors.RequestErrors
+		require.ErrorAs(t, err, &requestErrors)
+		assert.Equal(t, "The estimated query cost 100 exceeds the maximum allowed cost 10", requestErrors[0].Message)
+		assert.Equal(t, EstimatedCostTooExpensiveCode, requestErrors[0].Extensions.Code)
+	})
+
+	t.Run("rejects operations without slicing argument", func(t *testing.T) {
+		engine := newEngine(t, CostControl{})
+
+		_, err := execute(engine, &graphql.Request{Query: `{ users { id } }`})
+		var requestErrors graphqlerrors.RequestErrors
+		require.ErrorAs(t, err, &requestErrors)
+		assert.Equal(t, InvalidListSizeCode, requestErrors[0].Extensions.Code)
+	})
+}
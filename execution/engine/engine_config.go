@@ -8,6 +8,7 @@ import (
 	"github.com/wundergraph/graphql-go-tools/execution/graphql"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/fieldusage"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
 )
@@ -20,6 +21,10 @@ type Configuration struct {
 	schema                   *graphql.Schema
 	plannerConfig            plan.Configuration
 	websocketBeforeStartHook WebsocketBeforeStartHook
+	persistedQueryStore      PersistedQueryStore
+	trustedDocumentsManifest TrustedDocumentsManifest
+	costControl              *CostControl
+	fieldUsageCollector      *fieldusage.Collector
 }
 
 func NewConfiguration(schema *graphql.Schema) Configuration {
@@ -70,6 +75,26 @@ func (e *Configuration) SetWebsocketBeforeStartHook(hook WebsocketBeforeStartHoo
 	e.websocketBeforeStartHook = hook
 }
 
+// SetPersistedQueryStore - enables Automatic Persisted Queries, the store keeps the query text of registered hashes
+func (e *Configuration) SetPersistedQueryStore(store PersistedQueryStore) {
+	e.persistedQueryStore = store
+}
+
+// SetTrustedDocuments - enables the trusted documents mode, only operations of the manifest will be executed
+func (e *Configuration) SetTrustedDocuments(manifest TrustedDocumentsManifest) {
+	e.trustedDocumentsManifest = manifest
+}
+
+// SetCostControl - enables the demand control, operations are rejected when their estimated cost exceeds the budget
+func (e *Configuration) SetCostControl(costControl CostControl) {
+	e.costControl = &costControl
+}
+
+// SetFieldUsageCollector - records the field usage of every executed operation, the client is taken from the request headers
+func (e *Configuration) SetFieldUsageCollector(collector *fieldusage.Collector) {
+	e.fieldUsageCollector = collector
+}
+
 type dataSourceGeneratorOptions struct {
 	streamingClient           *http.Client
 	subscriptionType          SubscriptionType
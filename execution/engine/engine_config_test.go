@@ -391,7 +391,16 @@ directive @deprecated(
     [Markdown](https://daringfireball.net/projects/markdown/).
     """
     reason: String = "No longer supported"
-) on FIELD_DEFINITION | ENUM_VALUE
+) on FIELD_DEFINITION | ARGUMENT_DEFINITION | ENUM_VALUE | INPUT_FIELD_DEFINITION
+
+"Indicates exactly one field must be supplied and this field must not be 'null'."
+directive @oneOf on INPUT_OBJECT
+
+"Exposes a URL that specifies the behavior of this scalar."
+directive @specifiedBy(
+    "The URL that specifies the behavior of this scalar."
+    url: String!
+) on SCALAR
 
 """
 A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document.
@@ -404,7 +413,7 @@ type __Directive {
     name: String!
     description: String
     locations: [__DirectiveLocation!]!
-    args: [__InputValue!]!
+    args(includeDeprecated: Boolean = false): [__InputValue!]!
     isRepeatable: Boolean!
     __typename: String!
 }
@@ -472,7 +481,7 @@ a name, potentially a list of arguments, and a return type.
 type __Field {
     name: String!
     description: String
-    args: [__InputValue!]!
+    args(includeDeprecated: Boolean = false): [__InputValue!]!
     type: __Type!
     isDeprecated: Boolean!
     deprecationReason: String
@@ -490,6 +499,8 @@ type __InputValue {
     type: __Type!
     "A GraphQL-formatted string representing the default value for this input value."
     defaultValue: String
+    isDeprecated: Boolean!
+    deprecationReason: String
     __typename: String!
 }
 
@@ -499,6 +510,7 @@ available types and directives on the server, as well as the entry points for
 query, mutation, and subscription operations.
 """
 type __Schema {
+    description: String
     "A list of all types supported by this server."
     types: [__Type!]!
     "The type that query operations will be rooted at."
@@ -530,8 +542,12 @@ type __Type {
     interfaces: [__Type!]
     possibleTypes: [__Type!]
     enumValues(includeDeprecated: Boolean = false): [__EnumValue!]
-    inputFields: [__InputValue!]
+    inputFields(includeDeprecated: Boolean = false): [__InputValue!]
     ofType: __Type
+    "'true' for input object types annotated with @oneOf, 'null' for all other types."
+    isOneOf: Boolean
+    "The URL that specifies the behavior of a custom scalar, 'null' for all other types."
+    specifiedByURL: String
     __typename: String!
 }
 
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/apollocompatibility"
 	"net/http"
+	"strconv"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/jensneuse/abstractlogger"
 	"github.com/wundergraph/astjson"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/wundergraph/graphql-go-tools/execution/graphql"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
@@ -28,6 +30,7 @@ import (
 type internalExecutionContext struct {
 	resolveContext *resolve.Context
 	postProcessor  *postprocess.Processor
+	operationCost  *OperationCost
 }
 
 func newInternalExecutionContext() *internalExecutionContext {
@@ -67,6 +70,10 @@ type ExecutionEngine struct {
 	resolver                 *resolve.Resolver
 	executionPlanCache       *lru.Cache
 	apolloCompatibilityFlags apollocompatibility.Flags
+	trustedDocuments         *trustedDocuments
+	costController           *costController
+	tracer                   trace.Tracer
+	metrics                  resolve.Metrics
 }
 
 type WebsocketBeforeStartHook interface {
@@ -108,6 +115,21 @@ func WithRequestTraceOptions(options resolve.TraceOptions) ExecutionOptions {
 	}
 }
 
+// WithOverrideLabels activates the progressive @override directives with the given labels for the request
+func WithOverrideLabels(labels ...string) ExecutionOptions {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.OverrideLabels = labels
+	}
+}
+
+// WithIncrementalDelivery flushes @defer and @stream responses as separate payloads to the writer
+// Without it, deferred fragments and streamed lists are resolved inline into a single response
+func WithIncrementalDelivery() ExecutionOptions {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.ExecutionOptions.EnableIncrementalDelivery = true
+	}
+}
+
 func NewExecutionEngine(ctx context.Context, logger abstractlogger.Logger, engineConfig Configuration, resolverOptions resolve.ResolverOptions) (*ExecutionEngine, error) {
 	executionPlanCache, err := lru.New(1024)
 	if err != nil {
@@ -135,60 +157,78 @@ func NewExecutionEngine(ctx context.Context, logger abstractlogger.Logger, engin
 		dsIDs[ds.Id()] = struct{}{}
 	}
 
-	return &ExecutionEngine{
+	engine := &ExecutionEngine{
 		logger:             logger,
 		config:             engineConfig,
 		resolver:           resolve.New(ctx, resolverOptions),
+		tracer:             newTracer(resolverOptions.TracerProvider),
+		metrics:            resolverOptions.Metrics,
 		executionPlanCache: executionPlanCache,
 		apolloCompatibilityFlags: apollocompatibility.Flags{
 			ReplaceInvalidVarError: resolverOptions.ResolvableOptions.ApolloCompatibilityReplaceInvalidVarError,
 		},
-	}, nil
-}
+	}
 
-func (e *ExecutionEngine) Execute(ctx context.Context, operation *graphql.Request, writer resolve.SubscriptionResponseWriter, options ...ExecutionOptions) error {
+	if engineConfig.costControl != nil {
+		engine.costController = newCostController(*engineConfig.costControl, engineConfig.schema)
+	}
 
-	normalize := !operation.IsNormalized()
-	if normalize {
-		// Normalize the operation, but extract variables later so ValidateForSchema can return correct error messages for bad arguments.
-		result, err := operation.Normalize(e.config.schema,
-			astnormalization.WithRemoveFragmentDefinitions(),
-			astnormalization.WithRemoveUnusedVariables(),
-			astnormalization.WithInlineFragmentSpreads(),
-		)
+	if engineConfig.trustedDocumentsManifest != nil {
+		if err = engine.loadTrustedDocuments(engineConfig.trustedDocumentsManifest); err != nil {
+			return nil, err
+		}
+	}
+
+	return engine, nil
+}
+
+func (e *ExecutionEngine) Execute(ctx context.Context, operation *graphql.Request, writer resolve.SubscriptionResponseWriter, options ...ExecutionOptions) (err error) {
+	ctx, span := e.tracer.Start(ctx, SpanNameExecute, trace.WithAttributes(AttributeOperationName.String(operation.OperationName)))
+	defer func() {
+		recordSpanError(span, err)
+		span.End()
+		e.reportErrors(err)
+	}()
+
+	// in trusted documents mode, persisted queries are resolved from the manifest and can't be registered
+	var persistedQueryHash string
+	if e.trustedDocuments != nil {
+		if err := e.loadTrustedDocument(operation); err != nil {
+			return err
+		}
+	} else {
+		hash, err := e.loadPersistedQuery(ctx, operation)
 		if err != nil {
 			return err
-		} else if !result.Successful {
-			return result.Errors
 		}
-		normalize = true
+		persistedQueryHash = hash
 	}
 
-	// Validate the operation against the schema.
-	if result, err := operation.ValidateForSchema(e.config.schema); err != nil {
+	if err := e.phase(ctx, SpanNameParse, func(context.Context) error {
+		return operation.Parse()
+	}); err != nil {
 		return err
-	} else if !result.Valid {
-		return result.Errors
+	}
+	if operationType, err := operation.OperationType(); err == nil {
+		span.SetAttributes(AttributeOperationType.String(ast.OperationType(operationType).Name()))
 	}
 
-	if normalize {
-		// Normalize the operation again, this time just extracting additional variables from arguments.
-		result, err := operation.Normalize(e.config.schema,
-			astnormalization.WithExtractVariables(),
-		)
-		if err != nil {
-			return err
-		} else if !result.Successful {
-			return result.Errors
-		}
+	if err := e.normalizeAndValidate(ctx, operation, e.verifyTrustedOperation); err != nil {
+		return err
+	}
+
+	if err := e.registerPersistedQuery(ctx, persistedQueryHash, operation); err != nil {
+		return err
 	}
 
 	// Validate user-supplied and extracted variables against the operation.
 	if len(operation.Variables) > 0 && operation.Variables[0] == '{' {
-		validator := variablesvalidation.NewVariablesValidator(variablesvalidation.VariablesValidatorOptions{
-			ApolloCompatibilityFlags: e.apolloCompatibilityFlags,
-		})
-		if err := validator.Validate(operation.Document(), e.config.schema.Document(), operation.Variables); err != nil {
+		if err := e.phase(ctx, SpanNameValidateVariables, func(context.Context) error {
+			validator := variablesvalidation.NewVariablesValidator(variablesvalidation.VariablesValidatorOptions{
+				ApolloCompatibilityFlags: e.apolloCompatibilityFlags,
+			})
+			return validator.Validate(operation.Document(), e.config.schema.Document(), operation.Variables)
+		}); err != nil {
 			return err
 		}
 	}
@@ -199,6 +239,12 @@ func (e *ExecutionEngine) Execute(ctx context.Context, operation *graphql.Reques
 		options[i](execContext)
 	}
 
+	if e.costController != nil {
+		if err := e.costController.checkEstimatedCost(execContext, operation); err != nil {
+			return err
+		}
+	}
+
 	if execContext.resolveContext.TracingOptions.Enable {
 		traceCtx := resolve.SetTraceStart(execContext.resolveContext.Context(), execContext.resolveContext.TracingOptions.EnablePredictableDebugTimings)
 		execContext.setContext(traceCtx)
@@ -209,10 +255,16 @@ func (e *ExecutionEngine) Execute(ctx context.Context, operation *graphql.Reques
 		tracePlanStart = resolve.GetDurationNanoSinceTraceStart(execContext.resolveContext.Context())
 	}
 
-	var report operationreport.Report
-	cachedPlan := e.getCachedPlan(execContext, operation.Document(), e.config.schema.Document(), operation.OperationName, &report)
-	if report.HasErrors() {
-		return report
+	var cachedPlan plan.Plan
+	if err := e.phase(execContext.resolveContext.Context(), SpanNamePlan, func(context.Context) error {
+		var report operationreport.Report
+		cachedPlan = e.getCachedPlan(execContext, operation.Document(), e.config.schema.Document(), operation.OperationName, &report)
+		if report.HasErrors() {
+			return report
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	if execContext.resolveContext.TracingOptions.Enable && !execContext.resolveContext.TracingOptions.ExcludePlannerStats {
@@ -225,44 +277,152 @@ func (e *ExecutionEngine) Execute(ctx context.Context, operation *graphql.Reques
 		})
 	}
 
-	switch p := cachedPlan.(type) {
-	case *plan.SynchronousResponsePlan:
-		_, err := e.resolver.ResolveGraphQLResponse(execContext.resolveContext, p.Response, nil, writer)
-		return err
-	case *plan.SubscriptionResponsePlan:
-		return e.resolver.ResolveGraphQLSubscription(execContext.resolveContext, p.Response, writer)
-	default:
-		return errors.New("execution of operation is not possible")
+	if e.config.fieldUsageCollector != nil {
+		e.config.fieldUsageCollector.CollectRequest(operation.Document(), e.config.schema.Document(), operation.OperationName, cachedPlan, execContext.resolveContext.Request.Header)
 	}
+
+	return e.phase(execContext.resolveContext.Context(), SpanNameResolve, func(ctx context.Context) error {
+		execContext.setContext(ctx)
+		switch p := cachedPlan.(type) {
+		case *plan.SynchronousResponsePlan:
+			if e.costController == nil || !e.costController.config.ComputeActualCost || execContext.operationCost == nil {
+				_, err := e.resolver.ResolveGraphQLResponse(execContext.resolveContext, p.Response, nil, writer)
+				return err
+			}
+			costWriter := &actualCostWriter{SubscriptionResponseWriter: writer}
+			if _, err := e.resolver.ResolveGraphQLResponse(execContext.resolveContext, p.Response, nil, costWriter); err != nil {
+				return err
+			}
+			return e.costController.reportActualCost(execContext, operation, costWriter)
+		case *plan.SubscriptionResponsePlan:
+			return e.resolver.ResolveGraphQLSubscription(execContext.resolveContext, p.Response, writer)
+		default:
+			return errors.New("execution of operation is not possible")
+		}
+	})
 }
 
-func (e *ExecutionEngine) getCachedPlan(ctx *internalExecutionContext, operation, definition *ast.Document, operationName string, report *operationreport.Report) plan.Plan {
+// normalizeAndValidate normalizes the operation and validates it against the schema
+// Variables are extracted after validation, so that validation can return correct error messages for bad arguments
+// If set, validated is called with the validated operation before its literal values are extracted to variables
+func (e *ExecutionEngine) normalizeAndValidate(ctx context.Context, operation *graphql.Request, validated func(operation *graphql.Request) error) error {
+	normalize := !operation.IsNormalized()
+	if normalize {
+		if err := e.phase(ctx, SpanNameNormalize, func(context.Context) error {
+			result, err := operation.Normalize(e.config.schema,
+				astnormalization.WithRemoveFragmentDefinitions(),
+				astnormalization.WithRemoveUnusedVariables(),
+				astnormalization.WithInlineFragmentSpreads(),
+			)
+			if err != nil {
+				return err
+			} else if !result.Successful {
+				return result.Errors
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Validate the operation against the schema.
+	if err := e.phase(ctx, SpanNameValidate, func(context.Context) error {
+		if result, err := operation.ValidateForSchema(e.config.schema); err != nil {
+			return err
+		} else if !result.Valid {
+			return result.Errors
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if validated != nil {
+		if err := validated(operation); err != nil {
+			return err
+		}
+	}
+
+	if normalize {
+		// Normalize the operation again, this time just extracting additional variables from arguments.
+		return e.phase(ctx, SpanNameNormalizeVariables, func(context.Context) error {
+			result, err := operation.Normalize(e.config.schema,
+				astnormalization.WithExtractVariables(),
+			)
+			if err != nil {
+				return err
+			} else if !result.Successful {
+				return result.Errors
+			}
+			return nil
+		})
+	}
+	return nil
+}
 
+// operationHash is the hash of the normalized operation, it's used as the key of the execution plan cache
+func (e *ExecutionEngine) operationHash(operation *ast.Document) (uint64, error) {
 	hash := pool.Hash64.Get()
 	hash.Reset()
 	defer pool.Hash64.Put(hash)
 	err := astprinter.Print(operation, hash)
+	if err != nil {
+		return 0, err
+	}
+	return hash.Sum64(), nil
+}
+
+func overrideLabelsCacheKey(operationHash uint64, labels []string) uint64 {
+	hash := pool.Hash64.Get()
+	hash.Reset()
+	defer pool.Hash64.Put(hash)
+	_, _ = hash.WriteString(strconv.FormatUint(operationHash, 10))
+	for _, label := range labels {
+		_, _ = hash.WriteString(",")
+		_, _ = hash.WriteString(label)
+	}
+	return hash.Sum64()
+}
+
+func (e *ExecutionEngine) getCachedPlan(ctx *internalExecutionContext, operation, definition *ast.Document, operationName string, report *operationreport.Report) plan.Plan {
+	cacheKey, err := e.operationHash(operation)
 	if err != nil {
 		report.AddInternalError(err)
 		return nil
 	}
 
-	cacheKey := hash.Sum64()
+	// active override labels change the plan, so each combination of labels has its own plan
+	overrideLabels := plan.OverrideLabels(e.config.plannerConfig.DataSources, ctx.resolveContext.OverrideLabels)
+	if len(overrideLabels) > 0 {
+		cacheKey = overrideLabelsCacheKey(cacheKey, overrideLabels)
+	}
 
 	if cached, ok := e.executionPlanCache.Get(cacheKey); ok {
 		if p, ok := cached.(plan.Plan); ok {
+			if e.metrics != nil {
+				e.metrics.PlanCacheLookup(true)
+			}
 			return p
 		}
 	}
 
+	// the miss is reported before planning, so misses of operations which fail to plan are counted as well
+	if e.metrics != nil {
+		e.metrics.PlanCacheLookup(false)
+	}
+
+	start := time.Now()
 	planner, _ := plan.NewPlanner(e.config.plannerConfig)
-	planResult := planner.Plan(operation, definition, operationName, report)
+	planResult := planner.Plan(operation, definition, operationName, report, plan.WithOverrideLabels(overrideLabels...))
 	if report.HasErrors() {
 		return nil
 	}
 
 	p := ctx.postProcessor.Process(planResult)
 	e.executionPlanCache.Add(cacheKey, p)
+	if e.metrics != nil {
+		e.metrics.PlanningDuration(time.Since(start))
+	}
 	return p
 }
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/wundergraph/graphql-go-tools/execution/graphql"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/graphql_datasource"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/rest_datasource"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/staticdatasource"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
@@ -375,6 +377,89 @@ func TestExecutionEngine_Execute(t *testing.T) {
 			},
 		))
 
+		t.Run("execute type introspection query for isOneOf", runWithoutError(
+			ExecutionEngineTestCase{
+				schema: schema,
+				operation: func(t *testing.T) graphql.Request {
+					return graphql.Request{
+						OperationName: "myIntrospection",
+						Query: `query myIntrospection(){
+							input: __type(name: "ReviewInput") {
+								name
+								isOneOf
+							}
+							object: __type(name: "Review") {
+								name
+								isOneOf
+							}
+						}`,
+					}
+				},
+				expectedResponse: `{"data":{"input":{"name":"ReviewInput","isOneOf":false},"object":{"name":"Review","isOneOf":null}}}`,
+			},
+		))
+
+		t.Run("execute introspection query for deprecated input values and specifiedByURL", func(t *testing.T) {
+			schemaWithDeprecatedInputValues, err := graphql.NewSchemaFromString(`
+				"Droids schema"
+				schema { query: Query }
+				scalar URL @specifiedBy(url: "https://url.spec.whatwg.org")
+				type Query { droids(filter: DroidFilter, first: Int @deprecated(reason: "use filter")): URL }
+				input DroidFilter { name: String model: String @deprecated }
+			`)
+			require.NoError(t, err)
+
+			t.Run("without deprecated", runWithoutError(
+				ExecutionEngineTestCase{
+					schema: schemaWithDeprecatedInputValues,
+					operation: func(t *testing.T) graphql.Request {
+						return graphql.Request{
+							OperationName: "myIntrospection",
+							Query: `query myIntrospection {
+								__schema { description }
+								url: __type(name: "URL") { specifiedByURL }
+								filter: __type(name: "DroidFilter") { inputFields { name isDeprecated } }
+								query: __type(name: "Query") { fields { args { name isDeprecated deprecationReason } } }
+							}`,
+						}
+					},
+					expectedResponse: `{"data":{"__schema":{"description":"Droids schema"},"url":{"specifiedByURL":"https://url.spec.whatwg.org"},"filter":{"inputFields":[{"name":"name","isDeprecated":false}]},"query":{"fields":[{"args":[{"name":"filter","isDeprecated":false,"deprecationReason":null}]}]}}}`,
+				},
+			))
+
+			t.Run("with deprecated", runWithoutError(
+				ExecutionEngineTestCase{
+					schema: schemaWithDeprecatedInputValues,
+					operation: func(t *testing.T) graphql.Request {
+						return graphql.Request{
+							OperationName: "myIntrospection",
+							Variables:     []byte(`{"includeDeprecated":true}`),
+							Query: `query myIntrospection($includeDeprecated: Boolean) {
+								filter: __type(name: "DroidFilter") { inputFields(includeDeprecated: true) { name isDeprecated deprecationReason } }
+								query: __type(name: "Query") { fields { args(includeDeprecated: $includeDeprecated) { name isDeprecated deprecationReason } } }
+							}`,
+						}
+					},
+					expectedResponse: `{"data":{"filter":{"inputFields":[{"name":"name","isDeprecated":false,"deprecationReason":null},{"name":"model","isDeprecated":true,"deprecationReason":"No longer supported"}]},"query":{"fields":[{"args":[{"name":"filter","isDeprecated":false,"deprecationReason":null},{"name":"first","isDeprecated":true,"deprecationReason":"use filter"}]}]}}}`,
+				},
+			))
+
+			t.Run("nested include deprecated only applies to its own selection", runWithoutError(
+				ExecutionEngineTestCase{
+					schema: schemaWithDeprecatedInputValues,
+					operation: func(t *testing.T) graphql.Request {
+						return graphql.Request{
+							OperationName: "myIntrospection",
+							Query: `query myIntrospection {
+								filter: __type(name: "DroidFilter") { inputFields { name type { inputFields(includeDeprecated: true) { name } } } }
+							}`,
+						}
+					},
+					expectedResponse: `{"data":{"filter":{"inputFields":[{"name":"name","type":{"inputFields":null}}]}}}`,
+				},
+			))
+		})
+
 		t.Run("execute type introspection query with deprecated fields", runWithoutError(
 			ExecutionEngineTestCase{
 				schema: schema,
@@ -1482,6 +1567,110 @@ func TestExecutionEngine_Execute(t *testing.T) {
 		},
 	))
 
+	t.Run("rest data source", func(t *testing.T) {
+		schema, err := graphql.NewSchemaFromString(`
+			type Query {
+				users(limit: Int): [User!]!
+				user(id: ID!): User
+			}
+
+			type User {
+				id: ID!
+				name: String!
+				friends: [User!]!
+			}
+		`)
+		require.NoError(t, err)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/users":
+				assert.Equal(t, "2", r.URL.Query().Get("limit"))
+				_, _ = w.Write([]byte(`[{"id":"1","name":"Jens"},{"id":"2","name":"Stefan"}]`))
+			case "/users/1/friends":
+				_, _ = w.Write([]byte(`[{"id":"2","name":"Stefan"}]`))
+			case "/users/2/friends":
+				_, _ = w.Write([]byte(`[]`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		factory, err := rest_datasource.NewFactory(context.Background(), http.DefaultClient)
+		require.NoError(t, err)
+
+		restDataSource := func(t *testing.T, id string, rootNode plan.TypeField, fetch rest_datasource.FetchConfiguration) plan.DataSource {
+			cfg, err := plan.NewDataSourceConfiguration[rest_datasource.Configuration](
+				id,
+				factory,
+				&plan.DataSourceMetadata{
+					RootNodes: []plan.TypeField{rootNode},
+					ChildNodes: []plan.TypeField{
+						{TypeName: "User", FieldNames: []string{"id", "name"}},
+					},
+				},
+				rest_datasource.Configuration{Fetch: fetch},
+			)
+			require.NoError(t, err)
+			return cfg
+		}
+
+		dataSources := []plan.DataSource{
+			restDataSource(t, "users", plan.TypeField{TypeName: "Query", FieldNames: []string{"users"}}, rest_datasource.FetchConfiguration{
+				URL:    server.URL + "/users",
+				Method: "GET",
+				Query: []rest_datasource.QueryConfiguration{
+					{Name: "limit", Value: "{{ .arguments.limit }}"},
+				},
+			}),
+			restDataSource(t, "user", plan.TypeField{TypeName: "Query", FieldNames: []string{"user"}}, rest_datasource.FetchConfiguration{
+				URL:    server.URL + "/users/{{ .arguments.id }}",
+				Method: "GET",
+				StatusCodeErrors: map[int]rest_datasource.StatusCodeError{
+					http.StatusNotFound: {Message: "user not found"},
+				},
+			}),
+			restDataSource(t, "friends", plan.TypeField{TypeName: "User", FieldNames: []string{"friends"}}, rest_datasource.FetchConfiguration{
+				URL:    server.URL + "/users/{{ .object.id }}/friends",
+				Method: "GET",
+			}),
+		}
+
+		t.Run("nested fetches on list items", runWithoutError(ExecutionEngineTestCase{
+			schema: schema,
+			operation: func(t *testing.T) graphql.Request {
+				return graphql.Request{
+					Query: `{ users(limit: 2) { name friends { name } } }`,
+				}
+			},
+			dataSources:      dataSources,
+			expectedResponse: `{"data":{"users":[{"name":"Jens","friends":[{"name":"Stefan"}]},{"name":"Stefan","friends":[]}]}}`,
+		}))
+
+		t.Run("aliased root fields", runWithoutError(ExecutionEngineTestCase{
+			schema: schema,
+			operation: func(t *testing.T) graphql.Request {
+				return graphql.Request{
+					Query: `{ a: users(limit: 2) { id } b: users(limit: 2) { name } }`,
+				}
+			},
+			dataSources:      dataSources,
+			expectedResponse: `{"data":{"a":[{"id":"1"},{"id":"2"}],"b":[{"name":"Jens"},{"name":"Stefan"}]}}`,
+		}))
+
+		t.Run("status code mapped to error", runWithoutError(ExecutionEngineTestCase{
+			schema: schema,
+			operation: func(t *testing.T) graphql.Request {
+				return graphql.Request{
+					Query: `{ user(id: "3") { name } }`,
+				}
+			},
+			dataSources:      dataSources,
+			expectedResponse: `{"errors":[{"message":"Failed to fetch from Subgraph 'user'."}],"data":{"user":null}}`,
+		}))
+	})
+
 	t.Run("Spreading a fragment on an invalid type returns ErrInvalidFragmentSpread", runWithAndCompareError(
 		ExecutionEngineTestCase{
 			schema:    graphql.StarwarsSchema(t),
@@ -3753,6 +3942,68 @@ func testNetHttpClient(t *testing.T, testCase roundTripperTestCase) *http.Client
 	}
 }
 
+func TestExecutionEngine_Defer(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		directive @defer(label: String, if: Boolean! = true) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+		type Query { users(first: Int): [User!]! }
+		type User { id: ID! address: Address }
+		type Address { city: String! }
+	`)
+	require.NoError(t, err)
+
+	dsCfg, err := plan.NewDataSourceConfiguration[staticdatasource.Configuration](
+		"users",
+		&staticdatasource.Factory[staticdatasource.Configuration]{},
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"users"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"id", "address"}},
+				{TypeName: "Address", FieldNames: []string{"city"}},
+			},
+		},
+		staticdatasource.Configuration{
+			Data: `{"users":[{"id":"1","address":{"city":"Berlin"}},{"id":"2","address":null}]}`,
+		},
+	)
+	require.NoError(t, err)
+
+	engineConf := NewConfiguration(schema)
+	engineConf.SetDataSources([]plan.DataSource{dsCfg})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := NewExecutionEngine(ctx, abstractlogger.Noop{}, engineConf, resolve.ResolverOptions{
+		MaxConcurrency: 1024,
+	})
+	require.NoError(t, err)
+
+	operation := func() *graphql.Request {
+		return &graphql.Request{Query: `{ users(first: 10) { id ... @defer { address { city } } } }`}
+	}
+
+	t.Run("resolves deferred fragments inline by default", func(t *testing.T) {
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), operation(), &resultWriter)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"users":[{"id":"1","address":{"city":"Berlin"}},{"id":"2","address":null}]}}`, resultWriter.String())
+	})
+
+	t.Run("flushes deferred fragments with incremental delivery", func(t *testing.T) {
+		var payloads []string
+		resultWriter := graphql.NewEngineResultWriter()
+		resultWriter.SetFlushCallback(func(data []byte) {
+			payloads = append(payloads, string(data))
+		})
+		err := engine.Execute(context.Background(), operation(), &resultWriter, WithIncrementalDelivery())
+		require.NoError(t, err)
+		require.NotEmpty(t, payloads)
+		assert.Equal(t, `{"data":{"users":[{"id":"1"},{"id":"2"}]},"hasNext":true}`, payloads[0])
+		assert.Contains(t, payloads[len(payloads)-1], `"hasNext":false`)
+	})
+}
+
 func TestExecutionEngine_GetCachedPlan(t *testing.T) {
 	schema, err := graphql.NewSchemaFromString(testSubscriptionDefinition)
 	require.NoError(t, err)
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/staticdatasource"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/fieldusage"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+func TestExecutionEngine_FieldUsage(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		type Query { users(role: Role): [User!]! }
+		enum Role { ADMIN MEMBER }
+		type User { id: ID! name: String! }
+	`)
+	require.NoError(t, err)
+
+	dsCfg, err := plan.NewDataSourceConfiguration[staticdatasource.Configuration](
+		"users",
+		&staticdatasource.Factory[staticdatasource.Configuration]{},
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"users"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"id", "name"}},
+			},
+		},
+		staticdatasource.Configuration{
+			Data: `{"users":[{"id":"1","name":"Jens"}]}`,
+		},
+	)
+	require.NoError(t, err)
+
+	var reports []*fieldusage.Report
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	collector := fieldusage.NewCollector(ctx, fieldusage.Options{
+		Exporter: fieldusage.ExporterFunc(func(ctx context.Context, report *fieldusage.Report) error {
+			reports = append(reports, report)
+			return nil
+		}),
+	})
+
+	engineConf := NewConfiguration(schema)
+	engineConf.SetDataSources([]plan.DataSource{dsCfg})
+	engineConf.SetFieldUsageCollector(collector)
+	engine, err := NewExecutionEngine(ctx, abstractlogger.Noop{}, engineConf, resolve.ResolverOptions{
+		MaxConcurrency: 1024,
+	})
+	require.NoError(t, err)
+
+	resultWriter := graphql.NewEngineResultWriter()
+	err = engine.Execute(context.Background(), &graphql.Request{
+		OperationName: "Admins",
+		Query:         `query Admins { users(role: ADMIN) { name } }`,
+	}, &resultWriter, WithAdditionalHttpHeaders(http.Header{
+		fieldusage.DefaultClientNameHeader:    []string{"web"},
+		fieldusage.DefaultClientVersionHeader: []string{"1.2.3"},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"users":[{"name":"Jens"}]}}`, resultWriter.String())
+
+	require.NoError(t, collector.Flush(context.Background()))
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0].TracesPerQuery, 1)
+	for _, traces := range reports[0].TracesPerQuery {
+		require.Len(t, traces.StatsWithContext, 1)
+		stats := traces.StatsWithContext[0]
+		assert.Equal(t, fieldusage.StatsContext{ClientName: "web", ClientVersion: "1.2.3"}, stats.Context)
+		assert.Equal(t, &fieldusage.FieldStat{
+			ReturnType:   "[User!]!",
+			RequestCount: 1,
+			Subgraphs:    map[string]uint64{"users": 1},
+			Arguments:    map[string]*fieldusage.ArgumentStat{"role": {RequestCount: 1}},
+		}, stats.PerTypeStat["Query"].PerFieldStat["users"])
+		assert.Equal(t, &fieldusage.FieldStat{
+			ReturnType:   "String!",
+			RequestCount: 1,
+			Subgraphs:    map[string]uint64{"users": 1},
+		}, stats.PerTypeStat["User"].PerFieldStat["name"])
+		assert.Equal(t, map[string]*fieldusage.EnumStats{
+			"Role": {EnumValues: map[string]*fieldusage.EnumValueStats{"ADMIN": {RequestCount: 1}}},
+		}, stats.ExtendedReferences.EnumValues)
+	}
+}
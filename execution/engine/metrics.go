@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/errorcodes"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/variablesvalidation"
+)
+
+// reportErrors reports the errors that failed the execution of an operation grouped by their code
+// Errors of the response itself, e.g. subgraph errors, are reported by the resolver
+func (e *ExecutionEngine) reportErrors(err error) {
+	if e.metrics == nil || err == nil {
+		return
+	}
+	counts := make(map[string]int)
+	var (
+		requestErrors graphqlerrors.RequestErrors
+		report        operationreport.Report
+		variableErr   *variablesvalidation.InvalidVariableError
+	)
+	switch {
+	case errors.As(err, &requestErrors):
+		for _, requestErr := range requestErrors {
+			code := ""
+			if requestErr.Extensions != nil {
+				code = requestErr.Extensions.Code
+			}
+			counts[code]++
+		}
+	case errors.As(err, &report):
+		for _, externalErr := range report.ExternalErrors {
+			counts[externalErr.ExtensionCode]++
+		}
+		if len(report.InternalErrors) > 0 {
+			counts[errorcodes.InternalServerError] += len(report.InternalErrors)
+		}
+	case errors.As(err, &variableErr):
+		counts[variableErr.ExtensionCode]++
+	default:
+		counts[""]++
+	}
+	for code, count := range counts {
+		e.metrics.Errors(code, count)
+	}
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/errorcodes"
+)
+
+type testMetrics struct {
+	mu               sync.Mutex
+	planCacheLookups []bool
+	plannings        int
+	responseSizes    []int
+	errors           map[string]int
+}
+
+func (m *testMetrics) FetchDuration(info *resolve.FetchInfo, duration time.Duration, err error) {}
+
+func (m *testMetrics) EntityBatchSize(info *resolve.FetchInfo, size int) {}
+
+func (m *testMetrics) SingleFlight(info *resolve.FetchInfo, stats resolve.SingleFlightStats) {}
+
+func (m *testMetrics) PlanCacheLookup(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.planCacheLookups = append(m.planCacheLookups, hit)
+}
+
+func (m *testMetrics) PlanningDuration(duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plannings++
+}
+
+func (m *testMetrics) ResponseSize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responseSizes = append(m.responseSizes, size)
+}
+
+func (m *testMetrics) Errors(code string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[code] += count
+}
+
+func TestExecutionEngine_Metrics(t *testing.T) {
+	metrics := &testMetrics{errors: map[string]int{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := NewExecutionEngine(ctx, abstractlogger.Noop{}, NewConfiguration(graphql.StarwarsSchema(t)), resolve.ResolverOptions{
+		MaxConcurrency: 1024,
+		Metrics:        metrics,
+	})
+	require.NoError(t, err)
+
+	execute := func(query string) (string, error) {
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &graphql.Request{Query: query}, &resultWriter)
+		return resultWriter.String(), err
+	}
+
+	expected := `{"data":{"__schema":{"queryType":{"name":"Query"}}}}`
+	for i := 0; i < 2; i++ {
+		out, err := execute(`{ __schema { queryType { name } } }`)
+		require.NoError(t, err)
+		assert.Equal(t, expected, out)
+	}
+	assert.Equal(t, []bool{false, true}, metrics.planCacheLookups)
+	assert.Equal(t, 1, metrics.plannings)
+	assert.Equal(t, []int{len(expected), len(expected)}, metrics.responseSizes)
+	assert.Empty(t, metrics.errors)
+
+	// validation errors only carry a code in the Apollo compatibility mode
+	_, err = execute(`{ unknown }`)
+	require.Error(t, err)
+	assert.Equal(t, map[string]int{"": 1}, metrics.errors)
+
+	// there's no datasource for the field, so planning fails with an internal error
+	_, err = execute(`{ droid(id: "2000") { name } }`)
+	require.Error(t, err)
+	assert.Equal(t, map[string]int{"": 1, errorcodes.InternalServerError: 1}, metrics.errors)
+	// the failed planning is a cache miss as well
+	assert.Equal(t, []bool{false, true, false}, metrics.planCacheLookups)
+	assert.Equal(t, 1, metrics.plannings)
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/errorcodes"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+)
+
+const (
+	DefaultPersistedQueryCacheSize = 1024
+
+	persistedQueryVersion = 1
+
+	PersistedQueryNotFoundCode     = "PERSISTED_QUERY_NOT_FOUND"
+	PersistedQueryNotSupportedCode = "PERSISTED_QUERY_NOT_SUPPORTED"
+)
+
+var (
+	ErrPersistedQueryNotFound = graphqlerrors.RequestErrors{
+		{
+			Message:    "PersistedQueryNotFound",
+			Extensions: &graphqlerrors.Extensions{Code: PersistedQueryNotFoundCode},
+		},
+	}
+	ErrPersistedQueryNotSupported = graphqlerrors.RequestErrors{
+		{
+			Message:    "PersistedQueryNotSupported",
+			Extensions: &graphqlerrors.Extensions{Code: PersistedQueryNotSupportedCode},
+		},
+	}
+	ErrPersistedQueryHashMismatch = graphqlerrors.RequestErrors{
+		{
+			Message:    "provided sha does not match query",
+			Extensions: &graphqlerrors.Extensions{Code: errorcodes.BadUserInput},
+		},
+	}
+	ErrPersistedQueryUnsupportedVersion = graphqlerrors.RequestErrors{
+		{
+			Message:    "Unsupported persisted query version",
+			Extensions: &graphqlerrors.Extensions{Code: errorcodes.BadUserInput},
+		},
+	}
+)
+
+// PersistedQueryStore stores the query text of Automatic Persisted Queries by their sha256 hash
+// Implementations must be safe for concurrent use
+type PersistedQueryStore interface {
+	// Get returns the query for the hex encoded sha256 hash, found is false if the hash is unknown
+	Get(ctx context.Context, sha256Hash string) (query string, found bool, err error)
+	// Set registers the query for the hex encoded sha256 hash
+	Set(ctx context.Context, sha256Hash string, query string) error
+}
+
+// InMemoryPersistedQueryStore is a PersistedQueryStore which keeps the most recently used queries in memory
+type InMemoryPersistedQueryStore struct {
+	cache *lru.Cache
+}
+
+func NewInMemoryPersistedQueryStore(size int) (*InMemoryPersistedQueryStore, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &InMemoryPersistedQueryStore{
+		cache: cache,
+	}, nil
+}
+
+func (s *InMemoryPersistedQueryStore) Get(_ context.Context, sha256Hash string) (query string, found bool, err error) {
+	cached, ok := s.cache.Get(sha256Hash)
+	if !ok {
+		return "", false, nil
+	}
+	return cached.(string), true, nil
+}
+
+func (s *InMemoryPersistedQueryStore) Set(_ context.Context, sha256Hash string, query string) error {
+	s.cache.Add(sha256Hash, query)
+	return nil
+}
+
+type persistedQueryExtension struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// loadPersistedQuery implements the Automatic Persisted Queries protocol
+// A request with a hash but without a query is resolved from the store, or fails with PersistedQueryNotFound,
+// which tells the client to send the query along with the hash, so it gets registered for subsequent requests
+// The returned hash is set when the query has to be registered, which is done once the operation passed validation
+func (e *ExecutionEngine) loadPersistedQuery(ctx context.Context, operation *graphql.Request) (registerHash string, err error) {
+	if len(operation.Extensions) == 0 {
+		return "", nil
+	}
+	var extensions struct {
+		PersistedQuery *persistedQueryExtension `json:"persistedQuery"`
+	}
+	if err := json.Unmarshal(operation.Extensions, &extensions); err != nil {
+		return "", err
+	}
+	if extensions.PersistedQuery == nil {
+		return "", nil
+	}
+	if e.config.persistedQueryStore == nil {
+		return "", ErrPersistedQueryNotSupported
+	}
+	if extensions.PersistedQuery.Version != persistedQueryVersion {
+		return "", ErrPersistedQueryUnsupportedVersion
+	}
+
+	if operation.Query == "" {
+		query, found, err := e.config.persistedQueryStore.Get(ctx, extensions.PersistedQuery.Sha256Hash)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "", ErrPersistedQueryNotFound
+		}
+		operation.Query = query
+		return "", nil
+	}
+
+	sum := sha256.Sum256([]byte(operation.Query))
+	if hex.EncodeToString(sum[:]) != extensions.PersistedQuery.Sha256Hash {
+		return "", ErrPersistedQueryHashMismatch
+	}
+	return extensions.PersistedQuery.Sha256Hash, nil
+}
+
+func (e *ExecutionEngine) registerPersistedQuery(ctx context.Context, sha256Hash string, operation *graphql.Request) error {
+	if sha256Hash == "" {
+		return nil
+	}
+	return e.config.persistedQueryStore.Set(ctx, sha256Hash, operation.Query)
+}
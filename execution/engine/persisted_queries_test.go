@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+func TestExecutionEngine_PersistedQueries(t *testing.T) {
+	const query = `{ __schema { queryType { name } } }`
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+	extensions := []byte(fmt.Sprintf(`{"persistedQuery":{"version":1,"sha256Hash":"%s"}}`, hash))
+
+	newEngine := func(t *testing.T, store PersistedQueryStore) *ExecutionEngine {
+		t.Helper()
+		engineConf := NewConfiguration(graphql.StarwarsSchema(t))
+		if store != nil {
+			engineConf.SetPersistedQueryStore(store)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		engine, err := NewExecutionEngine(ctx, abstractlogger.Noop{}, engineConf, resolve.ResolverOptions{
+			MaxConcurrency: 1024,
+		})
+		require.NoError(t, err)
+		return engine
+	}
+
+	execute := func(engine *ExecutionEngine, operation *graphql.Request) (string, error) {
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), operation, &resultWriter)
+		return resultWriter.String(), err
+	}
+
+	t.Run("hash only request registers the query on second request", func(t *testing.T) {
+		store, err := NewInMemoryPersistedQueryStore(DefaultPersistedQueryCacheSize)
+		require.NoError(t, err)
+		engine := newEngine(t, store)
+
+		_, err = execute(engine, &graphql.Request{Extensions: extensions})
+		assert.Equal(t, ErrPersistedQueryNotFound, err)
+
+		response, err := execute(engine, &graphql.Request{Query: query, Extensions: extensions})
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__schema":{"queryType":{"name":"Query"}}}}`, response)
+
+		response, err = execute(engine, &graphql.Request{Extensions: extensions})
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__schema":{"queryType":{"name":"Query"}}}}`, response)
+	})
+
+	t.Run("hash mismatch", func(t *testing.T) {
+		store, err := NewInMemoryPersistedQueryStore(DefaultPersistedQueryCacheSize)
+		require.NoError(t, err)
+		engine := newEngine(t, store)
+
+		_, err = execute(engine, &graphql.Request{Query: `{ __typename }`, Extensions: extensions})
+		assert.Equal(t, ErrPersistedQueryHashMismatch, err)
+
+		_, found, err := store.Get(context.Background(), hash)
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("invalid query is not registered", func(t *testing.T) {
+		const invalidQuery = `{ notExisting }`
+		invalidSum := sha256.Sum256([]byte(invalidQuery))
+		invalidHash := hex.EncodeToString(invalidSum[:])
+
+		store, err := NewInMemoryPersistedQueryStore(DefaultPersistedQueryCacheSize)
+		require.NoError(t, err)
+		engine := newEngine(t, store)
+
+		_, err = execute(engine, &graphql.Request{
+			Query:      invalidQuery,
+			Extensions: []byte(fmt.Sprintf(`{"persistedQuery":{"version":1,"sha256Hash":"%s"}}`, invalidHash)),
+		})
+		assert.Error(t, err)
+
+		_, found, err := store.Get(context.Background(), invalidHash)
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		store, err := NewInMemoryPersistedQueryStore(DefaultPersistedQueryCacheSize)
+		require.NoError(t, err)
+		engine := newEngine(t, store)
+
+		_, err = execute(engine, &graphql.Request{
+			Query:      query,
+			Extensions: []byte(fmt.Sprintf(`{"persistedQuery":{"version":2,"sha256Hash":"%s"}}`, hash)),
+		})
+		assert.Equal(t, ErrPersistedQueryUnsupportedVersion, err)
+	})
+
+	t.Run("not supported without store", func(t *testing.T) {
+		engine := newEngine(t, nil)
+
+		_, err := execute(engine, &graphql.Request{Extensions: extensions})
+		assert.Equal(t, ErrPersistedQueryNotSupported, err)
+	})
+
+	t.Run("in memory store evicts least recently used queries", func(t *testing.T) {
+		store, err := NewInMemoryPersistedQueryStore(1)
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		require.NoError(t, store.Set(ctx, "a", "{ a }"))
+		require.NoError(t, store.Set(ctx, "b", "{ b }"))
+
+		_, found, err := store.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, found)
+
+		query, found, err := store.Get(ctx, "b")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "{ b }", query)
+	})
+}
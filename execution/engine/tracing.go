@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+// Span names and attributes of the OpenTelemetry spans of ExecutionEngine.Execute
+// The spans of the phases are children of the SpanNameExecute span, the fetch spans of the resolver are children of the SpanNameResolve span
+const (
+	SpanNameExecute            = "graphql.execute"
+	SpanNameParse              = "graphql.parse"
+	SpanNameNormalize          = "graphql.normalize"
+	SpanNameValidate           = "graphql.validate"
+	SpanNameNormalizeVariables = "graphql.normalize.variables"
+	SpanNameValidateVariables  = "graphql.validate.variables"
+	SpanNamePlan               = "graphql.plan"
+	SpanNameResolve            = "graphql.resolve"
+
+	AttributeOperationName = attribute.Key("graphql.operation.name")
+	AttributeOperationType = attribute.Key("graphql.operation.type")
+)
+
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(resolve.TracerName)
+}
+
+// phase runs an engine phase within a span
+func (e *ExecutionEngine) phase(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	ctx, span := e.tracer.Start(ctx, name)
+	defer span.End()
+	err := fn(ctx)
+	recordSpanError(span, err)
+	return err
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
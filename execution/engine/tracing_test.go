@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+func TestExecutionEngine_Tracing(t *testing.T) {
+	newEngine := func(t *testing.T) (*ExecutionEngine, *tracetest.SpanRecorder) {
+		t.Helper()
+		recorder := tracetest.NewSpanRecorder()
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		engine, err := NewExecutionEngine(ctx, abstractlogger.Noop{}, NewConfiguration(graphql.StarwarsSchema(t)), resolve.ResolverOptions{
+			MaxConcurrency: 1024,
+			TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		})
+		require.NoError(t, err)
+		return engine, recorder
+	}
+
+	// parents returns the name of the parent span by span name
+	parents := func(spans []sdktrace.ReadOnlySpan) map[string]string {
+		names := map[trace.SpanID]string{}
+		for _, span := range spans {
+			names[span.SpanContext().SpanID()] = span.Name()
+		}
+		out := map[string]string{}
+		for _, span := range spans {
+			out[span.Name()] = names[span.Parent().SpanID()]
+		}
+		return out
+	}
+
+	t.Run("spans of the phases and fetches", func(t *testing.T) {
+		engine, recorder := newEngine(t)
+
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &graphql.Request{
+			OperationName: "QueryTypeName",
+			Query:         `query QueryTypeName { __schema { queryType { name } } }`,
+		}, &resultWriter)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__schema":{"queryType":{"name":"Query"}}}}`, resultWriter.String())
+
+		spans := recorder.Ended()
+		assert.Equal(t, map[string]string{
+			SpanNameExecute:            "",
+			SpanNameParse:              SpanNameExecute,
+			SpanNameNormalize:          SpanNameExecute,
+			SpanNameValidate:           SpanNameExecute,
+			SpanNameNormalizeVariables: SpanNameExecute,
+			SpanNamePlan:               SpanNameExecute,
+			SpanNameResolve:            SpanNameExecute,
+			resolve.SpanNameFetch:      SpanNameResolve,
+		}, parents(spans))
+
+		execute := spans[len(spans)-1]
+		assert.Equal(t, SpanNameExecute, execute.Name())
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			AttributeOperationName.String("QueryTypeName"),
+			AttributeOperationType.String("query"),
+		}, execute.Attributes())
+	})
+
+	t.Run("failed phases are recorded", func(t *testing.T) {
+		engine, recorder := newEngine(t)
+
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &graphql.Request{
+			Query: `{ unknown }`,
+		}, &resultWriter)
+		require.Error(t, err)
+
+		spans := recorder.Ended()
+		statuses := map[string]codes.Code{}
+		for _, span := range spans {
+			statuses[span.Name()] = span.Status().Code
+		}
+		assert.Equal(t, map[string]codes.Code{
+			SpanNameExecute:   codes.Error,
+			SpanNameParse:     codes.Unset,
+			SpanNameNormalize: codes.Unset,
+			SpanNameValidate:  codes.Error,
+		}, statuses)
+	})
+}
@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+const (
+	PersistedQueryNotInListCode = "PERSISTED_QUERY_NOT_IN_LIST"
+	QueryNotInSafelistCode      = "QUERY_NOT_IN_SAFELIST"
+
+	apolloPersistedQueryManifestFormat = "apollo-persisted-query-manifest"
+)
+
+var (
+	ErrTrustedDocumentNotFound = graphqlerrors.RequestErrors{
+		{
+			Message:    "PersistedQueryNotInList",
+			Extensions: &graphqlerrors.Extensions{Code: PersistedQueryNotInListCode},
+		},
+	}
+	ErrOperationNotTrusted = graphqlerrors.RequestErrors{
+		{
+			Message:    "The operation body was not found in the trusted documents manifest",
+			Extensions: &graphqlerrors.Extensions{Code: QueryNotInSafelistCode},
+		},
+	}
+)
+
+// TrustedDocumentsManifest maps document ids, usually the sha256 hash of the document, to the document
+type TrustedDocumentsManifest map[string]string
+
+// ParseTrustedDocumentsManifest parses a manifest in the Relay format, a JSON object of document id to document,
+// or in the Apollo persisted query manifest format
+func ParseTrustedDocumentsManifest(reader io.Reader) (TrustedDocumentsManifest, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var apolloManifest struct {
+		Format     string `json:"format"`
+		Version    int    `json:"version"`
+		Operations []struct {
+			ID   string `json:"id"`
+			Body string `json:"body"`
+		} `json:"operations"`
+	}
+	if err = json.Unmarshal(data, &apolloManifest); err == nil && apolloManifest.Format == apolloPersistedQueryManifestFormat {
+		if apolloManifest.Version != 1 {
+			return nil, fmt.Errorf("unsupported apollo persisted query manifest version: %d", apolloManifest.Version)
+		}
+		manifest := make(TrustedDocumentsManifest, len(apolloManifest.Operations))
+		for _, operation := range apolloManifest.Operations {
+			manifest[operation.ID] = operation.Body
+		}
+		return manifest, nil
+	}
+
+	var manifest TrustedDocumentsManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid trusted documents manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+type trustedDocuments struct {
+	manifest TrustedDocumentsManifest
+	// operations are the printed normalized operations of all documents, including their literal values
+	// The full operation is compared instead of a hash, so a hash collision can't make an operation trusted
+	operations map[string]struct{}
+}
+
+// loadTrustedDocuments normalizes all operations of the manifest to build the allow-list
+// and pre-warms the execution plan cache, so the first request of each operation doesn't pay for planning
+func (e *ExecutionEngine) loadTrustedDocuments(manifest TrustedDocumentsManifest) error {
+	e.trustedDocuments = &trustedDocuments{
+		manifest:   manifest,
+		operations: make(map[string]struct{}, len(manifest)),
+	}
+
+	ids := make([]string, 0, len(manifest))
+	for id := range manifest {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		operationNames, err := e.documentOperationNames(manifest[id])
+		if err != nil {
+			return fmt.Errorf("trusted document %s: %w", id, err)
+		}
+		for _, operationName := range operationNames {
+			if err = e.prepareTrustedOperation(manifest[id], operationName); err != nil {
+				return fmt.Errorf("trusted document %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *ExecutionEngine) documentOperationNames(document string) ([]string, error) {
+	doc, report := astparser.ParseGraphqlDocumentString(document)
+	if report.HasErrors() {
+		return nil, report
+	}
+	var operationNames []string
+	for _, rootNode := range doc.RootNodes {
+		if rootNode.Kind != ast.NodeKindOperationDefinition {
+			continue
+		}
+		operationNames = append(operationNames, doc.OperationDefinitionNameString(rootNode.Ref))
+	}
+	if len(operationNames) == 1 {
+		// a single operation can be requested without an operation name
+		return []string{"", operationNames[0]}, nil
+	}
+	return operationNames, nil
+}
+
+func (e *ExecutionEngine) prepareTrustedOperation(document, operationName string) error {
+	operation := &graphql.Request{
+		Query:         document,
+		OperationName: operationName,
+	}
+	err := e.normalizeAndValidate(context.Background(), operation, func(operation *graphql.Request) error {
+		printed, err := astprinter.PrintString(operation.Document())
+		if err != nil {
+			return err
+		}
+		e.trustedDocuments.operations[printed] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	execContext := newInternalExecutionContext()
+	execContext.setContext(context.Background())
+	var report operationreport.Report
+	_ = e.getCachedPlan(execContext, operation.Document(), e.config.schema.Document(), operationName, &report)
+	if report.HasErrors() {
+		return report
+	}
+	return nil
+}
+
+// loadTrustedDocument replaces the query of the request with the document referenced by the documentId
+// Clients sending persisted documents with the APQ protocol are supported as well, the sha256Hash is used as the document id
+func (e *ExecutionEngine) loadTrustedDocument(operation *graphql.Request) error {
+	documentID := operation.DocumentID
+	if documentID == "" && operation.Query == "" {
+		documentID = e.persistedQueryHash(operation)
+	}
+	if documentID == "" {
+		return nil
+	}
+	document, ok := e.trustedDocuments.manifest[documentID]
+	if !ok {
+		return ErrTrustedDocumentNotFound
+	}
+	operation.Query = document
+	return nil
+}
+
+func (e *ExecutionEngine) persistedQueryHash(operation *graphql.Request) string {
+	if len(operation.Extensions) == 0 {
+		return ""
+	}
+	var extensions struct {
+		PersistedQuery *persistedQueryExtension `json:"persistedQuery"`
+	}
+	if err := json.Unmarshal(operation.Extensions, &extensions); err != nil || extensions.PersistedQuery == nil {
+		return ""
+	}
+	return extensions.PersistedQuery.Sha256Hash
+}
+
+// verifyTrustedOperation refuses all operations which are not part of the trusted documents manifest
+// The normalized operation is compared, so the formatting of the document doesn't matter
+// It's compared before the literal values are extracted to variables, so changing a literal of a trusted document isn't trusted
+func (e *ExecutionEngine) verifyTrustedOperation(operation *graphql.Request) error {
+	if e.trustedDocuments == nil {
+		return nil
+	}
+	printed, err := astprinter.PrintString(operation.Document())
+	if err != nil {
+		return err
+	}
+	if _, ok := e.trustedDocuments.operations[printed]; !ok {
+		return ErrOperationNotTrusted
+	}
+	return nil
+}
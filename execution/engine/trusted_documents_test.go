@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+func TestExecutionEngine_TrustedDocuments(t *testing.T) {
+	manifest := TrustedDocumentsManifest{
+		"queryTypeName": `query QueryTypeName { __schema { queryType { name } } }`,
+		"operations":    `query A { __schema { queryType { name } } } query B { __schema { mutationType { name } } }`,
+		"droidType":     `query DroidType { __type(name: "Droid") { name } }`,
+	}
+
+	newEngine := func(t *testing.T) *ExecutionEngine {
+		t.Helper()
+		engineConf := NewConfiguration(graphql.StarwarsSchema(t))
+		engineConf.SetTrustedDocuments(manifest)
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		engine, err := NewExecutionEngine(ctx, abstractlogger.Noop{}, engineConf, resolve.ResolverOptions{
+			MaxConcurrency: 1024,
+		})
+		require.NoError(t, err)
+		return engine
+	}
+
+	execute := func(engine *ExecutionEngine, operation *graphql.Request) (string, error) {
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), operation, &resultWriter)
+		return resultWriter.String(), err
+	}
+
+	t.Run("execute by document id", func(t *testing.T) {
+		engine := newEngine(t)
+
+		response, err := execute(engine, &graphql.Request{DocumentID: "queryTypeName"})
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__schema":{"queryType":{"name":"Query"}}}}`, response)
+
+		response, err = execute(engine, &graphql.Request{DocumentID: "operations", OperationName: "B"})
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__schema":{"mutationType":{"name":"Mutation"}}}}`, response)
+	})
+
+	t.Run("execute by apq hash", func(t *testing.T) {
+		engine := newEngine(t)
+
+		response, err := execute(engine, &graphql.Request{
+			Extensions: []byte(`{"persistedQuery":{"version":1,"sha256Hash":"queryTypeName"}}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__schema":{"queryType":{"name":"Query"}}}}`, response)
+	})
+
+	t.Run("unknown document id", func(t *testing.T) {
+		engine := newEngine(t)
+
+		_, err := execute(engine, &graphql.Request{DocumentID: "unknown"})
+		assert.Equal(t, ErrTrustedDocumentNotFound, err)
+	})
+
+	t.Run("trusted query body is allowed regardless of formatting", func(t *testing.T) {
+		engine := newEngine(t)
+
+		response, err := execute(engine, &graphql.Request{
+			Query: "query QueryTypeName {\n  __schema {\n    queryType {\n      name\n    }\n  }\n}",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__schema":{"queryType":{"name":"Query"}}}}`, response)
+	})
+
+	t.Run("ad-hoc query is refused", func(t *testing.T) {
+		engine := newEngine(t)
+
+		_, err := execute(engine, &graphql.Request{Query: `{ __schema { subscriptionType { name } } }`})
+		assert.Equal(t, ErrOperationNotTrusted, err)
+	})
+
+	t.Run("trusted query with a changed literal is refused", func(t *testing.T) {
+		engine := newEngine(t)
+
+		response, err := execute(engine, &graphql.Request{Query: `query DroidType { __type(name: "Droid") { name } }`})
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__type":{"name":"Droid"}}}`, response)
+
+		_, err = execute(engine, &graphql.Request{Query: `query DroidType { __type(name: "Human") { name } }`})
+		assert.Equal(t, ErrOperationNotTrusted, err)
+	})
+
+	t.Run("plan cache is pre-warmed", func(t *testing.T) {
+		engine := newEngine(t)
+		// QueryTypeName and DroidType with and without operation name normalize to the same operation
+		assert.Equal(t, 4, engine.executionPlanCache.Len())
+	})
+
+	t.Run("invalid document fails engine creation", func(t *testing.T) {
+		engineConf := NewConfiguration(graphql.StarwarsSchema(t))
+		engineConf.SetTrustedDocuments(TrustedDocumentsManifest{"invalid": `{ notExisting }`})
+		_, err := NewExecutionEngine(context.Background(), abstractlogger.Noop{}, engineConf, resolve.ResolverOptions{
+			MaxConcurrency: 1024,
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseTrustedDocumentsManifest(t *testing.T) {
+	t.Run("relay format", func(t *testing.T) {
+		manifest, err := ParseTrustedDocumentsManifest(strings.NewReader(`{"a":"{ a }","b":"{ b }"}`))
+		require.NoError(t, err)
+		assert.Equal(t, TrustedDocumentsManifest{"a": "{ a }", "b": "{ b }"}, manifest)
+	})
+
+	t.Run("apollo format", func(t *testing.T) {
+		manifest, err := ParseTrustedDocumentsManifest(strings.NewReader(`{
+			"format": "apollo-persisted-query-manifest",
+			"version": 1,
+			"operations": [{"id": "a", "name": "A", "type": "query", "body": "query A { a }"}]
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, TrustedDocumentsManifest{"a": "query A { a }"}, manifest)
+	})
+
+	t.Run("unsupported apollo version", func(t *testing.T) {
+		_, err := ParseTrustedDocumentsManifest(strings.NewReader(fmt.Sprintf(`{"format":"%s","version":2,"operations":[]}`, apolloPersistedQueryManifestFormat)))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid manifest", func(t *testing.T) {
+		_, err := ParseTrustedDocumentsManifest(strings.NewReader(`[]`))
+		assert.Error(t, err)
+	})
+}
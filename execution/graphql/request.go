@@ -36,6 +36,9 @@ type Request struct {
 	OperationName string          `json:"operationName"`
 	Variables     json.RawMessage `json:"variables,omitempty"`
 	Query         string          `json:"query"`
+	Extensions    json.RawMessage `json:"extensions,omitempty"`
+	// DocumentID references an operation of the trusted documents manifest instead of sending the Query
+	DocumentID string `json:"documentId,omitempty"`
 
 	document     ast.Document
 	isParsed     bool
@@ -105,6 +108,19 @@ func (r *Request) IsNormalized() bool {
 	return r.isNormalized
 }
 
+// Parse parses the query of the request once, the errors are the same as the errors of Normalize
+func (r *Request) Parse() error {
+	report := r.parseQueryOnce()
+	if !report.HasErrors() {
+		return nil
+	}
+	result, err := NormalizationResultFromReport(report)
+	if err != nil {
+		return err
+	}
+	return result.Errors
+}
+
 func (r *Request) parseQueryOnce() (report operationreport.Report) {
 	if r.isParsed {
 		return report
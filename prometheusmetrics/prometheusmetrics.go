@@ -0,0 +1,213 @@
+// Package prometheusmetrics exposes the metrics of the engine as Prometheus metrics
+// Metrics implements both resolve.Metrics and resolve.Reporter, so it can be passed as ResolverOptions.Metrics and ResolverOptions.Reporter
+package prometheusmetrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+const (
+	labelDataSource = "datasource"
+	labelStatus     = "status"
+	labelShared     = "shared"
+	labelResult     = "result"
+	labelCode       = "code"
+
+	statusSuccess = "success"
+	statusError   = "error"
+	resultHit     = "hit"
+	resultMiss    = "miss"
+)
+
+var (
+	// DefaultSizeBuckets are the default buckets of the response size histogram, from 128 bytes to 8 MiB
+	DefaultSizeBuckets = prometheus.ExponentialBuckets(128, 4, 9)
+	// DefaultBatchSizeBuckets are the default buckets of the entity batch size histogram
+	DefaultBatchSizeBuckets = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000}
+)
+
+type Options struct {
+	// Namespace is the prefix of the metric names, defaults to "graphql"
+	Namespace string
+	// Registerer registers the metrics, defaults to prometheus.DefaultRegisterer
+	Registerer prometheus.Registerer
+	// DurationBuckets are the buckets of the fetch and planning duration histograms in seconds, defaults to prometheus.DefBuckets
+	DurationBuckets []float64
+	// SizeBuckets are the buckets of the response size histogram in bytes, defaults to DefaultSizeBuckets
+	SizeBuckets []float64
+	// BatchSizeBuckets are the buckets of the entity batch size histogram, defaults to DefaultBatchSizeBuckets
+	BatchSizeBuckets []float64
+}
+
+// Metrics records the metrics of the resolver and the execution engine in Prometheus collectors
+type Metrics struct {
+	fetchDuration      *prometheus.HistogramVec
+	entityBatchSize    *prometheus.HistogramVec
+	singleFlight       *prometheus.CounterVec
+	planCacheLookups   *prometheus.CounterVec
+	planningDuration   prometheus.Histogram
+	responseSize       prometheus.Histogram
+	errors             *prometheus.CounterVec
+	subscriptions      prometheus.Gauge
+	triggers           prometheus.Gauge
+	subscriptionUpdate prometheus.Counter
+}
+
+var (
+	_ resolve.Metrics  = (*Metrics)(nil)
+	_ resolve.Reporter = (*Metrics)(nil)
+)
+
+// NewMetrics creates the collectors and registers them with the Registerer of the options
+func NewMetrics(options Options) (*Metrics, error) {
+	if options.Namespace == "" {
+		options.Namespace = "graphql"
+	}
+	if options.Registerer == nil {
+		options.Registerer = prometheus.DefaultRegisterer
+	}
+	if len(options.DurationBuckets) == 0 {
+		options.DurationBuckets = prometheus.DefBuckets
+	}
+	if len(options.SizeBuckets) == 0 {
+		options.SizeBuckets = DefaultSizeBuckets
+	}
+	if len(options.BatchSizeBuckets) == 0 {
+		options.BatchSizeBuckets = DefaultBatchSizeBuckets
+	}
+
+	m := &Metrics{
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: options.Namespace,
+			Name:      "fetch_duration_seconds",
+			Help:      "Duration of the fetches of a datasource.",
+			Buckets:   options.DurationBuckets,
+		}, []string{labelDataSource, labelStatus}),
+		entityBatchSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: options.Namespace,
+			Name:      "entity_batch_size",
+			Help:      "Number of entities requested by an _entities fetch.",
+			Buckets:   options.BatchSizeBuckets,
+		}, []string{labelDataSource}),
+		singleFlight: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      "single_flight_total",
+			Help:      "Number of fetches that were deduplicated by single flight, shared is true if the response of another fetch was reused.",
+		}, []string{labelDataSource, labelShared}),
+		planCacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      "plan_cache_lookups_total",
+			Help:      "Number of lookups in the execution plan cache by result.",
+		}, []string{labelResult}),
+		planningDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: options.Namespace,
+			Name:      "planning_duration_seconds",
+			Help:      "Duration of planning operations that weren't cached.",
+			Buckets:   options.DurationBuckets,
+		}),
+		responseSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: options.Namespace,
+			Name:      "response_size_bytes",
+			Help:      "Size of the responses in bytes.",
+			Buckets:   options.SizeBuckets,
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      "errors_total",
+			Help:      "Number of errors by code.",
+		}, []string{labelCode}),
+		subscriptions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: options.Namespace,
+			Name:      "subscriptions",
+			Help:      "Number of active subscriptions.",
+		}),
+		triggers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: options.Namespace,
+			Name:      "subscription_triggers",
+			Help:      "Number of active subscription triggers.",
+		}),
+		subscriptionUpdate: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      "subscription_updates_total",
+			Help:      "Number of updates sent to subscriptions.",
+		}),
+	}
+
+	for _, collector := range []prometheus.Collector{
+		m.fetchDuration, m.entityBatchSize, m.singleFlight, m.planCacheLookups, m.planningDuration,
+		m.responseSize, m.errors, m.subscriptions, m.triggers, m.subscriptionUpdate,
+	} {
+		if err := options.Registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func dataSourceName(info *resolve.FetchInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.DataSourceName
+}
+
+func (m *Metrics) FetchDuration(info *resolve.FetchInfo, duration time.Duration, err error) {
+	status := statusSuccess
+	if err != nil {
+		status = statusError
+	}
+	m.fetchDuration.WithLabelValues(dataSourceName(info), status).Observe(duration.Seconds())
+}
+
+func (m *Metrics) EntityBatchSize(info *resolve.FetchInfo, size int) {
+	m.entityBatchSize.WithLabelValues(dataSourceName(info)).Observe(float64(size))
+}
+
+func (m *Metrics) SingleFlight(info *resolve.FetchInfo, stats resolve.SingleFlightStats) {
+	m.singleFlight.WithLabelValues(dataSourceName(info), strconv.FormatBool(stats.SingleFlightSharedResponse)).Inc()
+}
+
+func (m *Metrics) PlanCacheLookup(hit bool) {
+	result := resultMiss
+	if hit {
+		result = resultHit
+	}
+	m.planCacheLookups.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) PlanningDuration(duration time.Duration) {
+	m.planningDuration.Observe(duration.Seconds())
+}
+
+func (m *Metrics) ResponseSize(size int) {
+	m.responseSize.Observe(float64(size))
+}
+
+func (m *Metrics) Errors(code string, count int) {
+	m.errors.WithLabelValues(code).Add(float64(count))
+}
+
+func (m *Metrics) SubscriptionUpdateSent() {
+	m.subscriptionUpdate.Inc()
+}
+
+func (m *Metrics) SubscriptionCountInc(count int) {
+	m.subscriptions.Add(float64(count))
+}
+
+func (m *Metrics) SubscriptionCountDec(count int) {
+	m.subscriptions.Sub(float64(count))
+}
+
+func (m *Metrics) TriggerCountInc(count int) {
+	m.triggers.Add(float64(count))
+}
+
+func (m *Metrics) TriggerCountDec(count int) {
+	m.triggers.Sub(float64(count))
+}
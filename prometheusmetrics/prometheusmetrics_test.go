@@ -0,0 +1,105 @@
+package prometheusmetrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/errorcodes"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, err := NewMetrics(Options{
+		Namespace:        "test",
+		Registerer:       registry,
+		DurationBuckets:  []float64{0.1, 1},
+		SizeBuckets:      []float64{100},
+		BatchSizeBuckets: []float64{10},
+	})
+	require.NoError(t, err)
+
+	users := &resolve.FetchInfo{DataSourceID: "0", DataSourceName: "users"}
+	metrics.FetchDuration(users, 50*time.Millisecond, nil)
+	metrics.FetchDuration(users, 500*time.Millisecond, errors.New("failed"))
+	metrics.EntityBatchSize(users, 3)
+	metrics.SingleFlight(users, resolve.SingleFlightStats{SingleFlightUsed: true, SingleFlightSharedResponse: true})
+	metrics.PlanCacheLookup(false)
+	metrics.PlanCacheLookup(true)
+	metrics.PlanCacheLookup(true)
+	metrics.PlanningDuration(2 * time.Second)
+	metrics.ResponseSize(42)
+	metrics.Errors(errorcodes.GraphQLValidationFailed, 2)
+	metrics.Errors("", 1)
+	metrics.SubscriptionCountInc(2)
+	metrics.SubscriptionCountDec(1)
+	metrics.TriggerCountInc(1)
+	metrics.SubscriptionUpdateSent()
+
+	expected := `
+# HELP test_entity_batch_size Number of entities requested by an _entities fetch.
+# TYPE test_entity_batch_size histogram
+test_entity_batch_size_bucket{datasource="users",le="10"} 1
+test_entity_batch_size_bucket{datasource="users",le="+Inf"} 1
+test_entity_batch_size_sum{datasource="users"} 3
+test_entity_batch_size_count{datasource="users"} 1
+# HELP test_errors_total Number of errors by code.
+# TYPE test_errors_total counter
+test_errors_total{code=""} 1
+test_errors_total{code="GRAPHQL_VALIDATION_FAILED"} 2
+# HELP test_fetch_duration_seconds Duration of the fetches of a datasource.
+# TYPE test_fetch_duration_seconds histogram
+test_fetch_duration_seconds_bucket{datasource="users",status="error",le="0.1"} 0
+test_fetch_duration_seconds_bucket{datasource="users",status="error",le="1"} 1
+test_fetch_duration_seconds_bucket{datasource="users",status="error",le="+Inf"} 1
+test_fetch_duration_seconds_sum{datasource="users",status="error"} 0.5
+test_fetch_duration_seconds_count{datasource="users",status="error"} 1
+test_fetch_duration_seconds_bucket{datasource="users",status="success",le="0.1"} 1
+test_fetch_duration_seconds_bucket{datasource="users",status="success",le="1"} 1
+test_fetch_duration_seconds_bucket{datasource="users",status="success",le="+Inf"} 1
+test_fetch_duration_seconds_sum{datasource="users",status="success"} 0.05
+test_fetch_duration_seconds_count{datasource="users",status="success"} 1
+# HELP test_plan_cache_lookups_total Number of lookups in the execution plan cache by result.
+# TYPE test_plan_cache_lookups_total counter
+test_plan_cache_lookups_total{result="hit"} 2
+test_plan_cache_lookups_total{result="miss"} 1
+# HELP test_planning_duration_seconds Duration of planning operations that weren't cached.
+# TYPE test_planning_duration_seconds histogram
+test_planning_duration_seconds_bucket{le="0.1"} 0
+test_planning_duration_seconds_bucket{le="1"} 0
+test_planning_duration_seconds_bucket{le="+Inf"} 1
+test_planning_duration_seconds_sum 2
+test_planning_duration_seconds_count 1
+# HELP test_response_size_bytes Size of the responses in bytes.
+# TYPE test_response_size_bytes histogram
+test_response_size_bytes_bucket{le="100"} 1
+test_response_size_bytes_bucket{le="+Inf"} 1
+test_response_size_bytes_sum 42
+test_response_size_bytes_count 1
+# HELP test_single_flight_total Number of fetches that were deduplicated by single flight, shared is true if the response of another fetch was reused.
+# TYPE test_single_flight_total counter
+test_single_flight_total{datasource="users",shared="true"} 1
+# HELP test_subscription_triggers Number of active subscription triggers.
+# TYPE test_subscription_triggers gauge
+test_subscription_triggers 1
+# HELP test_subscription_updates_total Number of updates sent to subscriptions.
+# TYPE test_subscription_updates_total counter
+test_subscription_updates_total 1
+# HELP test_subscriptions Number of active subscriptions.
+# TYPE test_subscriptions gauge
+test_subscriptions 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected)))
+
+	t.Run("registering twice fails", func(t *testing.T) {
+		_, err := NewMetrics(Options{Namespace: "test", Registerer: registry})
+		assert.Error(t, err)
+	})
+}
@@ -92,6 +92,12 @@ func (d *Document) InputObjectTypeDefinitionInputValueDefinitionByName(definitio
 	return -1
 }
 
+// InputObjectTypeDefinitionIsOneOf returns true if the input object is annotated with @oneOf,
+// so exactly one of its fields must be set to a non-null value
+func (d *Document) InputObjectTypeDefinitionIsOneOf(ref int) bool {
+	return d.InputObjectTypeDefinitions[ref].Directives.HasDirectiveByName(d, "oneOf")
+}
+
 func (d *Document) AddInputObjectTypeDefinition(definition InputObjectTypeDefinition) (ref int) {
 	d.InputObjectTypeDefinitions = append(d.InputObjectTypeDefinitions, definition)
 	return len(d.InputObjectTypeDefinitions) - 1
@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"bytes"
+
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafebytes"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/position"
 )
@@ -39,6 +41,15 @@ func (d *Document) ScalarTypeDefinitionHasDirectives(ref int) bool {
 	return d.ScalarTypeDefinitions[ref].HasDirectives
 }
 
+func (d *Document) ScalarTypeDefinitionDirectiveByName(ref int, directiveName ByteSlice) (directiveRef int, exists bool) {
+	for _, i := range d.ScalarTypeDefinitions[ref].Directives.Refs {
+		if bytes.Equal(directiveName, d.DirectiveNameBytes(i)) {
+			return i, true
+		}
+	}
+	return
+}
+
 func (d *Document) AddScalarTypeDefinition(definition ScalarTypeDefinition) (ref int) {
 	d.ScalarTypeDefinitions = append(d.ScalarTypeDefinitions, definition)
 	return len(d.ScalarTypeDefinitions) - 1
@@ -1,6 +1,9 @@
 package ast
 
-import "github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/position"
+import (
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafebytes"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/position"
+)
 
 type SchemaDefinition struct {
 	Description                  Description       // optional
@@ -14,6 +17,17 @@ func (s *SchemaDefinition) AddRootOperationTypeDefinitionRefs(refs ...int) {
 	s.RootOperationTypeDefinitions.Refs = append(s.RootOperationTypeDefinitions.Refs, refs...)
 }
 
+func (d *Document) SchemaDefinitionDescriptionBytes(ref int) ByteSlice {
+	if !d.SchemaDefinitions[ref].Description.IsDefined {
+		return nil
+	}
+	return d.Input.ByteSlice(d.SchemaDefinitions[ref].Description.Content)
+}
+
+func (d *Document) SchemaDefinitionDescriptionString(ref int) string {
+	return unsafebytes.BytesToString(d.SchemaDefinitionDescriptionBytes(ref))
+}
+
 func (d *Document) HasSchemaDefinition() bool {
 	return d.SchemaDefinitionRef() != InvalidRef
 }
@@ -450,6 +450,41 @@ func (v *valuesVisitor) valueSatisfiesInputObjectTypeDefinition(value ast.Value,
 		return false
 	}
 
+	if v.definition.InputObjectTypeDefinitionIsOneOf(inputObjectTypeDefinition) {
+		return v.objectValueSatisfiesOneOf(value, inputObjectTypeDefinition)
+	}
+
+	return true
+}
+
+// objectValueSatisfiesOneOf checks that exactly one field of a @oneOf input object is set
+// and that its value can't be null
+func (v *valuesVisitor) objectValueSatisfiesOneOf(value ast.Value, inputObjectTypeDefinition int) bool {
+	objectName := v.definition.InputObjectTypeDefinitionNameBytes(inputObjectTypeDefinition)
+
+	if len(v.operation.ObjectValues[value.Ref].Refs) != 1 {
+		v.Report.AddExternalError(operationreport.ErrOneOfInputObjectFieldCount(objectName, value.Position))
+		return false
+	}
+
+	objectField := v.operation.ObjectValues[value.Ref].Refs[0]
+	fieldValue := v.operation.ObjectFieldValue(objectField)
+
+	switch fieldValue.Kind {
+	case ast.ValueKindNull:
+		v.Report.AddExternalError(operationreport.ErrOneOfInputObjectNullField(objectName, v.operation.ObjectFieldNameBytes(objectField), fieldValue.Position))
+		return false
+	case ast.ValueKindVariable:
+		_, variableTypeRef, _, ok := v.operationVariableType(fieldValue.Ref)
+		if !ok {
+			return false
+		}
+		if v.operation.Types[variableTypeRef].TypeKind != ast.TypeKindNonNull {
+			v.Report.AddExternalError(operationreport.ErrOneOfInputObjectNullableVariable(v.operation.VariableValueNameBytes(fieldValue.Ref), objectName, fieldValue.Position))
+			return false
+		}
+	}
+
 	return true
 }
 
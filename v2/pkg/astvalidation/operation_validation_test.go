@@ -3099,6 +3099,50 @@ func TestExecutionValidation(t *testing.T) {
 					Values(), Invalid, withValidationErrors(`String cannot represent a non string value: 123`))
 			})
 		})
+		t.Run("OneOf Input Objects", func(t *testing.T) {
+			t.Run("exactly one field", func(t *testing.T) {
+				runWithDefinition(t, oneOfDefinition, `{
+									pet(by: { name: "Fido" })
+								}`,
+					Values(), Valid)
+			})
+			t.Run("no field", func(t *testing.T) {
+				runWithDefinition(t, oneOfDefinition, `{
+									pet(by: {})
+								}`,
+					Values(), Invalid, withValidationErrors(`OneOf Input Object "PetBy" must specify exactly one key.`))
+			})
+			t.Run("more than one field", func(t *testing.T) {
+				runWithDefinition(t, oneOfDefinition, `{
+									pet(by: { name: "Fido", id: 1 })
+								}`,
+					Values(), Invalid, withValidationErrors(`OneOf Input Object "PetBy" must specify exactly one key.`))
+			})
+			t.Run("null field", func(t *testing.T) {
+				runWithDefinition(t, oneOfDefinition, `{
+									pet(by: { name: null })
+								}`,
+					Values(), Invalid, withValidationErrors(`Field "PetBy.name" must be non-null.`))
+			})
+			t.Run("non-nullable variable", func(t *testing.T) {
+				runWithDefinition(t, oneOfDefinition, `query ($name: String!) {
+									pet(by: { name: $name })
+								}`,
+					Values(), Valid)
+			})
+			t.Run("nullable variable", func(t *testing.T) {
+				runWithDefinition(t, oneOfDefinition, `query ($name: String) {
+									pet(by: { name: $name })
+								}`,
+					Values(), Invalid, withValidationErrors(`Variable "$name" must be non-nullable to be used for OneOf Input Object "PetBy".`))
+			})
+			t.Run("variable default value", func(t *testing.T) {
+				runWithDefinition(t, oneOfDefinition, `query ($by: PetBy = { name: "Fido", id: 1 }) {
+									pet(by: $by)
+								}`,
+					Values(), Invalid, withDisableNormalization(), withValidationErrors(`OneOf Input Object "PetBy" must specify exactly one key.`))
+			})
+		})
 		t.Run("complex nested validation", func(t *testing.T) {
 			t.Run("complex nested 1", func(t *testing.T) {
 				run(t, `
@@ -4917,6 +4961,22 @@ enum __TypeKind {
     NON_NULL
 }`
 
+const oneOfDefinition = `
+scalar String
+scalar ID
+scalar Int
+directive @oneOf on INPUT_OBJECT
+schema {
+	query: Query
+}
+type Query {
+	pet(by: PetBy!): String
+}
+input PetBy @oneOf {
+	id: ID
+	name: String
+}`
+
 const boxDefinition = `
 scalar String
 scalar ID
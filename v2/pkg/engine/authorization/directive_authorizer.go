@@ -0,0 +1,303 @@
+package authorization
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+const (
+	AuthenticatedDirectiveName  = "authenticated"
+	RequiresScopesDirectiveName = "requiresScopes"
+
+	defaultScopesClaim = "scope"
+
+	reasonNotAuthenticated = "not authenticated"
+	reasonMissingScopes    = "missing required scopes"
+)
+
+type Options struct {
+	// ScopesClaim is the claim holding the scopes of the client,
+	// either a space delimited string or a list of strings. Defaults to "scope".
+	ScopesClaim string
+}
+
+// DirectiveAuthorizer is a resolve.Authorizer enforcing the @authenticated and @requiresScopes directives of a supergraph.
+//
+// A field requires an authenticated client, i.e. non nil resolve.Context.Claims, if the field, the field of an interface it implements,
+// or the type returned by the field is annotated with @authenticated.
+// Interface fields require the rules of their implementations, fields returning a union or interface require the rules of its possible types.
+// @requiresScopes(scopes: [[...]]) additionally requires the client to be granted all scopes of at least one of the scope sets.
+// Requirements of the field, its interface fields and its type are all enforced.
+//
+// Mutations and subscriptions are rejected before the fetch, fields of queries are set to null with an error.
+// The fields need HasAuthorizationRule to be planned with authorization, see FieldConfigurations.
+type DirectiveAuthorizer struct {
+	rules       map[coordinate]rule
+	scopesClaim string
+}
+
+type coordinate struct {
+	typeName, fieldName string
+}
+
+type rule struct {
+	authenticated bool
+	// scopes are the alternative scope sets, all scopes of at least one set must be granted
+	scopes [][]string
+}
+
+func (r rule) isEmpty() bool {
+	return !r.authenticated && len(r.scopes) == 0
+}
+
+// and returns the rule requiring both rules
+func (r rule) and(other rule) rule {
+	out := rule{
+		authenticated: r.authenticated || other.authenticated,
+	}
+	switch {
+	case len(r.scopes) == 0:
+		out.scopes = other.scopes
+	case len(other.scopes) == 0:
+		out.scopes = r.scopes
+	default:
+		out.scopes = make([][]string, 0, len(r.scopes)*len(other.scopes))
+		for _, left := range r.scopes {
+			for _, right := range other.scopes {
+				scopes := slices.Clone(left)
+				for _, scope := range right {
+					if !slices.Contains(scopes, scope) {
+						scopes = append(scopes, scope)
+					}
+				}
+				out.scopes = append(out.scopes, scopes)
+			}
+		}
+	}
+	return out
+}
+
+// NewDirectiveAuthorizer reads the authorization directives of the fields of object and interface types of the definition
+func NewDirectiveAuthorizer(definition *ast.Document, options Options) (*DirectiveAuthorizer, error) {
+	authorizer := &DirectiveAuthorizer{
+		rules:       map[coordinate]rule{},
+		scopesClaim: options.ScopesClaim,
+	}
+	if authorizer.scopesClaim == "" {
+		authorizer.scopesClaim = defaultScopesClaim
+	}
+
+	// fieldRules are the rules of the field definitions and of the types they return
+	fieldRules := map[coordinate]rule{}
+	for _, node := range definition.RootNodes {
+		if node.Kind != ast.NodeKindObjectTypeDefinition && node.Kind != ast.NodeKindInterfaceTypeDefinition {
+			continue
+		}
+		typeName := node.NameString(definition)
+		for _, fieldRef := range definition.NodeFieldDefinitions(node) {
+			fieldName := definition.FieldDefinitionNameString(fieldRef)
+			fieldRule, err := fieldDefinitionRule(definition, fieldRef)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", typeName, fieldName, err)
+			}
+			fieldRules[coordinate{typeName: typeName, fieldName: fieldName}] = fieldRule
+		}
+	}
+
+	// an object field requires the rules of the interface fields it implements
+	// an interface field requires the rules of all its implementations, because the field is planned on the interface
+	// the resolver authorizes the field with the __typename of the object, so the rules of the concrete type apply when it's known
+	for _, node := range definition.RootNodes {
+		var relatedTypeNames []string
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			for _, interfaceRef := range definition.NodeInterfaceRefs(node) {
+				relatedTypeNames = append(relatedTypeNames, definition.ResolveTypeNameString(interfaceRef))
+			}
+		case ast.NodeKindInterfaceTypeDefinition:
+			relatedTypeNames, _ = definition.InterfaceTypeDefinitionImplementedByObjectWithNames(node.Ref)
+		default:
+			continue
+		}
+		typeName := node.NameString(definition)
+		for _, fieldRef := range definition.NodeFieldDefinitions(node) {
+			fieldName := definition.FieldDefinitionNameString(fieldRef)
+			fieldRule := fieldRules[coordinate{typeName: typeName, fieldName: fieldName}]
+			for _, relatedTypeName := range relatedTypeNames {
+				fieldRule = fieldRule.and(fieldRules[coordinate{typeName: relatedTypeName, fieldName: fieldName}])
+			}
+			if !fieldRule.isEmpty() {
+				authorizer.rules[coordinate{typeName: typeName, fieldName: fieldName}] = fieldRule
+			}
+		}
+	}
+	return authorizer, nil
+}
+
+// fieldDefinitionRule combines the directives of the field definition and of the type it returns
+func fieldDefinitionRule(definition *ast.Document, fieldRef int) (rule, error) {
+	fieldRule, err := directivesRule(definition, definition.FieldDefinitionDirectives(fieldRef))
+	if err != nil {
+		return rule{}, err
+	}
+	typeNode, ok := definition.Index.FirstNodeByNameBytes(definition.ResolveTypeNameBytes(definition.FieldDefinitionType(fieldRef)))
+	if !ok {
+		return fieldRule, nil
+	}
+	typeRule, err := typeDefinitionRule(definition, typeNode)
+	if err != nil {
+		return rule{}, err
+	}
+	return fieldRule.and(typeRule), nil
+}
+
+// typeDefinitionRule is the rule of the directives of the type
+// A union or interface requires the rules of all its possible object types, as any of them can be returned
+func typeDefinitionRule(definition *ast.Document, typeNode ast.Node) (rule, error) {
+	typeRule, err := directivesRule(definition, definition.NodeDirectives(typeNode))
+	if err != nil {
+		return rule{}, fmt.Errorf("type %s: %w", typeNode.NameString(definition), err)
+	}
+	var possibleTypeNames []string
+	switch typeNode.Kind {
+	case ast.NodeKindUnionTypeDefinition:
+		possibleTypeNames, _ = definition.UnionTypeDefinitionMemberTypeNames(typeNode.Ref)
+	case ast.NodeKindInterfaceTypeDefinition:
+		possibleTypeNames, _ = definition.InterfaceTypeDefinitionImplementedByObjectWithNames(typeNode.Ref)
+	}
+	for _, possibleTypeName := range possibleTypeNames {
+		possibleTypeNode, ok := definition.Index.FirstNodeByNameStr(possibleTypeName)
+		if !ok {
+			continue
+		}
+		possibleTypeRule, err := directivesRule(definition, definition.NodeDirectives(possibleTypeNode))
+		if err != nil {
+			return rule{}, fmt.Errorf("type %s: %w", possibleTypeName, err)
+		}
+		typeRule = typeRule.and(possibleTypeRule)
+	}
+	return typeRule, nil
+}
+
+func directivesRule(definition *ast.Document, directiveRefs []int) (rule, error) {
+	var out rule
+	for _, ref := range directiveRefs {
+		switch definition.DirectiveNameString(ref) {
+		case AuthenticatedDirectiveName:
+			out.authenticated = true
+		case RequiresScopesDirectiveName:
+			value, ok := definition.DirectiveArgumentValueByName(ref, []byte("scopes"))
+			if !ok {
+				return rule{}, fmt.Errorf("@%s is missing the scopes argument", RequiresScopesDirectiveName)
+			}
+			scopesJSON, err := definition.ValueToJSON(value)
+			if err != nil {
+				return rule{}, err
+			}
+			var scopes [][]string
+			if err = json.Unmarshal(scopesJSON, &scopes); err != nil {
+				return rule{}, fmt.Errorf("@%s has invalid scopes %s: %w", RequiresScopesDirectiveName, scopesJSON, err)
+			}
+			out = out.and(rule{authenticated: true, scopes: scopes})
+		}
+	}
+	return out, nil
+}
+
+// FieldConfigurations sets HasAuthorizationRule for all fields with authorization directives, missing field configurations are added
+func (a *DirectiveAuthorizer) FieldConfigurations(fields plan.FieldConfigurations) plan.FieldConfigurations {
+	coordinates := make([]coordinate, 0, len(a.rules))
+	for c := range a.rules {
+		coordinates = append(coordinates, c)
+	}
+	slices.SortFunc(coordinates, func(a, b coordinate) int {
+		if c := strings.Compare(a.typeName, b.typeName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.fieldName, b.fieldName)
+	})
+
+	for _, c := range coordinates {
+		if field := fields.ForTypeField(c.typeName, c.fieldName); field != nil {
+			field.HasAuthorizationRule = true
+			continue
+		}
+		fields = append(fields, plan.FieldConfiguration{
+			TypeName:             c.typeName,
+			FieldName:            c.fieldName,
+			HasAuthorizationRule: true,
+		})
+	}
+	return fields
+}
+
+func (a *DirectiveAuthorizer) AuthorizePreFetch(ctx *resolve.Context, dataSourceID string, input json.RawMessage, coordinate resolve.GraphCoordinate) (result *resolve.AuthorizationDeny, err error) {
+	return a.authorize(ctx, coordinate), nil
+}
+
+func (a *DirectiveAuthorizer) AuthorizeObjectField(ctx *resolve.Context, dataSourceID string, object json.RawMessage, coordinate resolve.GraphCoordinate) (result *resolve.AuthorizationDeny, err error) {
+	return a.authorize(ctx, coordinate), nil
+}
+
+func (a *DirectiveAuthorizer) HasResponseExtensionData(ctx *resolve.Context) bool {
+	return false
+}
+
+func (a *DirectiveAuthorizer) RenderResponseExtension(ctx *resolve.Context, out io.Writer) error {
+	return nil
+}
+
+func (a *DirectiveAuthorizer) authorize(ctx *resolve.Context, gc resolve.GraphCoordinate) *resolve.AuthorizationDeny {
+	fieldRule, ok := a.rules[coordinate{typeName: gc.TypeName, fieldName: gc.FieldName}]
+	if !ok {
+		return nil
+	}
+	if ctx.Claims == nil {
+		return &resolve.AuthorizationDeny{Reason: reasonNotAuthenticated}
+	}
+	if len(fieldRule.scopes) == 0 {
+		return nil
+	}
+	granted := a.grantedScopes(ctx.Claims)
+	for _, scopes := range fieldRule.scopes {
+		if containsAll(granted, scopes) {
+			return nil
+		}
+	}
+	return &resolve.AuthorizationDeny{Reason: reasonMissingScopes}
+}
+
+func (a *DirectiveAuthorizer) grantedScopes(claims map[string]any) []string {
+	switch scopes := claims[a.scopesClaim].(type) {
+	case string:
+		return strings.Fields(scopes)
+	case []string:
+		return scopes
+	case []any:
+		out := make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			if s, ok := scope.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func containsAll(granted, required []string) bool {
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
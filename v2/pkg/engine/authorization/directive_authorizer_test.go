@@ -0,0 +1,311 @@
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/mock_datasource"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/postprocess"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+const definition = `
+	directive @authenticated on FIELD_DEFINITION | OBJECT | INTERFACE | SCALAR | ENUM
+	directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION | OBJECT | INTERFACE | SCALAR | ENUM
+
+	type Query {
+		me: User @authenticated
+		products: [Product!]!
+		node: Node
+		secret: Secret
+		search: [SearchResult]
+	}
+
+	type Mutation {
+		deleteProduct(id: ID!): Boolean @requiresScopes(scopes: [["write:products"], ["admin"]])
+	}
+
+	type User {
+		id: ID!
+		name: String!
+		email: String @requiresScopes(scopes: [["read:email"]])
+	}
+
+	interface Node {
+		id: ID!
+		internal: String @requiresScopes(scopes: [["admin"]])
+		notes: String
+	}
+
+	type Product implements Node {
+		id: ID!
+		internal: String
+		notes: String @authenticated
+		name: String!
+		price: Float @authenticated
+		stock: Int @requiresScopes(scopes: [["inventory"]])
+	}
+
+	type Secret @authenticated {
+		value: String
+	}
+
+	union SearchResult = Product | Secret
+`
+
+func TestNewDirectiveAuthorizer(t *testing.T) {
+	t.Run("rules", func(t *testing.T) {
+		authorizer := newAuthorizer(t, definition)
+		assert.Equal(t, map[coordinate]rule{
+			{typeName: "Query", fieldName: "me"}:               {authenticated: true},
+			{typeName: "Query", fieldName: "secret"}:           {authenticated: true},
+			{typeName: "Query", fieldName: "search"}:           {authenticated: true},
+			{typeName: "Mutation", fieldName: "deleteProduct"}: {authenticated: true, scopes: [][]string{{"write:products"}, {"admin"}}},
+			{typeName: "User", fieldName: "email"}:             {authenticated: true, scopes: [][]string{{"read:email"}}},
+			{typeName: "Node", fieldName: "internal"}:          {authenticated: true, scopes: [][]string{{"admin"}}},
+			{typeName: "Node", fieldName: "notes"}:             {authenticated: true},
+			{typeName: "Product", fieldName: "internal"}:       {authenticated: true, scopes: [][]string{{"admin"}}},
+			{typeName: "Product", fieldName: "notes"}:          {authenticated: true},
+			{typeName: "Product", fieldName: "price"}:          {authenticated: true},
+			{typeName: "Product", fieldName: "stock"}:          {authenticated: true, scopes: [][]string{{"inventory"}}},
+		}, authorizer.rules)
+	})
+
+	t.Run("scopes of field and interface are combined", func(t *testing.T) {
+		authorizer := newAuthorizer(t, `
+			directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION
+			interface Node { id: ID! @requiresScopes(scopes: [["a"], ["b"]]) }
+			type Product implements Node { id: ID! @requiresScopes(scopes: [["c"]]) }
+		`)
+		assert.Equal(t, rule{authenticated: true, scopes: [][]string{{"c", "a"}, {"c", "b"}}}, authorizer.rules[coordinate{typeName: "Product", fieldName: "id"}])
+	})
+
+	t.Run("interface fields require the rules of all implementations", func(t *testing.T) {
+		authorizer := newAuthorizer(t, `
+			directive @authenticated on FIELD_DEFINITION | OBJECT
+			directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION | OBJECT
+			interface Node { id: ID! }
+			type Product implements Node { id: ID! @requiresScopes(scopes: [["a"]]) }
+			type User implements Node { id: ID! @authenticated }
+			type Review implements Node { id: ID! }
+		`)
+		assert.Equal(t, rule{authenticated: true, scopes: [][]string{{"a"}}}, authorizer.rules[coordinate{typeName: "Node", fieldName: "id"}])
+		assert.Equal(t, rule{authenticated: true, scopes: [][]string{{"a"}}}, authorizer.rules[coordinate{typeName: "Product", fieldName: "id"}])
+		assert.Equal(t, rule{authenticated: true}, authorizer.rules[coordinate{typeName: "User", fieldName: "id"}])
+		assert.NotContains(t, authorizer.rules, coordinate{typeName: "Review", fieldName: "id"})
+	})
+
+	t.Run("fields returning abstract types require the rules of the possible types", func(t *testing.T) {
+		authorizer := newAuthorizer(t, `
+			directive @authenticated on FIELD_DEFINITION | OBJECT
+			directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION | OBJECT
+			type Query { search: [SearchResult] node: Node }
+			union SearchResult = Product | Secret
+			interface Node { id: ID! }
+			type Product implements Node { id: ID! }
+			type Secret implements Node @requiresScopes(scopes: [["secrets"]]) { id: ID! }
+		`)
+		assert.Equal(t, rule{authenticated: true, scopes: [][]string{{"secrets"}}}, authorizer.rules[coordinate{typeName: "Query", fieldName: "search"}])
+		assert.Equal(t, rule{authenticated: true, scopes: [][]string{{"secrets"}}}, authorizer.rules[coordinate{typeName: "Query", fieldName: "node"}])
+	})
+
+	t.Run("invalid scopes", func(t *testing.T) {
+		def := unsafeparser.ParseGraphqlDocumentString(`
+			directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION
+			type Query { me: String @requiresScopes(scopes: ["read"]) }
+		`)
+		_, err := NewDirectiveAuthorizer(&def, Options{})
+		assert.ErrorContains(t, err, `Query.me: @requiresScopes has invalid scopes ["read"]`)
+	})
+
+	t.Run("field configurations", func(t *testing.T) {
+		authorizer := newAuthorizer(t, definition)
+		fields := authorizer.FieldConfigurations(plan.FieldConfigurations{
+			{TypeName: "Query", FieldName: "me", Path: []string{"user"}},
+		})
+		assert.Equal(t, plan.FieldConfigurations{
+			{TypeName: "Query", FieldName: "me", Path: []string{"user"}, HasAuthorizationRule: true},
+			{TypeName: "Mutation", FieldName: "deleteProduct", HasAuthorizationRule: true},
+			{TypeName: "Node", FieldName: "internal", HasAuthorizationRule: true},
+			{TypeName: "Node", FieldName: "notes", HasAuthorizationRule: true},
+			{TypeName: "Product", FieldName: "internal", HasAuthorizationRule: true},
+			{TypeName: "Product", FieldName: "notes", HasAuthorizationRule: true},
+			{TypeName: "Product", FieldName: "price", HasAuthorizationRule: true},
+			{TypeName: "Product", FieldName: "stock", HasAuthorizationRule: true},
+			{TypeName: "Query", FieldName: "search", HasAuthorizationRule: true},
+			{TypeName: "Query", FieldName: "secret", HasAuthorizationRule: true},
+			{TypeName: "User", FieldName: "email", HasAuthorizationRule: true},
+		}, fields)
+	})
+}
+
+func TestDirectiveAuthorizer(t *testing.T) {
+	authorizer := newAuthorizer(t, definition)
+
+	factory, err := mock_datasource.NewFactory(context.Background())
+	require.NoError(t, err)
+	keys := plan.FederationMetaData{
+		Keys: plan.FederationFieldConfigurations{
+			{TypeName: "Product", SelectionSet: "id"},
+		},
+	}
+	products, err := plan.NewDataSourceConfiguration[mock_datasource.Configuration](
+		"products",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"me", "products", "node", "secret", "search"}},
+				{TypeName: "Mutation", FieldNames: []string{"deleteProduct"}},
+				{TypeName: "Product", FieldNames: []string{"id", "internal", "notes", "name", "price"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"id", "name", "email"}},
+				{TypeName: "Node", FieldNames: []string{"id", "internal", "notes"}},
+				{TypeName: "Secret", FieldNames: []string{"value"}},
+			},
+			FederationMetaData: keys,
+		},
+		mock_datasource.Configuration{
+			ListLength: 1,
+			Overrides: map[string]json.RawMessage{
+				"User.name":              json.RawMessage(`"Jens"`),
+				"User.email":             json.RawMessage(`"jens@example.com"`),
+				"Product.id":             json.RawMessage(`"1"`),
+				"Product.name":           json.RawMessage(`"Trilby"`),
+				"Product.price":          json.RawMessage(`10`),
+				"Product.internal":       json.RawMessage(`"internal"`),
+				"Product.notes":          json.RawMessage(`"notes"`),
+				"Secret.value":           json.RawMessage(`"secret"`),
+				"Mutation.deleteProduct": json.RawMessage(`true`),
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	inventory, err := plan.NewDataSourceConfiguration[mock_datasource.Configuration](
+		"inventory",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Product", FieldNames: []string{"id", "stock"}},
+			},
+			FederationMetaData: keys,
+		},
+		mock_datasource.Configuration{
+			Overrides: map[string]json.RawMessage{
+				"Product.stock": json.RawMessage(`3`),
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, claims map[string]any, operation string) string {
+		t.Helper()
+
+		def := unsafeparser.ParseGraphqlDocumentString(definition)
+		require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+		op := unsafeparser.ParseGraphqlDocumentString(operation)
+
+		report := &operationreport.Report{}
+		normalizer := astnormalization.NewWithOpts(astnormalization.WithExtractVariables(), astnormalization.WithRemoveUnusedVariables())
+		normalizer.NormalizeOperation(&op, &def, report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		planner, err := plan.NewPlanner(plan.Configuration{
+			DataSources: []plan.DataSource{products, inventory},
+			Fields:      authorizer.FieldConfigurations(nil),
+		})
+		require.NoError(t, err)
+		executionPlan := planner.Plan(&op, &def, "", report)
+		require.False(t, report.HasErrors(), report.Error())
+		postprocess.NewProcessor().Process(executionPlan)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := resolve.New(ctx, resolve.ResolverOptions{
+			MaxConcurrency: 32,
+		})
+
+		resolveCtx := resolve.NewContext(ctx)
+		resolveCtx.Claims = claims
+		resolveCtx.SetAuthorizer(authorizer)
+
+		out := &bytes.Buffer{}
+		_, err = resolver.ResolveGraphQLResponse(resolveCtx, executionPlan.(*plan.SynchronousResponsePlan).Response, nil, out)
+		require.NoError(t, err)
+		return out.String()
+	}
+
+	t.Run("anonymous", func(t *testing.T) {
+		out := execute(t, nil, `{ me { name } products { name price } secret { value } }`)
+		assert.Equal(t, `{"errors":[{"message":"Unauthorized to load field 'Query.me', Reason: not authenticated.","path":["me"]},{"message":"Unauthorized to load field 'Query.products.price', Reason: not authenticated.","path":["products",0,"price"]},{"message":"Unauthorized to load field 'Query.secret', Reason: not authenticated.","path":["secret"]}],"data":{"me":null,"products":[{"name":"Trilby","price":null}],"secret":null}}`, out)
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		out := execute(t, map[string]any{"sub": "1"}, `{ me { name email } products { price } secret { value } }`)
+		assert.Equal(t, `{"errors":[{"message":"Unauthorized to load field 'Query.me.email', Reason: missing required scopes.","path":["me","email"]}],"data":{"me":{"name":"Jens","email":null},"products":[{"price":10}],"secret":{"value":"secret"}}}`, out)
+	})
+
+	t.Run("scopes from space delimited claim", func(t *testing.T) {
+		out := execute(t, map[string]any{"scope": "openid read:email"}, `{ me { email } }`)
+		assert.Equal(t, `{"data":{"me":{"email":"jens@example.com"}}}`, out)
+	})
+
+	t.Run("fields reached through interfaces", func(t *testing.T) {
+		out := execute(t, map[string]any{"scope": []any{"read:email"}}, `{ node { id internal } }`)
+		assert.Equal(t, `{"errors":[{"message":"Unauthorized to load field 'Query.node.internal', Reason: missing required scopes.","path":["node","internal"]}],"data":{"node":{"id":"1","internal":null}}}`, out)
+
+		out = execute(t, map[string]any{"scope": []any{"admin"}}, `{ node { id internal } }`)
+		assert.Equal(t, `{"data":{"node":{"id":"1","internal":"internal"}}}`, out)
+	})
+
+	t.Run("interface fields with rules only on the implementation", func(t *testing.T) {
+		out := execute(t, nil, `{ node { id notes } }`)
+		assert.Equal(t, `{"errors":[{"message":"Unauthorized to load field 'Query.node.notes', Reason: not authenticated.","path":["node","notes"]}],"data":{"node":{"id":"1","notes":null}}}`, out)
+
+		out = execute(t, map[string]any{"sub": "1"}, `{ node { id notes } }`)
+		assert.Equal(t, `{"data":{"node":{"id":"1","notes":"notes"}}}`, out)
+	})
+
+	t.Run("fields returning a union with an authenticated member", func(t *testing.T) {
+		out := execute(t, nil, `{ search { ... on Secret { value } } }`)
+		assert.Equal(t, `{"errors":[{"message":"Unauthorized to load field 'Query.search', Reason: not authenticated.","path":["search"]}],"data":{"search":null}}`, out)
+
+		out = execute(t, map[string]any{"sub": "1"}, `{ search { ... on Secret { value } } }`)
+		assert.Equal(t, `{"data":{"search":[{"value":"secret"}]}}`, out)
+	})
+
+	t.Run("fields reached through entities", func(t *testing.T) {
+		out := execute(t, map[string]any{"sub": "1"}, `{ products { name stock } }`)
+		assert.Equal(t, `{"errors":[{"message":"Unauthorized to load field 'Query.products.stock', Reason: missing required scopes.","path":["products",0,"stock"]}],"data":{"products":[{"name":"Trilby","stock":null}]}}`, out)
+
+		out = execute(t, map[string]any{"scope": "inventory"}, `{ products { name stock } }`)
+		assert.Equal(t, `{"data":{"products":[{"name":"Trilby","stock":3}]}}`, out)
+	})
+
+	t.Run("mutations are rejected before the fetch", func(t *testing.T) {
+		out := execute(t, map[string]any{"scope": "read:email"}, `mutation { deleteProduct(id: "1") }`)
+		assert.Equal(t, `{"errors":[{"message":"Unauthorized request to Subgraph 'products', Reason: missing required scopes."},{"message":"Unauthorized to load field 'Mutation.deleteProduct', Reason: missing required scopes.","path":["deleteProduct"]}],"data":{"deleteProduct":null}}`, out)
+
+		out = execute(t, map[string]any{"scope": "write:products"}, `mutation { deleteProduct(id: "1") }`)
+		assert.Equal(t, `{"data":{"deleteProduct":true}}`, out)
+	})
+}
+
+func newAuthorizer(t *testing.T, schema string) *DirectiveAuthorizer {
+	t.Helper()
+	def := unsafeparser.ParseGraphqlDocumentString(schema)
+	authorizer, err := NewDirectiveAuthorizer(&def, Options{})
+	require.NoError(t, err)
+	return authorizer
+}
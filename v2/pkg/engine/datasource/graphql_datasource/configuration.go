@@ -9,6 +9,7 @@ import (
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/astparser"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/federation"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
 )
@@ -51,6 +52,21 @@ func NewConfiguration(input ConfigurationInput) (Configuration, error) {
 		if cfg.fetch.Method == "" {
 			cfg.fetch.Method = "POST"
 		}
+
+		if cfg.fetch.RequestCompression != nil {
+			if err := cfg.fetch.RequestCompression.Validate(); err != nil {
+				return Configuration{}, fmt.Errorf("fetch configuration is invalid: %w", err)
+			}
+		}
+
+		if cfg.fetch.Upstreams != nil {
+			if cfg.fetch.CircuitBreaker != nil {
+				return Configuration{}, errors.New("fetch configuration is invalid: circuit breaker can't be combined with upstreams")
+			}
+			if cfg.fetch.URL == "" {
+				cfg.fetch.URL = cfg.fetch.Upstreams.URLs()[0]
+			}
+		}
 	}
 
 	if input.Subscription != nil {
@@ -113,6 +129,17 @@ type FetchConfiguration struct {
 	URL    string
 	Method string
 	Header http.Header
+	// RetryPolicy retries failed queries to the upstream, mutations are never retried
+	RetryPolicy *httpclient.RetryPolicy
+	// CircuitBreaker stops requests to the upstream while it is unhealthy,
+	// it must not be shared with other upstreams
+	CircuitBreaker *httpclient.CircuitBreaker
+	// Upstreams load balances requests across replicas of the upstream and fails over to healthy replicas, URL is ignored when set.
+	// It can't be combined with a CircuitBreaker, use the passive ejection of the pool instead.
+	Upstreams *httpclient.UpstreamPool
+	// RequestCompression compresses request bodies above a size threshold, e.g. large _entities batches
+	// If nil, request bodies are sent uncompressed
+	RequestCompression *httpclient.RequestCompression
 }
 
 type FederationConfiguration struct {
@@ -307,6 +307,9 @@ func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
 
 	input = httpclient.SetInputURL(input, []byte(p.config.fetch.URL))
 	input = httpclient.SetInputMethod(input, []byte(p.config.fetch.Method))
+	if p.config.fetch.RequestCompression != nil {
+		input = httpclient.SetInputCompression(input, *p.config.fetch.RequestCompression)
+	}
 
 	postProcessing := DefaultPostProcessingConfiguration
 	requiresEntityFetch := p.requiresEntityFetch()
@@ -322,7 +325,11 @@ func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
 	return resolve.FetchConfiguration{
 		Input: string(input),
 		DataSource: &Source{
-			httpClient: p.fetchClient,
+			httpClient:     p.fetchClient,
+			retryPolicy:    p.retryPolicy(),
+			circuitBreaker: p.config.fetch.CircuitBreaker,
+			upstreams:      p.config.fetch.Upstreams,
+			idempotent:     p.isQuery(),
 		},
 		Variables:                             p.variables,
 		RequiresEntityFetch:                   requiresEntityFetch,
@@ -333,6 +340,19 @@ func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
 	}
 }
 
+// retryPolicy returns the retry policy of the upstream for queries, mutations are not idempotent and must not be retried
+func (p *Planner[T]) retryPolicy() *httpclient.RetryPolicy {
+	if !p.isQuery() {
+		return nil
+	}
+	return p.config.fetch.RetryPolicy
+}
+
+func (p *Planner[T]) isQuery() bool {
+	return len(p.upstreamOperation.OperationDefinitions) != 0 &&
+		p.upstreamOperation.OperationDefinitions[0].OperationType == ast.OperationTypeQuery
+}
+
 func (p *Planner[T]) shouldSelectSingleEntity() bool {
 	return p.dataSourcePlannerConfig.HasRequiredFields() &&
 		p.dataSourcePlannerConfig.PathType == plan.PlannerPathObject
@@ -1695,7 +1715,12 @@ func (f *Factory[T]) UpstreamSchema(dataSourceConfig plan.DataSourceConfiguratio
 }
 
 type Source struct {
-	httpClient *http.Client
+	httpClient     *http.Client
+	retryPolicy    *httpclient.RetryPolicy
+	circuitBreaker *httpclient.CircuitBreaker
+	upstreams      *httpclient.UpstreamPool
+	// idempotent is true for queries, only idempotent requests fail over after the upstream received them
+	idempotent bool
 }
 
 func (s *Source) compactAndUnNullVariables(input []byte) []byte {
@@ -1776,12 +1801,38 @@ func (s *Source) replaceEmptyObject(variables []byte) ([]byte, bool) {
 
 func (s *Source) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) (err error) {
 	input = s.compactAndUnNullVariables(input)
-	return httpclient.DoMultipartForm(s.httpClient, ctx, input, files, out)
+	if s.upstreams != nil {
+		// file uploads don't fail over, their body can't be replayed
+		input = httpclient.SetInputURL(input, []byte(s.upstreams.Next()))
+	}
+	if s.circuitBreaker == nil {
+		return httpclient.DoMultipartForm(s.httpClient, ctx, input, files, out)
+	}
+	// file uploads are not retried, their body can't be replayed
+	if !s.circuitBreaker.Allow() {
+		if responseContext, ok := httpclient.ResponseContextFromContext(ctx); ok {
+			responseContext.CircuitOpen = true
+		}
+		return httpclient.ErrCircuitOpen
+	}
+	err = httpclient.DoMultipartForm(s.httpClient, ctx, input, files, out)
+	statusCode := 0
+	if responseContext, ok := httpclient.ResponseContextFromContext(ctx); ok {
+		statusCode = responseContext.StatusCode
+	}
+	s.circuitBreaker.Report(err == nil && statusCode < http.StatusInternalServerError)
+	return err
 }
 
 func (s *Source) Load(ctx context.Context, input []byte, out *bytes.Buffer) (err error) {
 	input = s.compactAndUnNullVariables(input)
-	return httpclient.Do(s.httpClient, ctx, input, out)
+	if s.upstreams != nil {
+		return httpclient.DoWithFailover(s.httpClient, ctx, s.upstreams, s.retryPolicy, s.idempotent, input, out)
+	}
+	if s.retryPolicy == nil && s.circuitBreaker == nil {
+		return httpclient.Do(s.httpClient, ctx, input, out)
+	}
+	return httpclient.DoWithRetry(s.httpClient, ctx, s.retryPolicy, s.circuitBreaker, input, out)
 }
 
 type GraphQLSubscriptionClient interface {
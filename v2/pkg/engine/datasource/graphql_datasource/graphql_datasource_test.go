@@ -23,10 +23,14 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
 	. "github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasourcetesting"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/testing/subscriptiontesting"
 )
 
@@ -8351,6 +8355,207 @@ func TestSource_Load(t *testing.T) {
 	})
 }
 
+// planSingleFetch plans an operation on a single datasource and returns its first fetch
+func planSingleFetch(t *testing.T, definition, operation string, rootNodes []plan.TypeField, fetchConfiguration *FetchConfiguration) *resolve.SingleFetch {
+	t.Helper()
+	def := unsafeparser.ParseGraphqlDocumentString(definition)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+	op := unsafeparser.ParseGraphqlDocumentString(operation)
+	report := &operationreport.Report{}
+	astnormalization.NormalizeOperation(&op, &def, report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	planner, err := plan.NewPlanner(plan.Configuration{
+		DataSources: []plan.DataSource{
+			mustDataSourceConfiguration(t, "ds",
+				&plan.DataSourceMetadata{
+					RootNodes: rootNodes,
+				},
+				mustCustomConfiguration(t, ConfigurationInput{
+					Fetch:               fetchConfiguration,
+					SchemaConfiguration: mustSchema(t, nil, definition),
+				}),
+			),
+		},
+		DisableResolveFieldPositions: true,
+	})
+	require.NoError(t, err)
+
+	actualPlan := planner.Plan(&op, &def, "", report)
+	require.False(t, report.HasErrors(), report.Error())
+	return actualPlan.(*plan.SynchronousResponsePlan).Response.Data.Fetches[0].(*resolve.SingleFetch)
+}
+
+func TestSource_RetryPolicy(t *testing.T) {
+	definition := `
+		type Query {
+			hello: String
+		}
+		type Mutation {
+			greet: String
+		}
+	`
+	retryPolicy := &httpclient.RetryPolicy{MaxAttempts: 3}
+	circuitBreaker := httpclient.NewCircuitBreaker(5, time.Second)
+
+	planSource := func(t *testing.T, operation string) *Source {
+		t.Helper()
+		fetch := planSingleFetch(t, definition, operation, []plan.TypeField{
+			{TypeName: "Query", FieldNames: []string{"hello"}},
+			{TypeName: "Mutation", FieldNames: []string{"greet"}},
+		}, &FetchConfiguration{
+			URL:            "https://example.com/graphql",
+			RetryPolicy:    retryPolicy,
+			CircuitBreaker: circuitBreaker,
+		})
+		return fetch.DataSource.(*Source)
+	}
+
+	t.Run("queries are retried", func(t *testing.T) {
+		source := planSource(t, `query { hello }`)
+		assert.Same(t, retryPolicy, source.retryPolicy)
+		assert.Same(t, circuitBreaker, source.circuitBreaker)
+	})
+
+	t.Run("mutations are not retried", func(t *testing.T) {
+		source := planSource(t, `mutation { greet }`)
+		assert.Nil(t, source.retryPolicy)
+		assert.Same(t, circuitBreaker, source.circuitBreaker)
+	})
+
+	t.Run("load retries failed requests", func(t *testing.T) {
+		attempts := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"hello":"world"}}`))
+		}))
+		defer ts.Close()
+
+		source := &Source{
+			httpClient:  http.DefaultClient,
+			retryPolicy: &httpclient.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		}
+		ctx, responseContext := httpclient.InjectResponseContext(context.Background())
+		buf := &bytes.Buffer{}
+		require.NoError(t, source.Load(ctx, httpclient.SetInputURL(nil, []byte(ts.URL)), buf))
+		assert.Equal(t, `{"data":{"hello":"world"}}`, buf.String())
+		assert.Equal(t, 2, attempts)
+		assert.Len(t, responseContext.Attempts, 2)
+	})
+}
+
+func TestSource_Upstreams(t *testing.T) {
+	definition := `
+		type Query {
+			hello: String
+		}
+		type Mutation {
+			greet: String
+		}
+	`
+
+	t.Run("queries fail over", func(t *testing.T) {
+		upstreams, err := httpclient.NewUpstreamPool([]string{"https://a.example.com/graphql", "https://b.example.com/graphql"}, httpclient.UpstreamPoolOptions{})
+		require.NoError(t, err)
+
+		fetch := planSingleFetch(t, definition, `query { hello }`, []plan.TypeField{
+			{TypeName: "Query", FieldNames: []string{"hello"}},
+		}, &FetchConfiguration{
+			Upstreams: upstreams,
+		})
+		assert.Equal(t, `{"method":"POST","url":"https://a.example.com/graphql","body":{"query":"{hello}"}}`, fetch.Input)
+		source := fetch.DataSource.(*Source)
+		assert.Same(t, upstreams, source.upstreams)
+		assert.True(t, source.idempotent)
+	})
+
+	t.Run("mutations are not idempotent", func(t *testing.T) {
+		upstreams, err := httpclient.NewUpstreamPool([]string{"https://a.example.com/graphql"}, httpclient.UpstreamPoolOptions{})
+		require.NoError(t, err)
+
+		fetch := planSingleFetch(t, definition, `mutation { greet }`, []plan.TypeField{
+			{TypeName: "Mutation", FieldNames: []string{"greet"}},
+		}, &FetchConfiguration{
+			Upstreams: upstreams,
+		})
+		assert.False(t, fetch.DataSource.(*Source).idempotent)
+	})
+
+	t.Run("load fails over to a healthy replica", func(t *testing.T) {
+		unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer unavailable.Close()
+		available := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"data":{"hello":"world"}}`))
+		}))
+		defer available.Close()
+
+		upstreams, err := httpclient.NewUpstreamPool([]string{unavailable.URL, available.URL}, httpclient.UpstreamPoolOptions{})
+		require.NoError(t, err)
+		source := &Source{
+			httpClient: http.DefaultClient,
+			upstreams:  upstreams,
+			idempotent: true,
+		}
+		buf := &bytes.Buffer{}
+		require.NoError(t, source.Load(context.Background(), httpclient.SetInputURL(nil, []byte(unavailable.URL)), buf))
+		assert.Equal(t, `{"data":{"hello":"world"}}`, buf.String())
+	})
+
+	t.Run("circuit breaker can't be combined with upstreams", func(t *testing.T) {
+		upstreams, err := httpclient.NewUpstreamPool([]string{"https://a.example.com/graphql"}, httpclient.UpstreamPoolOptions{})
+		require.NoError(t, err)
+
+		_, err = NewConfiguration(ConfigurationInput{
+			Fetch: &FetchConfiguration{
+				Upstreams:      upstreams,
+				CircuitBreaker: httpclient.NewCircuitBreaker(5, time.Second),
+			},
+			SchemaConfiguration: mustSchema(t, nil, definition),
+		})
+		assert.EqualError(t, err, "fetch configuration is invalid: circuit breaker can't be combined with upstreams")
+	})
+}
+
+func TestSource_RequestCompression(t *testing.T) {
+	definition := `
+		type Query {
+			hello: String
+		}
+	`
+
+	t.Run("compression is added to the input", func(t *testing.T) {
+		fetch := planSingleFetch(t, definition, `query { hello }`, []plan.TypeField{
+			{TypeName: "Query", FieldNames: []string{"hello"}},
+		}, &FetchConfiguration{
+			URL: "https://example.com/graphql",
+			RequestCompression: &httpclient.RequestCompression{
+				Encoding: httpclient.EncodingZstd,
+				MinSize:  1024,
+			},
+		})
+		assert.Equal(t, `{"compression":{"encoding":"zstd","min_size":1024},"method":"POST","url":"https://example.com/graphql","body":{"query":"{hello}"}}`, fetch.Input)
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		_, err := NewConfiguration(ConfigurationInput{
+			Fetch: &FetchConfiguration{
+				URL: "https://example.com/graphql",
+				RequestCompression: &httpclient.RequestCompression{
+					Encoding: "br",
+				},
+			},
+			SchemaConfiguration: mustSchema(t, nil, definition),
+		})
+		assert.EqualError(t, err, `fetch configuration is invalid: unsupported request compression encoding: "br"`)
+	})
+}
+
 type ExpectedFile struct {
 	Name string
 	Size int64
@@ -12,6 +12,7 @@ import (
 	"github.com/buger/jsonparser"
 	log "github.com/jensneuse/abstractlogger"
 	"github.com/r3labs/sse/v2"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
 )
 
@@ -293,4 +294,5 @@ func (h *gqlSSEConnectionHandler) setSSEHeaders(req *http.Request) {
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Cache-Control", "no-cache")
+	httpclient.InjectTraceContext(req.Context(), req.Header)
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/gobwas/ws"
 	"github.com/gobwas/ws/wsutil"
 	"github.com/jensneuse/abstractlogger"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/netpoll"
 	"go.uber.org/atomic"
@@ -519,6 +520,7 @@ func (c *subscriptionClient) dial(ctx context.Context, options GraphQLSubscripti
 	req.Header.Set("Sec-WebSocket-Version", "13")
 	req.Header.Set("Connection", "Upgrade")
 	req.Header.Set("Upgrade", "websocket")
+	httpclient.InjectTraceContext(ctx, req.Header)
 
 	challengeKey, err := generateChallengeKey()
 	if err != nil {
@@ -0,0 +1,167 @@
+package grpc_datasource
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/xcontext"
+)
+
+// reflectionTimeout limits the server reflection of a service, it's detached from the request that triggered it
+const reflectionTimeout = 30 * time.Second
+
+// methodResolver looks up the descriptor of an RPC by the fully qualified name of its service
+type methodResolver interface {
+	FindMethod(ctx context.Context, service, method string) (protoreflect.MethodDescriptor, error)
+}
+
+func findMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, error) {
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", service, err)
+	}
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	methodDescriptor := serviceDescriptor.Methods().ByName(protoreflect.Name(method))
+	if methodDescriptor == nil {
+		return nil, fmt.Errorf("method %s not found on service %s", method, service)
+	}
+	if methodDescriptor.IsStreamingClient() || methodDescriptor.IsStreamingServer() {
+		return nil, fmt.Errorf("streaming method %s.%s is not supported", service, method)
+	}
+	return methodDescriptor, nil
+}
+
+// fileDescriptorSetResolver resolves methods from a FileDescriptorSet, e.g. generated with protoc --descriptor_set_out --include_imports
+type fileDescriptorSetResolver struct {
+	files *protoregistry.Files
+}
+
+func newFileDescriptorSetResolver(set *descriptorpb.FileDescriptorSet) (*fileDescriptorSetResolver, error) {
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+	return &fileDescriptorSetResolver{files: files}, nil
+}
+
+func (r *fileDescriptorSetResolver) FindMethod(_ context.Context, service, method string) (protoreflect.MethodDescriptor, error) {
+	return findMethod(r.files, service, method)
+}
+
+// reflectionResolver resolves methods with the gRPC server reflection of the upstream.
+// The descriptors of a service are fetched once and kept for the lifetime of the resolver.
+// Concurrent requests for the same service share a single reflection call.
+type reflectionResolver struct {
+	client reflectionpb.ServerReflectionClient
+	group  singleflight.Group
+
+	mu       sync.RWMutex
+	services map[string]*protoregistry.Files
+}
+
+func newReflectionResolver(conn grpc.ClientConnInterface) *reflectionResolver {
+	return &reflectionResolver{
+		client:   reflectionpb.NewServerReflectionClient(conn),
+		services: make(map[string]*protoregistry.Files),
+	}
+}
+
+func (r *reflectionResolver) FindMethod(ctx context.Context, service, method string) (protoreflect.MethodDescriptor, error) {
+	files, ok := r.cachedFiles(service)
+	if !ok {
+		// the reflection call outlives a canceled request, other requests might wait for it
+		result := r.group.DoChan(service, func() (interface{}, error) {
+			return r.loadFiles(xcontext.Detach(ctx), service)
+		})
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case res := <-result:
+			if res.Err != nil {
+				return nil, res.Err
+			}
+			files = res.Val.(*protoregistry.Files)
+		}
+	}
+
+	return findMethod(files, service, method)
+}
+
+func (r *reflectionResolver) cachedFiles(service string) (*protoregistry.Files, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	files, ok := r.services[service]
+	return files, ok
+}
+
+func (r *reflectionResolver) loadFiles(ctx context.Context, service string) (*protoregistry.Files, error) {
+	if files, ok := r.cachedFiles(service); ok {
+		return files, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reflectionTimeout)
+	defer cancel()
+
+	files, err := r.fetchFiles(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.services[service] = files
+	r.mu.Unlock()
+	return files, nil
+}
+
+// fetchFiles requests the file declaring the service, the server responds with the file and all of its dependencies
+func (r *reflectionResolver) fetchFiles(ctx context.Context, service string) (*protoregistry.Files, error) {
+	stream, err := r.client.ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = stream.CloseSend()
+	}()
+
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{
+			FileContainingSymbol: service,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if errorResponse := response.GetErrorResponse(); errorResponse != nil {
+		return nil, fmt.Errorf("server reflection failed for service %s: %s", service, errorResponse.GetErrorMessage())
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, raw := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		file := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, file); err != nil {
+			return nil, err
+		}
+		set.File = append(set.File, file)
+	}
+
+	return protodesc.NewFiles(set)
+}
@@ -0,0 +1,228 @@
+package grpc_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jensneuse/abstractlogger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+type Configuration struct {
+	// Descriptors are the protobuf descriptors of the upstream services, including their imports
+	Descriptors *descriptorpb.FileDescriptorSet
+	// UseServerReflection resolves the descriptors with the gRPC server reflection of the upstream instead of Descriptors
+	UseServerReflection bool
+	// RPCs maps the root fields of the datasource to the methods of the upstream services
+	RPCs []RPCConfiguration
+}
+
+type RPCConfiguration struct {
+	TypeName  string
+	FieldName string
+	// Service is the fully qualified name of the service, e.g. users.v1.UserService
+	Service string
+	Method  string
+	// Request is the JSON representation of the request message, it supports templates for
+	// field arguments ({{ .arguments.id }}) and fields of the parent object ({{ .object.id }}).
+	// An empty Request sends an empty message.
+	Request string
+	// ResponsePath selects the field value from the response message, e.g. ["user"] for a GetUserResponse,
+	// it uses the JSON names of the protobuf fields. An empty ResponsePath uses the whole response message.
+	ResponsePath []string
+}
+
+func (c *Configuration) rpc(typeName, fieldName string) (RPCConfiguration, bool) {
+	for i := range c.RPCs {
+		if c.RPCs[i].TypeName == typeName && c.RPCs[i].FieldName == fieldName {
+			return c.RPCs[i], true
+		}
+	}
+	return RPCConfiguration{}, false
+}
+
+type Factory[T Configuration] struct {
+	executionContext context.Context
+	conn             grpc.ClientConnInterface
+
+	mu             sync.Mutex
+	reflection     *reflectionResolver
+	descriptorSets map[*descriptorpb.FileDescriptorSet]*fileDescriptorSetResolver
+}
+
+// NewFactory creates a new factory for the gRPC datasource planner,
+// all datasources created by the factory call the upstream through conn
+func NewFactory(executionContext context.Context, conn grpc.ClientConnInterface) (*Factory[Configuration], error) {
+	if executionContext == nil {
+		return nil, fmt.Errorf("execution context is required")
+	}
+	if conn == nil {
+		return nil, fmt.Errorf("grpc client connection is required")
+	}
+
+	return &Factory[Configuration]{
+		executionContext: executionContext,
+		conn:             conn,
+		descriptorSets:   make(map[*descriptorpb.FileDescriptorSet]*fileDescriptorSetResolver),
+	}, nil
+}
+
+func (f *Factory[T]) Planner(logger abstractlogger.Logger) plan.DataSourcePlanner[T] {
+	return &Planner[T]{
+		factory: f,
+	}
+}
+
+func (f *Factory[T]) Context() context.Context {
+	return f.executionContext
+}
+
+func (f *Factory[T]) UpstreamSchema(dataSourceConfig plan.DataSourceConfiguration[T]) (*ast.Document, bool) {
+	return nil, false
+}
+
+// methodResolver returns the resolver for the descriptors of the configuration,
+// resolvers are shared between planners to parse the descriptors only once
+func (f *Factory[T]) methodResolver(config *Configuration) (methodResolver, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if config.UseServerReflection {
+		if f.reflection == nil {
+			f.reflection = newReflectionResolver(f.conn)
+		}
+		return f.reflection, nil
+	}
+
+	if config.Descriptors == nil {
+		return nil, errors.New("grpc datasource requires descriptors or server reflection")
+	}
+
+	resolver, ok := f.descriptorSets[config.Descriptors]
+	if !ok {
+		var err error
+		resolver, err = newFileDescriptorSetResolver(config.Descriptors)
+		if err != nil {
+			return nil, fmt.Errorf("invalid descriptors: %w", err)
+		}
+		f.descriptorSets[config.Descriptors] = resolver
+	}
+	return resolver, nil
+}
+
+type Planner[T Configuration] struct {
+	id            int
+	factory       *Factory[T]
+	v             *plan.Visitor
+	config        Configuration
+	rootField     int
+	rootFieldPath string
+	rpc           RPCConfiguration
+	parentPath    string
+	isArrayItem   bool
+}
+
+func (p *Planner[T]) SetID(id int) {
+	p.id = id
+}
+
+func (p *Planner[T]) ID() (id int) {
+	return p.id
+}
+
+func (p *Planner[T]) DownstreamResponseFieldAlias(_ int) (alias string, exists bool) {
+	// the gRPC DataSourcePlanner doesn't rewrite upstream fields: skip
+	return
+}
+
+func (p *Planner[T]) DataSourcePlanningBehavior() plan.DataSourcePlanningBehavior {
+	return plan.DataSourcePlanningBehavior{
+		MergeAliasedRootNodes:      false,
+		OverrideFieldPathFromAlias: true,
+	}
+}
+
+func (p *Planner[T]) Register(visitor *plan.Visitor, configuration plan.DataSourceConfiguration[T], dataSourcePlannerConfiguration plan.DataSourcePlannerConfiguration) error {
+	p.v = visitor
+	p.config = Configuration(configuration.CustomConfiguration())
+	p.rootField = ast.InvalidRef
+	p.parentPath = dataSourcePlannerConfiguration.ParentPath
+	p.isArrayItem = dataSourcePlannerConfiguration.PathType == plan.PlannerPathArrayItem
+	visitor.Walker.RegisterEnterFieldVisitor(p)
+	return nil
+}
+
+func (p *Planner[T]) EnterField(ref int) {
+	if p.rootField != ast.InvalidRef {
+		// nested fields are part of the response message
+		return
+	}
+
+	fieldAliasOrName := p.v.Operation.FieldAliasOrNameString(ref)
+	if plan.IsParentPathField(p.v, p.parentPath, ref) {
+		return
+	}
+
+	typeName := p.v.Walker.EnclosingTypeDefinition.NameString(p.v.Definition)
+	fieldName := p.v.Operation.FieldNameString(ref)
+	rpc, ok := p.config.rpc(typeName, fieldName)
+	if !ok {
+		p.v.Walker.StopWithInternalErr(fmt.Errorf("no rpc configured for field %s.%s", typeName, fieldName))
+		return
+	}
+
+	p.rootField = ref
+	p.rootFieldPath = fieldAliasOrName
+	p.rpc = rpc
+}
+
+func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
+	if p.rootField == ast.InvalidRef {
+		p.v.Walker.StopWithInternalErr(errors.New("grpc root field is not set"))
+		return resolve.FetchConfiguration{}
+	}
+
+	resolver, err := p.factory.methodResolver(&p.config)
+	if err != nil {
+		p.v.Walker.StopWithInternalErr(err)
+		return resolve.FetchConfiguration{}
+	}
+
+	return resolve.FetchConfiguration{
+		Input: p.configureInput(),
+		DataSource: &Source{
+			conn:     p.factory.conn,
+			resolver: resolver,
+		},
+		RequiresParallelListItemFetch: p.isArrayItem,
+		PostProcessing: resolve.PostProcessingConfiguration{
+			SelectResponseDataPath:   append([]string{"data"}, p.rpc.ResponsePath...),
+			SelectResponseErrorsPath: []string{"errors"},
+			MergePath:                []string{p.rootFieldPath},
+		},
+	}
+}
+
+func (p *Planner[T]) ConfigureSubscription() plan.SubscriptionConfiguration {
+	// the gRPC DataSourcePlanner doesn't have subscriptions
+	return plan.SubscriptionConfiguration{}
+}
+
+func (p *Planner[T]) configureInput() string {
+	request := strings.TrimSpace(p.rpc.Request)
+	if request == "" {
+		request = "{}"
+	}
+	service, _ := json.Marshal(p.rpc.Service)
+	method, _ := json.Marshal(p.rpc.Method)
+	return fmt.Sprintf(`{"service":%s,"method":%s,"request":%s}`, service, method, request)
+}
@@ -0,0 +1,549 @@
+package grpc_datasource
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wundergraph/astjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasourcetesting"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/postprocess"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+const (
+	definition = `
+		type Query {
+			user(id: ID!): User
+			users: [User!]!
+		}
+
+		type User {
+			id: ID!
+			name: String!
+			age: Int!
+			friends: [User!]!
+		}
+	`
+
+	usersProto = `
+		name: "users.proto"
+		package: "users.v1"
+		syntax: "proto3"
+		message_type {
+			name: "User"
+			field { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "id" }
+			field { name: "name" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "name" }
+			field { name: "age" number: 3 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "age" }
+		}
+		message_type {
+			name: "Stats"
+			field { name: "views" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "views" }
+			field { name: "ids" number: 2 label: LABEL_REPEATED type: TYPE_FIXED64 json_name: "ids" }
+			field { name: "code" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "code" }
+			field { name: "parent" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".users.v1.Stats" json_name: "parent" }
+		}
+		message_type {
+			name: "GetUserRequest"
+			field { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "id" }
+		}
+		message_type {
+			name: "GetUserResponse"
+			field { name: "user" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".users.v1.User" json_name: "user" }
+		}
+		message_type {
+			name: "ListUsersRequest"
+		}
+		message_type {
+			name: "ListFriendsRequest"
+			field { name: "user_id" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "userId" }
+		}
+		message_type {
+			name: "ListUsersResponse"
+			field { name: "users" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".users.v1.User" json_name: "users" }
+		}
+		service {
+			name: "UserService"
+			method { name: "GetUser" input_type: ".users.v1.GetUserRequest" output_type: ".users.v1.GetUserResponse" }
+			method { name: "ListUsers" input_type: ".users.v1.ListUsersRequest" output_type: ".users.v1.ListUsersResponse" }
+			method { name: "ListFriends" input_type: ".users.v1.ListFriendsRequest" output_type: ".users.v1.ListUsersResponse" }
+		}
+	`
+)
+
+func TestGRPCDataSourcePlanning(t *testing.T) {
+	factory, err := NewFactory(context.Background(), &grpc.ClientConn{})
+	require.NoError(t, err)
+
+	dataSource, err := plan.NewDataSourceConfiguration[Configuration](
+		"users",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"user"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"id", "name", "age"}},
+			},
+		},
+		Configuration{
+			Descriptors: descriptors(t),
+			RPCs: []RPCConfiguration{
+				{
+					TypeName:     "Query",
+					FieldName:    "user",
+					Service:      "users.v1.UserService",
+					Method:       "GetUser",
+					Request:      `{"id":"{{ .arguments.id }}"}`,
+					ResponsePath: []string{"user"},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	t.Run("unary rpc with argument", datasourcetesting.RunTest(definition, `query User($id: ID!) { me: user(id: $id) { name } }`, "User",
+		&plan.SynchronousResponsePlan{
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fetches: []resolve.Fetch{
+						&resolve.SingleFetch{
+							DataSourceIdentifier: []byte("grpc_datasource.Source"),
+							FetchConfiguration: resolve.FetchConfiguration{
+								Input:      `{"service":"users.v1.UserService","method":"GetUser","request":{"id":"$$0$$"}}`,
+								DataSource: &Source{},
+								Variables: resolve.NewVariables(
+									&resolve.ContextVariable{
+										Path:     []string{"id"},
+										Renderer: resolve.NewPlainVariableRenderer(),
+									},
+								),
+								PostProcessing: resolve.PostProcessingConfiguration{
+									SelectResponseDataPath:   []string{"data", "user"},
+									SelectResponseErrorsPath: []string{"errors"},
+									MergePath:                []string{"me"},
+								},
+							},
+						},
+					},
+					Fields: []*resolve.Field{
+						{
+							Name: []byte("me"),
+							Value: &resolve.Object{
+								Path:     []string{"me"},
+								Nullable: true,
+								PossibleTypes: map[string]struct{}{
+									"User": {},
+								},
+								TypeName: "User",
+								Fields: []*resolve.Field{
+									{
+										Name: []byte("name"),
+										Value: &resolve.String{
+											Path: []string{"name"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		plan.Configuration{
+			DataSources:                  []plan.DataSource{dataSource},
+			DisableResolveFieldPositions: true,
+		},
+	))
+}
+
+func TestGRPCDataSource(t *testing.T) {
+	conn := startUserService(t)
+
+	factory, err := NewFactory(context.Background(), conn)
+	require.NoError(t, err)
+
+	dataSources := func(t *testing.T, config Configuration) []plan.DataSource {
+		t.Helper()
+		childNodes := []plan.TypeField{
+			{TypeName: "User", FieldNames: []string{"id", "name", "age"}},
+		}
+
+		users, err := plan.NewDataSourceConfiguration[Configuration](
+			"users",
+			factory,
+			&plan.DataSourceMetadata{
+				RootNodes: []plan.TypeField{
+					{TypeName: "Query", FieldNames: []string{"user", "users"}},
+				},
+				ChildNodes: childNodes,
+			},
+			Configuration{
+				Descriptors:         config.Descriptors,
+				UseServerReflection: config.UseServerReflection,
+				RPCs: []RPCConfiguration{
+					{
+						TypeName:     "Query",
+						FieldName:    "user",
+						Service:      "users.v1.UserService",
+						Method:       "GetUser",
+						Request:      `{"id":"{{ .arguments.id }}"}`,
+						ResponsePath: []string{"user"},
+					},
+					{
+						TypeName:     "Query",
+						FieldName:    "users",
+						Service:      "users.v1.UserService",
+						Method:       "ListUsers",
+						ResponsePath: []string{"users"},
+					},
+				},
+			},
+		)
+		require.NoError(t, err)
+
+		friends, err := plan.NewDataSourceConfiguration[Configuration](
+			"friends",
+			factory,
+			&plan.DataSourceMetadata{
+				RootNodes: []plan.TypeField{
+					{TypeName: "User", FieldNames: []string{"friends"}},
+				},
+				ChildNodes: childNodes,
+			},
+			Configuration{
+				Descriptors:         config.Descriptors,
+				UseServerReflection: config.UseServerReflection,
+				RPCs: []RPCConfiguration{
+					{
+						TypeName:     "User",
+						FieldName:    "friends",
+						Service:      "users.v1.UserService",
+						Method:       "ListFriends",
+						Request:      `{"userId":"{{ .object.id }}"}`,
+						ResponsePath: []string{"users"},
+					},
+				},
+			},
+		)
+		require.NoError(t, err)
+
+		return []plan.DataSource{users, friends}
+	}
+
+	modes := map[string]Configuration{
+		"descriptors":       {Descriptors: descriptors(t)},
+		"server reflection": {UseServerReflection: true},
+	}
+
+	for name, mode := range modes {
+		t.Run(name, func(t *testing.T) {
+			t.Run("unary rpc", func(t *testing.T) {
+				out := execute(t, dataSources(t, mode), `{ user(id: "1") { id name age } }`)
+				assert.Equal(t, `{"data":{"user":{"id":"1","name":"Jens","age":0}}}`, out)
+			})
+
+			t.Run("nested rpc on list items", func(t *testing.T) {
+				out := execute(t, dataSources(t, mode), `{ users { name friends { name } } }`)
+				assert.Equal(t, `{"data":{"users":[{"name":"Jens","friends":[{"name":"Stefan"}]},{"name":"Stefan","friends":[]}]}}`, out)
+			})
+
+			t.Run("status error", func(t *testing.T) {
+				out := execute(t, dataSources(t, mode), `{ user(id: "3") { name } }`)
+				assert.Equal(t, `{"errors":[{"message":"Failed to fetch from Subgraph 'users'.","extensions":{"errors":[{"message":"user 3 not found","extensions":{"code":"NOT_FOUND"}}]}}],"data":{"user":null}}`, out)
+			})
+		})
+	}
+}
+
+func TestSource_Load(t *testing.T) {
+	conn := startUserService(t)
+
+	resolver, err := newFileDescriptorSetResolver(descriptors(t))
+	require.NoError(t, err)
+
+	source := &Source{
+		conn:     conn,
+		resolver: resolver,
+	}
+
+	t.Run("response message", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"service":"users.v1.UserService","method":"GetUser","request":{"id":"2"}}`), out)
+		require.NoError(t, err)
+		// protojson randomizes whitespaces of its output
+		assert.JSONEq(t, `{"data":{"user":{"id":"2","name":"Stefan","age":42}}}`, out.String())
+	})
+
+	t.Run("invalid request message", func(t *testing.T) {
+		err := source.Load(context.Background(), []byte(`{"service":"users.v1.UserService","method":"GetUser","request":{"unknown":"2"}}`), &bytes.Buffer{})
+		assert.ErrorContains(t, err, "failed to build request message users.v1.GetUserRequest")
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		err := source.Load(context.Background(), []byte(`{"service":"users.v1.UserService","method":"DeleteUser","request":{}}`), &bytes.Buffer{})
+		assert.EqualError(t, err, "method DeleteUser not found on service users.v1.UserService")
+	})
+}
+
+// blockingReflectionClient counts the reflection calls and blocks them until release is closed
+type blockingReflectionClient struct {
+	reflectionpb.ServerReflectionClient
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (c *blockingReflectionClient) ServerReflectionInfo(ctx context.Context, opts ...grpc.CallOption) (reflectionpb.ServerReflection_ServerReflectionInfoClient, error) {
+	c.calls.Add(1)
+	<-c.release
+	return c.ServerReflectionClient.ServerReflectionInfo(ctx, opts...)
+}
+
+func TestReflectionResolver_FindMethod(t *testing.T) {
+	conn := startUserService(t)
+
+	client := &blockingReflectionClient{
+		ServerReflectionClient: reflectionpb.NewServerReflectionClient(conn),
+		release:                make(chan struct{}),
+	}
+	resolver := newReflectionResolver(conn)
+	resolver.client = client
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := resolver.FindMethod(canceledCtx, "users.v1.UserService", "GetUser")
+		canceled <- err
+	}()
+	require.Eventually(t, func() bool {
+		return client.calls.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	found := make(chan error)
+	go func() {
+		_, err := resolver.FindMethod(context.Background(), "users.v1.UserService", "ListUsers")
+		found <- err
+	}()
+
+	// the canceled request returns immediately, the reflection call continues for the other request
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+	close(client.release)
+	assert.NoError(t, <-found)
+
+	method, err := resolver.FindMethod(context.Background(), "users.v1.UserService", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("users.v1.UserService.GetUser"), method.FullName())
+	assert.Equal(t, int32(1), client.calls.Load())
+}
+
+func TestMarshalResponse(t *testing.T) {
+	files, err := protodesc.NewFiles(descriptors(t))
+	require.NoError(t, err)
+	descriptor, err := files.FindDescriptorByName("users.v1.Stats")
+	require.NoError(t, err)
+	statsDescriptor := descriptor.(protoreflect.MessageDescriptor)
+	fields := statsDescriptor.Fields()
+
+	parent := dynamicpb.NewMessage(statsDescriptor)
+	parent.Set(fields.ByName("views"), protoreflect.ValueOfInt64(-7))
+	stats := dynamicpb.NewMessage(statsDescriptor)
+	stats.Set(fields.ByName("views"), protoreflect.ValueOfInt64(9007199254740991))
+	ids := stats.Mutable(fields.ByName("ids")).List()
+	ids.Append(protoreflect.ValueOfUint64(1))
+	ids.Append(protoreflect.ValueOfUint64(2))
+	stats.Set(fields.ByName("code"), protoreflect.ValueOfString("42"))
+	stats.Set(fields.ByName("parent"), protoreflect.ValueOfMessage(parent))
+
+	out, err := marshalResponse(stats)
+	require.NoError(t, err)
+	// 64-bit integers are numbers, strings containing numbers are kept as strings
+	assert.JSONEq(t, `{"views":9007199254740991,"ids":[1,2],"code":"42","parent":{"views":-7,"ids":[],"code":"","parent":null}}`, string(out))
+}
+
+func TestPlanner_ConfigureInput(t *testing.T) {
+	planner := &Planner[Configuration]{
+		rpc: RPCConfiguration{Service: `users.v1."UserService"`, Method: `Get\User`},
+	}
+	// service and method are escaped, a missing request template is rendered as an empty message
+	assert.Equal(t, `{"service":"users.v1.\"UserService\"","method":"Get\\User","request":{}}`, planner.configureInput())
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, "NOT_FOUND", errorCode(codes.NotFound))
+	assert.Equal(t, "INVALID_ARGUMENT", errorCode(codes.InvalidArgument))
+	assert.Equal(t, "INTERNAL", errorCode(codes.Internal))
+}
+
+func execute(t *testing.T, dataSources []plan.DataSource, operation string) string {
+	t.Helper()
+
+	def := unsafeparser.ParseGraphqlDocumentString(definition)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+	op := unsafeparser.ParseGraphqlDocumentString(operation)
+
+	report := &operationreport.Report{}
+	normalizer := astnormalization.NewWithOpts(astnormalization.WithExtractVariables(), astnormalization.WithRemoveUnusedVariables())
+	normalizer.NormalizeOperation(&op, &def, report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	planner, err := plan.NewPlanner(plan.Configuration{
+		DataSources: dataSources,
+	})
+	require.NoError(t, err)
+	executionPlan := planner.Plan(&op, &def, "", report)
+	require.False(t, report.HasErrors(), report.Error())
+	postprocess.NewProcessor().Process(executionPlan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := resolve.New(ctx, resolve.ResolverOptions{
+		MaxConcurrency:              32,
+		PropagateSubgraphErrors:     true,
+		AllowedErrorExtensionFields: []string{"code"},
+	})
+
+	resolveCtx := resolve.NewContext(ctx)
+	if len(op.Input.Variables) > 0 {
+		resolveCtx.Variables = astjson.MustParseBytes(op.Input.Variables)
+	}
+
+	out := &bytes.Buffer{}
+	_, err = resolver.ResolveGraphQLResponse(resolveCtx, executionPlan.(*plan.SynchronousResponsePlan).Response, nil, out)
+	require.NoError(t, err)
+	return out.String()
+}
+
+func descriptors(t *testing.T) *descriptorpb.FileDescriptorSet {
+	t.Helper()
+	file := &descriptorpb.FileDescriptorProto{}
+	require.NoError(t, prototext.Unmarshal([]byte(usersProto), file))
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}}
+}
+
+// startUserService starts an in-process users.v1.UserService with server reflection
+func startUserService(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	files, err := protodesc.NewFiles(descriptors(t))
+	require.NoError(t, err)
+	serviceDescriptor, err := files.FindDescriptorByName("users.v1.UserService")
+	require.NoError(t, err)
+	methods := serviceDescriptor.(protoreflect.ServiceDescriptor).Methods()
+
+	users := map[string][2]any{
+		"1": {"Jens", int32(0)},
+		"2": {"Stefan", int32(42)},
+	}
+	friends := map[string][]string{
+		"1": {"2"},
+	}
+
+	newUser := func(userDescriptor protoreflect.MessageDescriptor, id string) *dynamicpb.Message {
+		user := dynamicpb.NewMessage(userDescriptor)
+		user.Set(userDescriptor.Fields().ByName("id"), protoreflect.ValueOfString(id))
+		user.Set(userDescriptor.Fields().ByName("name"), protoreflect.ValueOfString(users[id][0].(string)))
+		user.Set(userDescriptor.Fields().ByName("age"), protoreflect.ValueOfInt32(users[id][1].(int32)))
+		return user
+	}
+
+	newUsers := func(method protoreflect.MethodDescriptor, ids []string) *dynamicpb.Message {
+		response := dynamicpb.NewMessage(method.Output())
+		field := method.Output().Fields().ByName("users")
+		list := response.Mutable(field).List()
+		for _, id := range ids {
+			list.Append(protoreflect.ValueOfMessage(newUser(field.Message(), id)))
+		}
+		return response
+	}
+
+	handlers := map[string]func(method protoreflect.MethodDescriptor, request *dynamicpb.Message) (any, error){
+		"GetUser": func(method protoreflect.MethodDescriptor, request *dynamicpb.Message) (any, error) {
+			id := request.Get(method.Input().Fields().ByName("id")).String()
+			if _, ok := users[id]; !ok {
+				return nil, status.Errorf(codes.NotFound, "user %s not found", id)
+			}
+			response := dynamicpb.NewMessage(method.Output())
+			field := method.Output().Fields().ByName("user")
+			response.Set(field, protoreflect.ValueOfMessage(newUser(field.Message(), id)))
+			return response, nil
+		},
+		"ListUsers": func(method protoreflect.MethodDescriptor, _ *dynamicpb.Message) (any, error) {
+			return newUsers(method, []string{"1", "2"}), nil
+		},
+		"ListFriends": func(method protoreflect.MethodDescriptor, request *dynamicpb.Message) (any, error) {
+			id := request.Get(method.Input().Fields().ByName("user_id")).String()
+			return newUsers(method, friends[id]), nil
+		},
+	}
+
+	serviceDesc := &grpc.ServiceDesc{
+		ServiceName: "users.v1.UserService",
+		HandlerType: (*any)(nil),
+	}
+	for name, handler := range handlers {
+		method := methods.ByName(protoreflect.Name(name))
+		handler := handler
+		serviceDesc.Methods = append(serviceDesc.Methods, grpc.MethodDesc{
+			MethodName: name,
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				request := dynamicpb.NewMessage(method.Input())
+				if err := dec(request); err != nil {
+					return nil, err
+				}
+				return handler(method, request)
+			},
+		})
+	}
+
+	server := grpc.NewServer()
+	server.RegisterService(serviceDesc, struct{}{})
+	reflectionpb.RegisterServerReflectionServer(server, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           server,
+		DescriptorResolver: files,
+		ExtensionResolver:  protoregistry.GlobalTypes,
+	}))
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
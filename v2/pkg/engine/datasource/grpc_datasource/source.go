@@ -0,0 +1,203 @@
+package grpc_datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"unicode"
+
+	"github.com/buger/jsonparser"
+	"github.com/wundergraph/astjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+)
+
+var (
+	inputPaths = [][]string{
+		{"service"},
+		{"method"},
+		{"request"},
+	}
+	marshalOptions = protojson.MarshalOptions{
+		// GraphQL expects non-null fields to be present, proto3 omits zero values by default
+		EmitUnpopulated: true,
+	}
+)
+
+type Source struct {
+	conn     grpc.ClientConnInterface
+	resolver methodResolver
+}
+
+// Load translates the JSON request of the input into the request message of the RPC and renders the response
+// message as GraphQL response. Status errors of the upstream are rendered as GraphQL errors.
+func (s *Source) Load(ctx context.Context, input []byte, out *bytes.Buffer) (err error) {
+	var service, method string
+	var request []byte
+	jsonparser.EachKey(input, func(i int, value []byte, _ jsonparser.ValueType, _ error) {
+		switch i {
+		case 0:
+			service = string(value)
+		case 1:
+			method = string(value)
+		case 2:
+			request = value
+		}
+	}, inputPaths...)
+
+	methodDescriptor, err := s.resolver.FindMethod(ctx, service, method)
+	if err != nil {
+		return err
+	}
+
+	requestMessage := dynamicpb.NewMessage(methodDescriptor.Input())
+	if err = protojson.Unmarshal(request, requestMessage); err != nil {
+		return fmt.Errorf("failed to build request message %s: %w", methodDescriptor.Input().FullName(), err)
+	}
+
+	responseMessage := dynamicpb.NewMessage(methodDescriptor.Output())
+	err = s.conn.Invoke(ctx, fmt.Sprintf("/%s/%s", service, method), requestMessage, responseMessage)
+	if err != nil {
+		st, ok := status.FromError(err)
+		if !ok {
+			return err
+		}
+		return writeStatusError(st, out)
+	}
+
+	response, err := marshalResponse(responseMessage)
+	if err != nil {
+		return err
+	}
+
+	_, _ = out.WriteString(`{"data":`)
+	_, _ = out.Write(response)
+	_, _ = out.WriteString(`}`)
+	return nil
+}
+
+func (s *Source) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) (err error) {
+	panic("not implemented")
+}
+
+// marshalResponse renders the response message as JSON. protojson renders 64-bit integers as JSON strings,
+// they are rendered as numbers instead to be valid values of Int and Float fields.
+// Values beyond the 53 bits of a float64 lose precision in most clients, use string fields for them.
+func marshalResponse(message *dynamicpb.Message) ([]byte, error) {
+	response, err := marshalOptions.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	value, err := astjson.ParseBytesWithoutCache(response)
+	if err != nil {
+		return nil, err
+	}
+	renderIntegersAsNumbers(&astjson.Arena{}, value, message.Descriptor())
+	return value.MarshalTo(nil), nil
+}
+
+func renderIntegersAsNumbers(arena *astjson.Arena, value *astjson.Value, message protoreflect.MessageDescriptor) {
+	if value.Type() != astjson.TypeObject {
+		// well known types like Timestamp or Duration are rendered as strings
+		return
+	}
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		fieldValue := value.Get(field.JSONName())
+		if fieldValue == nil {
+			continue
+		}
+		switch {
+		case field.IsMap():
+			// map keys are always rendered as strings
+			entries := fieldValue.GetObject()
+			if entries == nil {
+				continue
+			}
+			var keys []string
+			entries.Visit(func(key []byte, _ *astjson.Value) {
+				keys = append(keys, string(key))
+			})
+			for _, key := range keys {
+				if number := integerAsNumber(arena, fieldValue.Get(key), field.MapValue()); number != nil {
+					fieldValue.Set(key, number)
+				}
+			}
+		case field.IsList():
+			for j, item := range fieldValue.GetArray() {
+				if number := integerAsNumber(arena, item, field); number != nil {
+					fieldValue.SetArrayItem(j, number)
+				}
+			}
+		default:
+			if number := integerAsNumber(arena, fieldValue, field); number != nil {
+				value.Set(field.JSONName(), number)
+			}
+		}
+	}
+}
+
+// integerAsNumber returns the number of a 64-bit integer value or nil for all other values,
+// 64-bit integers of nested messages are replaced in place
+func integerAsNumber(arena *astjson.Arena, value *astjson.Value, field protoreflect.FieldDescriptor) *astjson.Value {
+	switch field.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		switch field.Message().FullName() {
+		case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+			// wrappers are rendered as their plain value
+		default:
+			renderIntegersAsNumbers(arena, value, field.Message())
+			return nil
+		}
+	default:
+		return nil
+	}
+	if value.Type() != astjson.TypeString {
+		return nil
+	}
+	return arena.NewNumberString(string(value.GetStringBytes()))
+}
+
+type upstreamResponse struct {
+	Errors []upstreamError `json:"errors"`
+}
+
+type upstreamError struct {
+	Message    string            `json:"message"`
+	Extensions map[string]string `json:"extensions"`
+}
+
+func writeStatusError(st *status.Status, out *bytes.Buffer) error {
+	return json.NewEncoder(out).Encode(upstreamResponse{
+		Errors: []upstreamError{
+			{
+				Message: st.Message(),
+				Extensions: map[string]string{
+					"code": errorCode(st.Code()),
+				},
+			},
+		},
+	})
+}
+
+// errorCode renders the status code in the style of GraphQL error codes, e.g. NOT_FOUND for codes.NotFound
+func errorCode(code codes.Code) string {
+	name := code.String()
+	out := make([]rune, 0, len(name)+4)
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			out = append(out, '_')
+		}
+		out = append(out, unicode.ToUpper(r))
+	}
+	return string(out)
+}
@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/buger/jsonparser"
+	"github.com/klauspost/compress/zstd"
+	"github.com/tidwall/sjson"
+)
+
+// RequestCompression configures the compression of request bodies sent to an upstream.
+// The upstream must support the Content-Encoding of the compressed bodies.
+type RequestCompression struct {
+	// Encoding is the content encoding of compressed bodies, either EncodingGzip or EncodingZstd
+	Encoding string
+	// MinSize is the minimum size of a body in bytes to be compressed, smaller bodies are sent uncompressed
+	MinSize int
+}
+
+func (c RequestCompression) Validate() error {
+	switch c.Encoding {
+	case EncodingGzip, EncodingZstd:
+		return nil
+	default:
+		return fmt.Errorf("unsupported request compression encoding: %q", c.Encoding)
+	}
+}
+
+var (
+	compressionInputPaths = [][]string{
+		{COMPRESSION, "encoding"},
+		{COMPRESSION, "min_size"},
+	}
+
+	gzipWriterPool = sync.Pool{
+		New: func() any {
+			return gzip.NewWriter(nil)
+		},
+	}
+
+	zstdEncoderOnce sync.Once
+	zstdEncoder     *zstd.Encoder
+	zstdEncoderErr  error
+)
+
+type compressionInput struct {
+	Encoding string `json:"encoding"`
+	MinSize  int    `json:"min_size"`
+}
+
+func SetInputCompression(input []byte, compression RequestCompression) []byte {
+	value, _ := json.Marshal(compressionInput(compression))
+	out, _ := sjson.SetRawBytes(input, COMPRESSION, value)
+	return out
+}
+
+// requestBodyEncoding returns the encoding of the request body according to the compression of the input,
+// or an empty string when the body is sent uncompressed
+func requestBodyEncoding(input []byte, bodySize int) string {
+	var (
+		encoding string
+		minSize  int
+	)
+	jsonparser.EachKey(input, func(i int, value []byte, _ jsonparser.ValueType, _ error) {
+		switch i {
+		case 0:
+			encoding = string(value)
+		case 1:
+			minSize, _ = strconv.Atoi(string(value))
+		}
+	}, compressionInputPaths...)
+	if encoding == "" || bodySize == 0 || bodySize < minSize {
+		return ""
+	}
+	return encoding
+}
+
+func compressBody(body []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case EncodingGzip:
+		buf := bytes.NewBuffer(make([]byte, 0, len(body)/2))
+		writer := gzipWriterPool.Get().(*gzip.Writer)
+		defer gzipWriterPool.Put(writer)
+		writer.Reset(buf)
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case EncodingZstd:
+		zstdEncoderOnce.Do(func() {
+			// the encoder is only used with EncodeAll, which is safe for concurrent use
+			zstdEncoder, zstdEncoderErr = zstd.NewWriter(nil)
+		})
+		if zstdEncoderErr != nil {
+			return nil, zstdEncoderErr
+		}
+		return zstdEncoder.EncodeAll(body, make([]byte, 0, len(body)/2)), nil
+	default:
+		return nil, fmt.Errorf("unsupported request compression encoding: %q", encoding)
+	}
+}
@@ -0,0 +1,286 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+const (
+	defaultHealthCheckQuery    = "{ __typename }"
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultHealthCheckTimeout  = 2 * time.Second
+
+	defaultEjectionFailures = 5
+	defaultEjectionDuration = 30 * time.Second
+)
+
+// HealthCheck configures the active probing of the upstreams of an UpstreamPool.
+// An upstream is unhealthy while its last probe failed, a probe fails on errors, non 2xx status codes and GraphQL errors.
+type HealthCheck struct {
+	// Query is sent as GraphQL request to the upstreams. Defaults to { __typename }.
+	Query string
+	// Header is sent with each probe
+	Header http.Header
+	// Interval is the delay between two probes of the upstreams. Defaults to 10s.
+	Interval time.Duration
+	// Timeout of a single probe. Defaults to 2s.
+	Timeout time.Duration
+}
+
+// PassiveEjection ejects an upstream from an UpstreamPool after consecutive failed requests,
+// a request fails on connection errors and 5xx status codes.
+// The upstream is added back once EjectionDuration has passed or a health check probe succeeded.
+type PassiveEjection struct {
+	// ConsecutiveFailures is the number of failed requests after which the upstream is ejected. Defaults to 5.
+	ConsecutiveFailures int
+	// EjectionDuration is the time the upstream is ejected for. Defaults to 30s.
+	EjectionDuration time.Duration
+}
+
+type UpstreamPoolOptions struct {
+	// HealthCheck enables active health checks, they are run by UpstreamPool.Run
+	HealthCheck *HealthCheck
+	// PassiveEjection enables the ejection of upstreams on failed requests
+	PassiveEjection *PassiveEjection
+}
+
+// UpstreamPool load balances requests across the replicas of an upstream in round-robin order and skips unhealthy replicas.
+// An UpstreamPool keeps the health of the replicas and must be shared by all requests to them.
+type UpstreamPool struct {
+	upstreams []*upstream
+	options   UpstreamPoolOptions
+	next      atomic.Uint64
+	now       func() time.Time
+}
+
+type upstream struct {
+	url string
+
+	mu sync.Mutex
+	// probeFailed is true while the last health check probe failed
+	probeFailed  bool
+	failures     int
+	ejectedUntil time.Time
+}
+
+func NewUpstreamPool(urls []string, options UpstreamPoolOptions) (*UpstreamPool, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("upstream pool requires at least one url")
+	}
+	pool := &UpstreamPool{
+		upstreams: make([]*upstream, 0, len(urls)),
+		options:   options,
+		now:       time.Now,
+	}
+	for _, url := range urls {
+		if url == "" {
+			return nil, errors.New("upstream pool urls must not be empty")
+		}
+		pool.upstreams = append(pool.upstreams, &upstream{url: url})
+	}
+	return pool, nil
+}
+
+// URLs returns the urls of all upstreams of the pool
+func (p *UpstreamPool) URLs() []string {
+	urls := make([]string, 0, len(p.upstreams))
+	for _, u := range p.upstreams {
+		urls = append(urls, u.url)
+	}
+	return urls
+}
+
+// Healthy returns the urls of the upstreams which currently receive requests
+func (p *UpstreamPool) Healthy() []string {
+	now := p.now()
+	var urls []string
+	for _, u := range p.upstreams {
+		if u.healthy(now) {
+			urls = append(urls, u.url)
+		}
+	}
+	return urls
+}
+
+// Run probes the upstreams according to the HealthCheck options until ctx is done.
+// Without HealthCheck options Run returns immediately.
+func (p *UpstreamPool) Run(ctx context.Context, client *http.Client) {
+	if p.options.HealthCheck == nil {
+		return
+	}
+	interval := p.options.HealthCheck.Interval
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.Probe(ctx, client)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Probe runs a single health check against all upstreams concurrently and waits for the results
+func (p *UpstreamPool) Probe(ctx context.Context, client *http.Client) {
+	if p.options.HealthCheck == nil {
+		return
+	}
+	wg := &sync.WaitGroup{}
+	for _, u := range p.upstreams {
+		wg.Add(1)
+		go func(u *upstream) {
+			defer wg.Done()
+			healthy := p.probe(ctx, client, u)
+			u.mu.Lock()
+			u.probeFailed = !healthy
+			if healthy {
+				u.failures = 0
+				u.ejectedUntil = time.Time{}
+			}
+			u.mu.Unlock()
+		}(u)
+	}
+	wg.Wait()
+}
+
+func (p *UpstreamPool) probe(ctx context.Context, client *http.Client, u *upstream) bool {
+	check := p.options.HealthCheck
+	query, timeout := check.Query, check.Timeout
+	if query == "" {
+		query = defaultHealthCheckQuery
+	}
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctx, responseContext := InjectResponseContext(ctx)
+
+	body, _ := json.Marshal(map[string]string{"query": query})
+	input := SetInputURL(nil, []byte(u.url))
+	input = SetInputMethod(input, []byte(http.MethodPost))
+	input = SetInputBody(input, body)
+	if len(check.Header) != 0 {
+		header, _ := json.Marshal(check.Header)
+		input = SetInputHeader(input, header)
+	}
+
+	out := &bytes.Buffer{}
+	if err := Do(client, ctx, input, out); err != nil {
+		return false
+	}
+	if responseContext.StatusCode < http.StatusOK || responseContext.StatusCode >= http.StatusMultipleChoices {
+		return false
+	}
+	_, dataType, _, err := jsonparser.Get(out.Bytes(), "errors")
+	return err != nil || dataType == jsonparser.Null
+}
+
+// Next returns the url of the next healthy upstream in round-robin order, e.g. for requests which can't fail over
+func (p *UpstreamPool) Next() string {
+	return p.candidates()[0].url
+}
+
+// candidates returns the healthy upstreams in round-robin order.
+// If no upstream is healthy all upstreams are returned, a failing request is preferred over rejecting it.
+func (p *UpstreamPool) candidates() []*upstream {
+	now := p.now()
+	start := int(p.next.Add(1)-1) % len(p.upstreams)
+	candidates := make([]*upstream, 0, len(p.upstreams))
+	for i := range p.upstreams {
+		u := p.upstreams[(start+i)%len(p.upstreams)]
+		if u.healthy(now) {
+			candidates = append(candidates, u)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range p.upstreams {
+			candidates = append(candidates, p.upstreams[(start+i)%len(p.upstreams)])
+		}
+	}
+	return candidates
+}
+
+// report records the outcome of a request to the upstream for passive ejection
+func (p *UpstreamPool) report(u *upstream, success bool) {
+	ejection := p.options.PassiveEjection
+	if ejection == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if success {
+		u.failures = 0
+		return
+	}
+	u.failures++
+	threshold := ejection.ConsecutiveFailures
+	if threshold < 1 {
+		threshold = defaultEjectionFailures
+	}
+	if u.failures < threshold {
+		return
+	}
+	duration := ejection.EjectionDuration
+	if duration <= 0 {
+		duration = defaultEjectionDuration
+	}
+	u.failures = 0
+	u.ejectedUntil = p.now().Add(duration)
+}
+
+func (u *upstream) healthy(now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return !u.probeFailed && !now.Before(u.ejectedUntil)
+}
+
+// DoWithFailover behaves like DoWithRetry but sends the request to the upstreams of the pool instead of the url of the input.
+// A failed request fails over to the next healthy upstream, each upstream is tried once with the retry policy.
+// Non idempotent requests, e.g. mutations, only fail over when the upstream refused the connection,
+// because the upstream might have processed the request otherwise.
+// The upstream of each attempt is recorded in the ResponseContext of ctx.
+func DoWithFailover(client *http.Client, ctx context.Context, pool *UpstreamPool, policy *RetryPolicy, idempotent bool, requestInput []byte, out *bytes.Buffer) (err error) {
+	responseContext, ok := ResponseContextFromContext(ctx)
+	if !ok {
+		ctx, responseContext = InjectResponseContext(ctx)
+	}
+
+	offset := out.Len()
+	for i, u := range pool.candidates() {
+		if i != 0 {
+			out.Truncate(offset)
+		}
+
+		attempts := len(responseContext.Attempts)
+		err = DoWithRetry(client, ctx, policy, nil, SetInputURL(requestInput, []byte(u.url)), out)
+		for j := attempts; j < len(responseContext.Attempts); j++ {
+			responseContext.Attempts[j].URL = u.url
+		}
+
+		failed := err != nil || responseContext.StatusCode >= http.StatusInternalServerError
+		pool.report(u, !failed)
+		if !failed || ctx.Err() != nil {
+			return err
+		}
+		if !idempotent && !errors.Is(err, syscall.ECONNREFUSED) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,208 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoWithFailover(t *testing.T) {
+	// replica responds with the status code and its name, the status code can be changed while the test runs
+	replica := func(t *testing.T, name string, statusCode int) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+		t.Helper()
+		requests, status := &atomic.Int32{}, &atomic.Int32{}
+		status.Store(int32(statusCode))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if string(body) == `{"query":"{ __typename }"}` {
+				w.WriteHeader(int(status.Load()))
+				_, _ = w.Write([]byte(`{"data":{"__typename":"Query"}}`))
+				return
+			}
+			requests.Add(1)
+			w.WriteHeader(int(status.Load()))
+			_, _ = w.Write([]byte(`{"data":{"replica":"` + name + `"}}`))
+		}))
+		t.Cleanup(server.Close)
+		return server, requests, status
+	}
+
+	// closedURL returns the url of a port without listener, connections to it are refused
+	closedURL := func(t *testing.T) string {
+		t.Helper()
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		url := "http://" + listener.Addr().String()
+		require.NoError(t, listener.Close())
+		return url
+	}
+
+	do := func(t *testing.T, pool *UpstreamPool, idempotent bool) (*ResponseContext, string, error) {
+		t.Helper()
+		ctx, responseContext := InjectResponseContext(context.Background())
+		out := &bytes.Buffer{}
+		input := SetInputMethod(nil, []byte("POST"))
+		input = SetInputBody(input, []byte(`{"query":"{replica}"}`))
+		err := DoWithFailover(http.DefaultClient, ctx, pool, nil, idempotent, input, out)
+		return responseContext, out.String(), err
+	}
+
+	t.Run("load balances across healthy upstreams", func(t *testing.T) {
+		a, aRequests, _ := replica(t, "a", http.StatusOK)
+		b, bRequests, _ := replica(t, "b", http.StatusOK)
+		pool, err := NewUpstreamPool([]string{a.URL, b.URL}, UpstreamPoolOptions{})
+		require.NoError(t, err)
+
+		for _, expected := range []string{"a", "b", "a", "b"} {
+			_, out, err := do(t, pool, true)
+			require.NoError(t, err)
+			assert.Equal(t, `{"data":{"replica":"`+expected+`"}}`, out)
+		}
+		assert.Equal(t, int32(2), aRequests.Load())
+		assert.Equal(t, int32(2), bRequests.Load())
+	})
+
+	t.Run("fails over within a single request", func(t *testing.T) {
+		a, _, _ := replica(t, "a", http.StatusServiceUnavailable)
+		b, _, _ := replica(t, "b", http.StatusOK)
+		pool, err := NewUpstreamPool([]string{a.URL, b.URL}, UpstreamPoolOptions{})
+		require.NoError(t, err)
+
+		responseContext, out, err := do(t, pool, true)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"replica":"b"}}`, out)
+		require.Len(t, responseContext.Attempts, 2)
+		assert.Equal(t, Attempt{StatusCode: http.StatusServiceUnavailable, URL: a.URL}, responseContext.Attempts[0])
+		assert.Equal(t, Attempt{StatusCode: http.StatusOK, URL: b.URL}, responseContext.Attempts[1])
+	})
+
+	t.Run("returns the last failure when all upstreams fail", func(t *testing.T) {
+		a, _, _ := replica(t, "a", http.StatusBadGateway)
+		b, _, _ := replica(t, "b", http.StatusServiceUnavailable)
+		pool, err := NewUpstreamPool([]string{a.URL, b.URL}, UpstreamPoolOptions{})
+		require.NoError(t, err)
+
+		responseContext, out, err := do(t, pool, true)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"replica":"b"}}`, out)
+		assert.Equal(t, http.StatusServiceUnavailable, responseContext.StatusCode)
+	})
+
+	t.Run("non idempotent requests only fail over on refused connections", func(t *testing.T) {
+		a, aRequests, _ := replica(t, "a", http.StatusServiceUnavailable)
+		b, bRequests, _ := replica(t, "b", http.StatusOK)
+		pool, err := NewUpstreamPool([]string{a.URL, b.URL}, UpstreamPoolOptions{})
+		require.NoError(t, err)
+
+		responseContext, _, err := do(t, pool, false)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, responseContext.StatusCode)
+		assert.Equal(t, int32(1), aRequests.Load())
+		assert.Equal(t, int32(0), bRequests.Load())
+
+		pool, err = NewUpstreamPool([]string{closedURL(t), b.URL}, UpstreamPoolOptions{})
+		require.NoError(t, err)
+		_, out, err := do(t, pool, false)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"replica":"b"}}`, out)
+	})
+
+	t.Run("passive ejection", func(t *testing.T) {
+		a, aRequests, aStatus := replica(t, "a", http.StatusInternalServerError)
+		b, _, _ := replica(t, "b", http.StatusOK)
+		pool, err := NewUpstreamPool([]string{a.URL, b.URL}, UpstreamPoolOptions{
+			PassiveEjection: &PassiveEjection{
+				ConsecutiveFailures: 2,
+				EjectionDuration:    time.Minute,
+			},
+		})
+		require.NoError(t, err)
+		now := time.Now()
+		pool.now = func() time.Time { return now }
+
+		for i := 0; i < 4; i++ {
+			_, out, err := do(t, pool, true)
+			require.NoError(t, err)
+			assert.Equal(t, `{"data":{"replica":"b"}}`, out)
+		}
+		// the first and the third request started with a, afterwards a is ejected
+		assert.Equal(t, int32(2), aRequests.Load())
+		assert.Equal(t, []string{b.URL}, pool.Healthy())
+
+		aStatus.Store(http.StatusOK)
+		now = now.Add(time.Minute)
+		assert.Equal(t, []string{a.URL, b.URL}, pool.Healthy())
+	})
+
+	t.Run("active health checks", func(t *testing.T) {
+		a, aRequests, aStatus := replica(t, "a", http.StatusServiceUnavailable)
+		b, _, _ := replica(t, "b", http.StatusOK)
+		pool, err := NewUpstreamPool([]string{a.URL, b.URL}, UpstreamPoolOptions{
+			HealthCheck: &HealthCheck{},
+		})
+		require.NoError(t, err)
+
+		pool.Probe(context.Background(), http.DefaultClient)
+		assert.Equal(t, []string{b.URL}, pool.Healthy())
+		for i := 0; i < 2; i++ {
+			_, out, err := do(t, pool, true)
+			require.NoError(t, err)
+			assert.Equal(t, `{"data":{"replica":"b"}}`, out)
+		}
+		assert.Equal(t, int32(0), aRequests.Load())
+
+		aStatus.Store(http.StatusOK)
+		pool.Probe(context.Background(), http.DefaultClient)
+		assert.Equal(t, []string{a.URL, b.URL}, pool.Healthy())
+	})
+
+	t.Run("health check fails on graphql errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "secret", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"errors":[{"message":"unavailable"}]}`))
+		}))
+		t.Cleanup(server.Close)
+		pool, err := NewUpstreamPool([]string{server.URL}, UpstreamPoolOptions{
+			HealthCheck: &HealthCheck{
+				Query:  "{ health }",
+				Header: http.Header{"Authorization": []string{"secret"}},
+			},
+		})
+		require.NoError(t, err)
+
+		pool.Probe(context.Background(), http.DefaultClient)
+		assert.Empty(t, pool.Healthy())
+	})
+
+	t.Run("all upstreams are tried when none is healthy", func(t *testing.T) {
+		a, aRequests, aStatus := replica(t, "a", http.StatusServiceUnavailable)
+		pool, err := NewUpstreamPool([]string{a.URL}, UpstreamPoolOptions{
+			HealthCheck: &HealthCheck{},
+		})
+		require.NoError(t, err)
+		pool.Probe(context.Background(), http.DefaultClient)
+		require.Empty(t, pool.Healthy())
+
+		aStatus.Store(http.StatusOK)
+		_, out, err := do(t, pool, true)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"replica":"a"}}`, out)
+		assert.Equal(t, int32(1), aRequests.Load())
+	})
+
+	t.Run("invalid urls", func(t *testing.T) {
+		_, err := NewUpstreamPool(nil, UpstreamPoolOptions{})
+		assert.EqualError(t, err, "upstream pool requires at least one url")
+		_, err = NewUpstreamPool([]string{""}, UpstreamPoolOptions{})
+		assert.EqualError(t, err, "upstream pool urls must not be empty")
+	})
+}
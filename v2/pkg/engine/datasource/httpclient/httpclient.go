@@ -33,6 +33,7 @@ const (
 	FORWARDED_CLIENT_HEADER_REGULAR_EXPRESSIONS = "forwarded_client_header_regular_expressions"
 	TRACE                                       = "__trace__"
 	WsSubProtocol                               = "ws_sub_protocol"
+	COMPRESSION                                 = "compression"
 )
 
 var (
@@ -10,8 +10,10 @@ import (
 	"net/http/httputil"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/tidwall/sjson"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/quotes"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/literal"
@@ -74,6 +76,9 @@ func TestHttpClient(t *testing.T) {
 
 	in = SetInputBodyWithPath(nil, []byte(`{"bar":$$0$$}`), "variables.foo")
 	assert.Equal(t, `{"body":{"variables":{"foo":{"bar":$$0$$}}}}`, string(in))
+
+	in = SetInputCompression(nil, RequestCompression{Encoding: EncodingGzip, MinSize: 1024})
+	assert.Equal(t, `{"compression":{"encoding":"gzip","min_size":1024}}`, string(in))
 }
 
 func TestHttpClientDo(t *testing.T) {
@@ -197,6 +202,42 @@ func TestHttpClientDo(t *testing.T) {
 		t.Run("net", runTest(background, input, `ok`))
 	})
 
+	t.Run("request compression", func(t *testing.T) {
+		body := []byte(`{"query":"query($representations: [_Any!]!){_entities(representations: $representations){__typename}}"}`)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reader io.Reader = r.Body
+			switch r.Header.Get("Content-Encoding") {
+			case "gzip":
+				gzipReader, err := gzip.NewReader(r.Body)
+				assert.NoError(t, err)
+				reader = gzipReader
+			case "zstd":
+				zstdReader, err := zstd.NewReader(r.Body)
+				assert.NoError(t, err)
+				defer zstdReader.Close()
+				reader = zstdReader
+			}
+			actualBody, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, string(body), string(actualBody))
+			_, err = w.Write([]byte(r.Header.Get("Content-Encoding")))
+			assert.NoError(t, err)
+		}))
+		defer server.Close()
+
+		input := func(compression RequestCompression) []byte {
+			var input []byte
+			input = SetInputMethod(input, []byte("POST"))
+			input = SetInputBody(input, body)
+			input = SetInputURL(input, []byte(server.URL))
+			return SetInputCompression(input, compression)
+		}
+
+		t.Run("gzip", runTest(background, input(RequestCompression{Encoding: EncodingGzip}), `gzip`))
+		t.Run("zstd", runTest(background, input(RequestCompression{Encoding: EncodingZstd, MinSize: len(body)}), `zstd`))
+		t.Run("below min size", runTest(background, input(RequestCompression{Encoding: EncodingGzip, MinSize: len(body) + 1}), ``))
+	})
+
 	t.Run("redact sensitive headers", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			_, err := httputil.DumpRequest(r, true)
@@ -216,4 +257,25 @@ func TestHttpClientDo(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Contains(t, out.String(), `"Authorization":["****"]`)
 	})
+
+	t.Run("trace context propagation", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Header.Get("traceparent")))
+		}))
+		defer server.Close()
+		var input []byte
+		input = SetInputMethod(input, []byte("POST"))
+		input = SetInputURL(input, []byte(server.URL))
+
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		spanCtx := trace.ContextWithSpanContext(background, trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}))
+
+		t.Run("with span", runTest(spanCtx, input, `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`))
+		t.Run("without span", runTest(background, input, ``))
+	})
 }
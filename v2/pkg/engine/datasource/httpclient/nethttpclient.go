@@ -12,12 +12,15 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/buger/jsonparser"
+	"go.opentelemetry.io/otel/propagation"
+
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/literal"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/pool"
 )
@@ -30,6 +33,7 @@ const (
 
 	EncodingGzip    = "gzip"
 	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
 
 	ContentTypeJSON = "application/json"
 )
@@ -49,8 +53,10 @@ var (
 )
 
 type TraceHTTP struct {
-	Request  TraceHTTPRequest  `json:"request"`
-	Response TraceHTTPResponse `json:"response"`
+	Request    TraceHTTPRequest    `json:"request"`
+	Response   TraceHTTPResponse   `json:"response"`
+	// Connection is omitted from predictable traces, see WithPredictableTrace
+	Connection *TraceHTTPConnection `json:"connection,omitempty"`
 }
 
 type TraceHTTPRequest struct {
@@ -59,6 +65,18 @@ type TraceHTTPRequest struct {
 	Headers http.Header `json:"headers"`
 }
 
+// TraceHTTPConnection describes the connection the request was sent on
+type TraceHTTPConnection struct {
+	// Protocol of the response, e.g. HTTP/1.1 or HTTP/2.0
+	Protocol string `json:"protocol"`
+	// Reused is true if the connection was used for a previous request
+	Reused bool `json:"reused"`
+	// WasIdle is true if the connection was taken from the idle pool
+	WasIdle bool `json:"was_idle"`
+	// IdleTimeMs is the time the connection was idle before the request
+	IdleTimeMs int64 `json:"idle_time_ms"`
+}
+
 type TraceHTTPResponse struct {
 	StatusCode int         `json:"status_code"`
 	Status     string      `json:"status"`
@@ -72,6 +90,10 @@ type ResponseContext struct {
 	StatusCode int
 	Request    *http.Request
 	Response   *http.Response
+	// Attempts are the requests made by DoWithRetry
+	Attempts []Attempt
+	// CircuitOpen is true when DoWithRetry rejected the request because the circuit breaker was open
+	CircuitOpen bool
 }
 
 func InjectResponseContext(ctx context.Context) (context.Context, *ResponseContext) {
@@ -79,6 +101,12 @@ func InjectResponseContext(ctx context.Context) (context.Context, *ResponseConte
 	return context.WithValue(ctx, responseContextKey{}, value), value
 }
 
+// ResponseContextFromContext returns the ResponseContext injected with InjectResponseContext
+func ResponseContextFromContext(ctx context.Context) (*ResponseContext, bool) {
+	value, ok := ctx.Value(responseContextKey{}).(*ResponseContext)
+	return value, ok
+}
+
 func setRequest(ctx context.Context, request *http.Request) {
 	if value, ok := ctx.Value(responseContextKey{}).(*ResponseContext); ok {
 		value.Request = request
@@ -129,6 +157,14 @@ func respBodyReader(res *http.Response) (io.Reader, error) {
 	}
 }
 
+type predictableTraceContextKey struct{}
+
+// WithPredictableTrace omits the connection from the trace of requests made with the returned context,
+// so that the trace output is predictable for debugging purposes
+func WithPredictableTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, predictableTraceContextKey{}, true)
+}
+
 type bodyHashContextKey struct{}
 
 func BodyHashFromContext(ctx context.Context) (uint64, bool) {
@@ -139,7 +175,25 @@ func BodyHashFromContext(ctx context.Context) (uint64, bool) {
 	return value.(uint64), true
 }
 
-func makeHTTPRequest(client *http.Client, ctx context.Context, url, method, headers, queryParams []byte, body io.Reader, enableTrace bool, out *bytes.Buffer, contentType string) (err error) {
+// traceContext propagates spans in the W3C Trace Context format
+var traceContext = propagation.TraceContext{}
+
+// InjectTraceContext sets the W3C traceparent and tracestate headers of the OpenTelemetry span of the context,
+// so that the spans of the subgraphs become children of the fetch. The headers are not set without a valid span.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	traceContext.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+func makeHTTPRequest(client *http.Client, ctx context.Context, url, method, headers, queryParams []byte, body io.Reader, enableTrace bool, out *bytes.Buffer, contentType, contentEncoding string) (err error) {
+
+	var connection *httptrace.GotConnInfo
+	if enableTrace && ctx.Value(predictableTraceContextKey{}) == nil {
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				connection = &info
+			},
+		})
+	}
 
 	request, err := http.NewRequestWithContext(ctx, string(method), string(url), body)
 	if err != nil {
@@ -196,8 +250,12 @@ func makeHTTPRequest(client *http.Client, ctx context.Context, url, method, head
 
 	request.Header.Add(AcceptHeader, ContentTypeJSON)
 	request.Header.Add(ContentTypeHeader, contentType)
+	if contentEncoding != "" {
+		request.Header.Set(ContentEncodingHeader, contentEncoding)
+	}
 	request.Header.Set(AcceptEncodingHeader, EncodingGzip)
 	request.Header.Add(AcceptEncodingHeader, EncodingDeflate)
+	InjectTraceContext(ctx, request.Header)
 
 	setRequest(ctx, request)
 
@@ -241,6 +299,14 @@ func makeHTTPRequest(client *http.Client, ctx context.Context, url, method, head
 			BodySize:   len(data),
 		},
 	}
+	if connection != nil {
+		responseTrace.Connection = &TraceHTTPConnection{
+			Protocol:   response.Proto,
+			Reused:     connection.Reused,
+			WasIdle:    connection.WasIdle,
+			IdleTimeMs: connection.IdleTime.Milliseconds(),
+		}
+	}
 	trace, err := json.Marshal(responseTrace)
 	if err != nil {
 		return err
@@ -260,7 +326,14 @@ func Do(client *http.Client, ctx context.Context, requestInput []byte, out *byte
 	bodyHash := h.Sum64()
 	pool.Hash64.Put(h)
 	ctx = context.WithValue(ctx, bodyHashContextKey{}, bodyHash)
-	return makeHTTPRequest(client, ctx, url, method, headers, queryParams, bytes.NewReader(body), enableTrace, out, ContentTypeJSON)
+	contentEncoding := requestBodyEncoding(requestInput, len(body))
+	if contentEncoding != "" {
+		body, err = compressBody(body, contentEncoding)
+		if err != nil {
+			return err
+		}
+	}
+	return makeHTTPRequest(client, ctx, url, method, headers, queryParams, bytes.NewReader(body), enableTrace, out, ContentTypeJSON, contentEncoding)
 }
 
 func DoMultipartForm(
@@ -323,7 +396,7 @@ func DoMultipartForm(
 	bodyHash := h.Sum64()
 	ctx = context.WithValue(ctx, bodyHashContextKey{}, bodyHash)
 
-	return makeHTTPRequest(client, ctx, url, method, headers, queryParams, multipartBody, enableTrace, out, contentType)
+	return makeHTTPRequest(client, ctx, url, method, headers, queryParams, multipartBody, enableTrace, out, contentType, "")
 }
 
 func multipartBytes(values map[string]io.Reader, files []File) (*io.PipeReader, string, error) {
@@ -0,0 +1,217 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = 50 * time.Millisecond
+	defaultMaxBackoff     = time.Second
+)
+
+// ErrCircuitOpen is returned without calling the upstream while the circuit breaker of the upstream is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// RetryPolicy configures how often a failed request to an upstream is retried.
+// Requests are retried on connection errors, e.g. a connection reset by the upstream, and on 5xx status codes.
+// A RetryPolicy must only be used for idempotent requests.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one, values below 2 disable retries
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, it doubles with each retry. Defaults to 50ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts. Defaults to 1s.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the delay which is randomized, e.g. 0.2 waits between 80% and 100% of the delay.
+	// Values are clamped to [0, 1].
+	Jitter float64
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the delay before the given retry, starting with 1 for the first retry
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	delay, maxDelay := p.InitialBackoff, p.MaxBackoff
+	if delay <= 0 {
+		delay = defaultInitialBackoff
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxBackoff
+	}
+	for i := 1; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	jitter := min(max(p.Jitter, 0), 1)
+	return delay - time.Duration(jitter*rand.Float64()*float64(delay))
+}
+
+// Attempt is a single request to the upstream made by DoWithRetry
+type Attempt struct {
+	StatusCode int
+	Err        error
+	// Backoff is the delay before the attempt
+	Backoff time.Duration
+	// URL is the upstream of the attempt, it's only set by DoWithFailover
+	URL string
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker stops requests to an unhealthy upstream.
+// The circuit opens after FailureThreshold consecutive failed attempts and rejects all requests with ErrCircuitOpen.
+// Once OpenDuration has passed a single probe request is let through,
+// the circuit closes when the probe succeeds and opens again when it fails.
+// A CircuitBreaker keeps the state of a single upstream and must be shared by all requests to it.
+type CircuitBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+	now              func() time.Time
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+	}
+}
+
+// Allow reports whether a request to the upstream may be made.
+// Every allowed request must be followed by a call to Report.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// the probe is still in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// Report records the outcome of an allowed request
+func (b *CircuitBreaker) Report(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.failureThreshold {
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// DoWithRetry behaves like Do but retries failed requests according to the policy and consults the circuit breaker before each attempt.
+// A nil policy makes a single attempt, a nil breaker is never open.
+// The attempts are recorded in the ResponseContext of ctx.
+func DoWithRetry(client *http.Client, ctx context.Context, policy *RetryPolicy, breaker *CircuitBreaker, requestInput []byte, out *bytes.Buffer) (err error) {
+	responseContext, ok := ResponseContextFromContext(ctx)
+	if !ok {
+		ctx, responseContext = InjectResponseContext(ctx)
+	}
+
+	offset := out.Len()
+	maxAttempts := policy.maxAttempts()
+	for attempt := 1; ; attempt++ {
+		var backoff time.Duration
+		if attempt > 1 {
+			backoff = policy.backoff(attempt - 1)
+			if err = sleep(ctx, backoff); err != nil {
+				return err
+			}
+			out.Truncate(offset)
+		}
+
+		if breaker != nil && !breaker.Allow() {
+			responseContext.CircuitOpen = true
+			return ErrCircuitOpen
+		}
+
+		responseContext.StatusCode = 0
+		err = Do(client, ctx, requestInput, out)
+		statusCode := responseContext.StatusCode
+		responseContext.Attempts = append(responseContext.Attempts, Attempt{
+			StatusCode: statusCode,
+			Err:        err,
+			Backoff:    backoff,
+		})
+
+		failed := err != nil || statusCode >= http.StatusInternalServerError
+		if breaker != nil {
+			breaker.Report(!failed)
+		}
+		if !failed || attempt >= maxAttempts || !isRetryable(ctx, err) {
+			return err
+		}
+	}
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err == nil {
+		// the upstream responded with a 5xx status code
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoWithRetry(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	}
+
+	// upstream responds with the status codes in order, the last status code is repeated
+	upstream := func(t *testing.T, statusCodes ...int) (*httptest.Server, *atomic.Int32) {
+		t.Helper()
+		requests := &atomic.Int32{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			i := int(requests.Add(1)) - 1
+			statusCode := statusCodes[min(i, len(statusCodes)-1)]
+			if statusCode == 0 {
+				// reset the connection without responding
+				conn, _, err := w.(http.Hijacker).Hijack()
+				require.NoError(t, err)
+				_ = conn.Close()
+				return
+			}
+			w.WriteHeader(statusCode)
+			_, _ = w.Write([]byte(`{"data":{"attempt":` + strconv.Itoa(i+1) + `}}`))
+		}))
+		t.Cleanup(server.Close)
+		return server, requests
+	}
+
+	do := func(t *testing.T, server *httptest.Server, policy *RetryPolicy, breaker *CircuitBreaker) (*ResponseContext, string, error) {
+		t.Helper()
+		ctx, responseContext := InjectResponseContext(context.Background())
+		out := &bytes.Buffer{}
+		input := SetInputURL(nil, []byte(server.URL))
+		input = SetInputMethod(input, []byte("POST"))
+		err := DoWithRetry(http.DefaultClient, ctx, policy, breaker, input, out)
+		return responseContext, out.String(), err
+	}
+
+	t.Run("retries 5xx status codes", func(t *testing.T) {
+		server, requests := upstream(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+		responseContext, out, err := do(t, server, policy, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), requests.Load())
+		assert.Equal(t, `{"data":{"attempt":3}}`, out)
+		assert.Equal(t, http.StatusOK, responseContext.StatusCode)
+		require.Len(t, responseContext.Attempts, 3)
+		assert.Equal(t, http.StatusServiceUnavailable, responseContext.Attempts[0].StatusCode)
+		assert.Equal(t, time.Duration(0), responseContext.Attempts[0].Backoff)
+		assert.Equal(t, http.StatusBadGateway, responseContext.Attempts[1].StatusCode)
+		assert.Equal(t, time.Millisecond, responseContext.Attempts[1].Backoff)
+		assert.Equal(t, http.StatusOK, responseContext.Attempts[2].StatusCode)
+	})
+
+	t.Run("retries reset connections", func(t *testing.T) {
+		server, requests := upstream(t, 0, http.StatusOK)
+		responseContext, out, err := do(t, server, policy, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), requests.Load())
+		assert.Equal(t, `{"data":{"attempt":2}}`, out)
+		require.Len(t, responseContext.Attempts, 2)
+		assert.Error(t, responseContext.Attempts[0].Err)
+	})
+
+	t.Run("does not retry 4xx status codes", func(t *testing.T) {
+		server, requests := upstream(t, http.StatusBadRequest)
+		responseContext, _, err := do(t, server, policy, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+		assert.Equal(t, http.StatusBadRequest, responseContext.StatusCode)
+	})
+
+	t.Run("stops after max attempts", func(t *testing.T) {
+		server, requests := upstream(t, http.StatusInternalServerError)
+		responseContext, out, err := do(t, server, policy, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), requests.Load())
+		assert.Equal(t, `{"data":{"attempt":3}}`, out)
+		assert.Equal(t, http.StatusInternalServerError, responseContext.StatusCode)
+	})
+
+	t.Run("without policy", func(t *testing.T) {
+		server, requests := upstream(t, http.StatusInternalServerError)
+		responseContext, _, err := do(t, server, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+		assert.Len(t, responseContext.Attempts, 1)
+	})
+
+	t.Run("open circuit breaker stops retries", func(t *testing.T) {
+		server, requests := upstream(t, http.StatusInternalServerError)
+		breaker := NewCircuitBreaker(2, time.Minute)
+
+		responseContext, _, err := do(t, server, policy, breaker)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.True(t, responseContext.CircuitOpen)
+		assert.Equal(t, int32(2), requests.Load())
+
+		responseContext, _, err = do(t, server, policy, breaker)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Len(t, responseContext.Attempts, 0)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, time.Second)
+	breaker.now = func() time.Time {
+		return now
+	}
+
+	assert.True(t, breaker.Allow())
+	breaker.Report(false)
+	assert.True(t, breaker.Allow())
+	breaker.Report(true)
+
+	// consecutive failures open the circuit
+	assert.True(t, breaker.Allow())
+	breaker.Report(false)
+	assert.True(t, breaker.Allow())
+	breaker.Report(false)
+	assert.False(t, breaker.Allow())
+
+	// a single probe is allowed after the open duration, a failed probe opens the circuit again
+	now = now.Add(time.Second)
+	assert.True(t, breaker.Allow())
+	assert.False(t, breaker.Allow())
+	breaker.Report(false)
+	assert.False(t, breaker.Allow())
+
+	// a successful probe closes the circuit
+	now = now.Add(time.Second)
+	assert.True(t, breaker.Allow())
+	breaker.Report(true)
+	assert.True(t, breaker.Allow())
+	assert.True(t, breaker.Allow())
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	assert.Equal(t, time.Second, policy.backoff(5))
+	assert.Equal(t, time.Second, policy.backoff(10))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 100*time.Millisecond)
+	}
+
+	assert.Equal(t, defaultInitialBackoff, (&RetryPolicy{}).backoff(1))
+}
@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// Protocol is the HTTP protocol a subgraph transport speaks to the upstreams
+// HTTP/3 is not supported, NewTransport rejects unknown protocols
+type Protocol string
+
+const (
+	// ProtocolHTTP1 only uses HTTP/1.1
+	ProtocolHTTP1 Protocol = "http1"
+	// ProtocolHTTP2 negotiates HTTP/2 with TLS upstreams and falls back to HTTP/1.1, it's the default
+	ProtocolHTTP2 Protocol = "http2"
+	// ProtocolH2C uses HTTP/2 without TLS and without upgrade (prior knowledge), e.g. for internal subgraphs.
+	// All upstreams of the transport must support h2c.
+	ProtocolH2C Protocol = "h2c"
+)
+
+const (
+	defaultMaxIdleConnsPerHost = 1024
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultDialTimeout         = 30 * time.Second
+)
+
+// ConnectionReporter receives the connection pool events of a subgraph transport.
+// Hosts are reported as host:port.
+type ConnectionReporter interface {
+	// ConnectionOpened is called when a new connection to the host is established
+	ConnectionOpened(host string)
+	// ConnectionClosed is called when a connection to the host is closed
+	ConnectionClosed(host string)
+	// ConnectionAcquired is called when a request got a connection to the host, reused is true for pooled connections
+	ConnectionAcquired(host string, reused bool)
+}
+
+type TransportOptions struct {
+	// Protocol defaults to ProtocolHTTP2
+	Protocol Protocol
+	// MaxIdleConnsPerHost limits the idle HTTP/1.1 connections kept per host. Defaults to 1024.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes connections which have been idle for the duration. Defaults to 90s.
+	IdleConnTimeout time.Duration
+	// DialTimeout limits the time to establish a connection. Defaults to 30s.
+	DialTimeout time.Duration
+	// TLSClientConfig is used for TLS upstreams, it's not used with ProtocolH2C
+	TLSClientConfig *tls.Config
+	// Reporter receives the connection pool events, it's optional
+	Reporter ConnectionReporter
+}
+
+// NewTransport creates a transport for subgraph clients, e.g. &http.Client{Transport: transport, Timeout: 10 * time.Second}
+func NewTransport(options TransportOptions) (http.RoundTripper, error) {
+	if options.MaxIdleConnsPerHost <= 0 {
+		options.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if options.IdleConnTimeout <= 0 {
+		options.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if options.DialTimeout <= 0 {
+		options.DialTimeout = defaultDialTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   options.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil || options.Reporter == nil {
+			return conn, err
+		}
+		options.Reporter.ConnectionOpened(addr)
+		return &reportingConn{Conn: conn, host: addr, reporter: options.Reporter}, nil
+	}
+
+	var transport http.RoundTripper
+	switch options.Protocol {
+	case ProtocolH2C:
+		transport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+			IdleConnTimeout: options.IdleConnTimeout,
+		}
+	case ProtocolHTTP1, ProtocolHTTP2, "":
+		httpTransport := &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dial,
+			MaxIdleConnsPerHost: options.MaxIdleConnsPerHost,
+			IdleConnTimeout:     options.IdleConnTimeout,
+			TLSClientConfig:     options.TLSClientConfig,
+			ForceAttemptHTTP2:   options.Protocol != ProtocolHTTP1,
+		}
+		if options.Protocol == ProtocolHTTP1 {
+			// a non nil empty map disables HTTP/2
+			httpTransport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+		transport = httpTransport
+	default:
+		return nil, fmt.Errorf("unsupported transport protocol: %q", options.Protocol)
+	}
+
+	if options.Reporter == nil {
+		return transport, nil
+	}
+	return &reportingTransport{transport: transport, reporter: options.Reporter}, nil
+}
+
+// reportingTransport reports the connection of each request
+type reportingTransport struct {
+	transport http.RoundTripper
+	reporter  ConnectionReporter
+}
+
+func (t *reportingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	host := canonicalAddr(request)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.reporter.ConnectionAcquired(host, info.Reused)
+		},
+	}
+	return t.transport.RoundTrip(request.WithContext(httptrace.WithClientTrace(request.Context(), trace)))
+}
+
+func (t *reportingTransport) CloseIdleConnections() {
+	if closer, ok := t.transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// canonicalAddr returns the host:port of the request url, the port defaults to the port of the scheme
+func canonicalAddr(request *http.Request) string {
+	if port := request.URL.Port(); port != "" {
+		return request.URL.Host
+	}
+	port := "80"
+	if request.URL.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(request.URL.Hostname(), port)
+}
+
+type reportingConn struct {
+	net.Conn
+	host      string
+	reporter  ConnectionReporter
+	closeOnce sync.Once
+}
+
+func (c *reportingConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.reporter.ConnectionClosed(c.host)
+	})
+	return c.Conn.Close()
+}
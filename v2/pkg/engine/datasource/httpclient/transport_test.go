@@ -0,0 +1,171 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type testConnectionReporter struct {
+	mu       sync.Mutex
+	opened   map[string]int
+	closed   map[string]int
+	acquired []bool
+}
+
+func (r *testConnectionReporter) ConnectionOpened(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.opened[host]++
+}
+
+func (r *testConnectionReporter) ConnectionClosed(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed[host]++
+}
+
+func (r *testConnectionReporter) ConnectionAcquired(host string, reused bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.acquired = append(r.acquired, reused)
+}
+
+func TestNewTransport(t *testing.T) {
+	protoHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"proto":"` + r.Proto + `"}}`))
+	})
+
+	do := func(t *testing.T, client *http.Client, url string, enableTrace bool) string {
+		t.Helper()
+		input := SetInputURL(nil, []byte(url))
+		input = SetInputMethod(input, []byte("POST"))
+		if enableTrace {
+			input = SetInputFlag(input, TRACE)
+		}
+		out := &bytes.Buffer{}
+		require.NoError(t, Do(client, context.Background(), input, out))
+		return out.String()
+	}
+
+	newReporter := func() *testConnectionReporter {
+		return &testConnectionReporter{
+			opened: map[string]int{},
+			closed: map[string]int{},
+		}
+	}
+
+	t.Run("h2c with prior knowledge", func(t *testing.T) {
+		server := httptest.NewServer(h2c.NewHandler(protoHandler, &http2.Server{}))
+		defer server.Close()
+
+		reporter := newReporter()
+		transport, err := NewTransport(TransportOptions{
+			Protocol: ProtocolH2C,
+			Reporter: reporter,
+		})
+		require.NoError(t, err)
+		client := &http.Client{Transport: transport}
+
+		assert.Equal(t, `{"data":{"proto":"HTTP/2.0"}}`, do(t, client, server.URL, false))
+		assert.Equal(t, `{"data":{"proto":"HTTP/2.0"}}`, do(t, client, server.URL, false))
+
+		host := strings.TrimPrefix(server.URL, "http://")
+		assert.Equal(t, map[string]int{host: 1}, reporter.opened)
+		assert.Equal(t, []bool{false, true}, reporter.acquired)
+
+		client.CloseIdleConnections()
+		assert.Equal(t, map[string]int{host: 1}, reporter.closed)
+	})
+
+	t.Run("http2 over tls", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(protoHandler)
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		transport, err := NewTransport(TransportOptions{
+			TLSClientConfig: server.Client().Transport.(*http.Transport).TLSClientConfig,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"data":{"proto":"HTTP/2.0"}}`, do(t, &http.Client{Transport: transport}, server.URL, false))
+	})
+
+	t.Run("http1", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(protoHandler)
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		transport, err := NewTransport(TransportOptions{
+			Protocol:        ProtocolHTTP1,
+			TLSClientConfig: server.Client().Transport.(*http.Transport).TLSClientConfig,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"data":{"proto":"HTTP/1.1"}}`, do(t, &http.Client{Transport: transport}, server.URL, false))
+	})
+
+	t.Run("connection reuse is added to the trace", func(t *testing.T) {
+		server := httptest.NewServer(protoHandler)
+		defer server.Close()
+
+		reporter := newReporter()
+		transport, err := NewTransport(TransportOptions{
+			Protocol: ProtocolHTTP1,
+			Reporter: reporter,
+		})
+		require.NoError(t, err)
+		client := &http.Client{Transport: transport}
+
+		first := do(t, client, server.URL, true)
+		second := do(t, client, server.URL, true)
+
+		protocol, err := jsonparser.GetString([]byte(first), "extensions", "trace", "connection", "protocol")
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1", protocol)
+		reused, err := jsonparser.GetBoolean([]byte(first), "extensions", "trace", "connection", "reused")
+		require.NoError(t, err)
+		assert.False(t, reused)
+		reused, err = jsonparser.GetBoolean([]byte(second), "extensions", "trace", "connection", "reused")
+		require.NoError(t, err)
+		assert.True(t, reused)
+		wasIdle, err := jsonparser.GetBoolean([]byte(second), "extensions", "trace", "connection", "was_idle")
+		require.NoError(t, err)
+		assert.True(t, wasIdle)
+
+		assert.Equal(t, []bool{false, true}, reporter.acquired)
+	})
+
+	t.Run("predictable trace omits the connection", func(t *testing.T) {
+		server := httptest.NewServer(protoHandler)
+		defer server.Close()
+
+		input := SetInputURL(nil, []byte(server.URL))
+		input = SetInputMethod(input, []byte("POST"))
+		input = SetInputFlag(input, TRACE)
+		ctx := WithPredictableTrace(context.Background())
+		for i := 0; i < 2; i++ {
+			out := &bytes.Buffer{}
+			require.NoError(t, Do(server.Client(), ctx, input, out))
+			_, _, _, err := jsonparser.Get(out.Bytes(), "extensions", "trace", "connection")
+			assert.ErrorIs(t, err, jsonparser.KeyPathNotFoundError)
+		}
+	})
+
+	t.Run("unsupported protocol", func(t *testing.T) {
+		_, err := NewTransport(TransportOptions{Protocol: "h3"})
+		assert.EqualError(t, err, `unsupported transport protocol: "h3"`)
+	})
+}
@@ -91,11 +91,11 @@ func (f *IntrospectionConfigFactory) buildRootDataSourceConfiguration() (plan.Da
 			ChildNodes: []plan.TypeField{
 				{
 					TypeName:   "__Schema",
-					FieldNames: []string{"queryType", "mutationType", "subscriptionType", "types", "directives", "__typename"},
+					FieldNames: []string{"description", "queryType", "mutationType", "subscriptionType", "types", "directives", "__typename"},
 				},
 				{
 					TypeName:   "__Type",
-					FieldNames: []string{"kind", "name", "description", "interfaces", "possibleTypes", "inputFields", "ofType", "__typename"},
+					FieldNames: []string{"kind", "name", "description", "interfaces", "possibleTypes", "inputFields", "ofType", "isOneOf", "specifiedByURL", "__typename"},
 				},
 				{
 					TypeName:   "__Field",
@@ -103,7 +103,7 @@ func (f *IntrospectionConfigFactory) buildRootDataSourceConfiguration() (plan.Da
 				},
 				{
 					TypeName:   "__InputValue",
-					FieldNames: []string{"name", "description", "type", "defaultValue", "__typename"},
+					FieldNames: []string{"name", "description", "type", "defaultValue", "isDeprecated", "deprecationReason", "__typename"},
 				},
 				{
 					TypeName:   "__Directive",
@@ -129,7 +129,7 @@ func (f *IntrospectionConfigFactory) buildFieldsConfiguration() (plan.DataSource
 			ChildNodes: []plan.TypeField{
 				{
 					TypeName:   "__Type",
-					FieldNames: []string{"kind", "name", "description", "interfaces", "possibleTypes", "inputFields", "ofType", "__typename"},
+					FieldNames: []string{"kind", "name", "description", "interfaces", "possibleTypes", "inputFields", "ofType", "isOneOf", "specifiedByURL", "__typename"},
 				},
 				{
 					TypeName:   "__Field",
@@ -137,7 +137,7 @@ func (f *IntrospectionConfigFactory) buildFieldsConfiguration() (plan.DataSource
 				},
 				{
 					TypeName:   "__InputValue",
-					FieldNames: []string{"name", "description", "type", "defaultValue", "__typename"},
+					FieldNames: []string{"name", "description", "type", "defaultValue", "isDeprecated", "deprecationReason", "__typename"},
 				},
 			},
 		},
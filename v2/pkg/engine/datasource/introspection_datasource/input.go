@@ -2,6 +2,7 @@ package introspection_datasource
 
 import (
 	"bytes"
+	"sort"
 	"strconv"
 )
 
@@ -19,6 +20,19 @@ const (
 	typeFieldName       = "__type"
 	fieldsFieldName     = "fields"
 	enumValuesFieldName = "enumValues"
+
+	argsFieldName        = "args"
+	inputFieldsFieldName = "inputFields"
+
+	includeDeprecatedArgumentName = "includeDeprecated"
+)
+
+// paths of the nested args and inputFields selections rendered by the Source, relative to the root field
+const (
+	schemaTypesInputFieldsPath = "types.inputFields"
+	schemaDirectivesArgsPath   = "directives.args"
+	typeInputFieldsPath        = inputFieldsFieldName
+	fieldArgsPath              = argsFieldName
 )
 
 type introspectionInput struct {
@@ -26,8 +40,15 @@ type introspectionInput struct {
 	OnTypeName        *string     `json:"on_type_name"`
 	TypeName          *string     `json:"type_name"`
 	IncludeDeprecated bool        `json:"include_deprecated"`
+	// NestedIncludeDeprecated mirrors the includeDeprecated argument of nested args and inputFields selections
+	// by their path relative to the root field, e.g. directives.args for __schema
+	NestedIncludeDeprecated map[string]bool `json:"nested_include_deprecated"`
 }
 
+// nestedIncludeDeprecated holds the rendered values of includeDeprecated arguments
+// of nested args and inputFields selections by their path relative to the root field
+type nestedIncludeDeprecated map[string]string
+
 var (
 	lBrace                         = []byte("{")
 	rBrace                         = []byte("}")
@@ -37,9 +58,11 @@ var (
 	typeNameField                  = []byte(`"type_name":"{{ .arguments.name }}"`)
 	includeDeprecatedFieldArgument = []byte(`"include_deprecated":{{ .arguments.includeDeprecated }}`)
 	includeDeprecatedFalse         = []byte(`"include_deprecated":false`)
+	nestedIncludeDeprecatedField   = []byte(`"nested_include_deprecated":`)
+	colon                          = []byte(":")
 )
 
-func buildInput(fieldName string, hasIncludeDeprecatedArgument bool) string {
+func buildInput(fieldName string, hasIncludeDeprecatedArgument bool, nested nestedIncludeDeprecated) string {
 	buf := &bytes.Buffer{}
 	buf.Write(lBrace)
 
@@ -58,6 +81,8 @@ func buildInput(fieldName string, hasIncludeDeprecatedArgument bool) string {
 		writeRequestTypeField(buf, SchemaRequestType)
 	}
 
+	writeNestedIncludeDeprecatedFields(buf, nested)
+
 	buf.Write(rBrace)
 
 	return buf.String()
@@ -78,3 +103,28 @@ func writeOnTypeFields(buf *bytes.Buffer, hasIncludeDeprecatedArgument bool) {
 		buf.Write(includeDeprecatedFalse)
 	}
 }
+
+func writeNestedIncludeDeprecatedFields(buf *bytes.Buffer, nested nestedIncludeDeprecated) {
+	if len(nested) == 0 {
+		return
+	}
+
+	paths := make([]string, 0, len(nested))
+	for path := range nested {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	buf.Write(comma)
+	buf.Write(nestedIncludeDeprecatedField)
+	buf.Write(lBrace)
+	for i, path := range paths {
+		if i != 0 {
+			buf.Write(comma)
+		}
+		buf.WriteString(strconv.Quote(path))
+		buf.Write(colon)
+		buf.WriteString(nested[path])
+	}
+	buf.Write(rBrace)
+}
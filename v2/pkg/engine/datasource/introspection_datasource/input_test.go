@@ -12,7 +12,7 @@ func TestBuildInput(t *testing.T) {
 	run := func(fieldName string, expectedJson string, hasDeprecatedArg bool) func(t *testing.T) {
 		t.Helper()
 		return func(t *testing.T) {
-			actualResult := buildInput(fieldName, hasDeprecatedArg)
+			actualResult := buildInput(fieldName, hasDeprecatedArg, nestedIncludeDeprecated{})
 			assert.Equal(t, expectedJson, actualResult)
 		}
 	}
@@ -23,6 +23,14 @@ func TestBuildInput(t *testing.T) {
 	t.Run("type enum values", run(enumValuesFieldName, `{"request_type":4,"on_type_name":"{{ .object.name }}","include_deprecated":{{ .arguments.includeDeprecated }}}`, true))
 	t.Run("type fields default for include deprecated", run(fieldsFieldName, `{"request_type":3,"on_type_name":"{{ .object.name }}","include_deprecated":false}`, false))
 	t.Run("type enum values for include deprecated", run(enumValuesFieldName, `{"request_type":4,"on_type_name":"{{ .object.name }}","include_deprecated":false}`, false))
+
+	t.Run("nested include deprecated arguments", func(t *testing.T) {
+		actualResult := buildInput(schemaFieldName, false, nestedIncludeDeprecated{"types.inputFields": "$$0$$", "directives.args": "true"})
+		assert.Equal(t, `{"request_type":1,"nested_include_deprecated":{"directives.args":true,"types.inputFields":$$0$$}}`, actualResult)
+
+		actualResult = buildInput(fieldsFieldName, true, nestedIncludeDeprecated{"args": "false"})
+		assert.Equal(t, `{"request_type":3,"on_type_name":"{{ .object.name }}","include_deprecated":{{ .arguments.includeDeprecated }},"nested_include_deprecated":{"args":false}}`, actualResult)
+	})
 }
 
 func TestUnmarshalIntrospectionInput(t *testing.T) {
@@ -41,4 +49,6 @@ func TestUnmarshalIntrospectionInput(t *testing.T) {
 	t.Run("type introspection", run(`{"request_type":2,"type_name":"Foo"}`, introspectionInput{RequestType: TypeRequestType, TypeName: &foo}))
 	t.Run("type fields", run(`{"request_type":3,"on_type_name":"Foo","include_deprecated":true}`, introspectionInput{RequestType: TypeFieldsRequestType, OnTypeName: &foo, IncludeDeprecated: true}))
 	t.Run("type enum values", run(`{"request_type":4,"on_type_name":"Foo","include_deprecated":false}`, introspectionInput{RequestType: TypeEnumValuesRequestType, OnTypeName: &foo, IncludeDeprecated: false}))
+	t.Run("type with deprecated input fields", run(`{"request_type":2,"type_name":"Foo","nested_include_deprecated":{"inputFields":true}}`, introspectionInput{RequestType: TypeRequestType, TypeName: &foo, NestedIncludeDeprecated: map[string]bool{"inputFields": true}}))
+	t.Run("type fields with deprecated args", run(`{"request_type":3,"on_type_name":"Foo","include_deprecated":false,"nested_include_deprecated":{"args":true}}`, introspectionInput{RequestType: TypeFieldsRequestType, OnTypeName: &foo, NestedIncludeDeprecated: map[string]bool{"args": true}}))
 }
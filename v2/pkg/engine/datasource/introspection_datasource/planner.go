@@ -2,6 +2,8 @@ package introspection_datasource
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
@@ -22,6 +24,8 @@ type Planner[T Configuration] struct {
 	rootFielPath                 string
 	hasIncludeDeprecatedArgument bool
 	isArrayItem                  bool
+	nestedIncludeDeprecated      nestedIncludeDeprecated
+	variables                    resolve.Variables
 }
 
 func (p *Planner[T]) SetID(id int) {
@@ -35,6 +39,8 @@ func (p *Planner[T]) ID() (id int) {
 func (p *Planner[T]) Register(visitor *plan.Visitor, dataSourceConfiguration plan.DataSourceConfiguration[T], dataSourcePlannerConfiguration plan.DataSourcePlannerConfiguration) error {
 	p.v = visitor
 	p.rootField = ast.InvalidRef
+	p.nestedIncludeDeprecated = nestedIncludeDeprecated{}
+	p.variables = nil
 	p.isArrayItem = dataSourcePlannerConfiguration.PathType == plan.PlannerPathArrayItem
 	visitor.Walker.RegisterEnterFieldVisitor(p)
 	return nil
@@ -64,11 +70,57 @@ func (p *Planner[T]) EnterField(ref int) {
 		p.rootField = ref
 		p.rootFieldName = fieldName
 		p.rootFielPath = fieldAliasOrName
+	case argsFieldName, inputFieldsFieldName:
+		if value := p.includeDeprecatedArgumentValue(ref); value != "" {
+			p.nestedIncludeDeprecated[p.nestedFieldPath(ref)] = value
+		}
 	}
 }
 
+// nestedFieldPath returns the path of a nested field relative to the root field, e.g. types.inputFields for __schema.
+// Field names are used instead of aliases, they match the keys of the data rendered by the Source.
+func (p *Planner[T]) nestedFieldPath(ref int) string {
+	path := &strings.Builder{}
+	for _, ancestor := range p.v.Walker.Ancestors {
+		if ancestor.Kind != ast.NodeKindField {
+			continue
+		}
+		if ancestor.Ref == p.rootField {
+			path.Reset()
+			continue
+		}
+		path.WriteString(p.v.Operation.FieldNameString(ancestor.Ref))
+		path.WriteString(".")
+	}
+	path.WriteString(p.v.Operation.FieldNameString(ref))
+	return path.String()
+}
+
+// includeDeprecatedArgumentValue renders the includeDeprecated argument of a nested field
+// into the input. Variables are resolved from the request context at execution time.
+func (p *Planner[T]) includeDeprecatedArgumentValue(ref int) string {
+	argRef, ok := p.v.Operation.FieldArgument(ref, []byte(includeDeprecatedArgumentName))
+	if !ok {
+		return ""
+	}
+
+	value := p.v.Operation.ArgumentValue(argRef)
+	switch value.Kind {
+	case ast.ValueKindBoolean:
+		return strconv.FormatBool(bool(p.v.Operation.BooleanValue(value.Ref)))
+	case ast.ValueKindVariable:
+		variableName, _ := p.variables.AddVariable(&resolve.ContextVariable{
+			Path:     []string{p.v.Operation.VariableValueNameString(value.Ref)},
+			Renderer: resolve.NewPlainVariableRenderer(),
+		})
+		return variableName
+	}
+
+	return ""
+}
+
 func (p *Planner[T]) configureInput() string {
-	return buildInput(p.rootFieldName, p.hasIncludeDeprecatedArgument)
+	return buildInput(p.rootFieldName, p.hasIncludeDeprecatedArgument, p.nestedIncludeDeprecated)
 }
 
 func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
@@ -88,6 +140,7 @@ func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
 
 	return resolve.FetchConfiguration{
 		Input:                         p.configureInput(),
+		Variables:                     p.variables,
 		RequiresParallelListItemFetch: requiresParallelListItemFetch,
 		DataSource: &Source{
 			introspectionData: p.introspectionData,
@@ -27,33 +27,43 @@ func (s *Source) Load(ctx context.Context, input []byte, out *bytes.Buffer) (err
 
 	switch req.RequestType {
 	case TypeRequestType:
-		return s.singleType(out, req.TypeName)
+		return s.singleType(out, req.TypeName, req.NestedIncludeDeprecated[typeInputFieldsPath])
 	case TypeEnumValuesRequestType:
 		return s.enumValuesForType(out, req.OnTypeName, req.IncludeDeprecated)
 	case TypeFieldsRequestType:
-		return s.fieldsForType(out, req.OnTypeName, req.IncludeDeprecated)
+		return s.fieldsForType(out, req.OnTypeName, req.IncludeDeprecated, req.NestedIncludeDeprecated[fieldArgsPath])
 	}
 
-	return json.NewEncoder(out).Encode(s.schemaWithoutTypeInfo())
+	return json.NewEncoder(out).Encode(s.schemaWithoutTypeInfo(req.NestedIncludeDeprecated[schemaTypesInputFieldsPath], req.NestedIncludeDeprecated[schemaDirectivesArgsPath]))
 }
 
 func (s *Source) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) (err error) {
 	panic("not implemented")
 }
 
-func (s *Source) schemaWithoutTypeInfo() introspection.Schema {
+func (s *Source) schemaWithoutTypeInfo(includeDeprecatedInputFields, includeDeprecatedArgs bool) introspection.Schema {
 	types := make([]*introspection.FullType, 0, len(s.introspectionData.Schema.Types))
 
 	for i := range s.introspectionData.Schema.Types {
-		types = append(types, s.typeWithoutFieldAndEnumValues(s.introspectionData.Schema.Types[i]))
+		types = append(types, s.typeWithoutFieldAndEnumValues(s.introspectionData.Schema.Types[i], includeDeprecatedInputFields))
+	}
+
+	directives := s.introspectionData.Schema.Directives
+	if !includeDeprecatedArgs {
+		directives = make([]introspection.Directive, 0, len(s.introspectionData.Schema.Directives))
+		for _, directive := range s.introspectionData.Schema.Directives {
+			directive.Args = withoutDeprecatedInputValues(directive.Args)
+			directives = append(directives, directive)
+		}
 	}
 
 	return introspection.Schema{
+		Description:      s.introspectionData.Schema.Description,
 		QueryType:        s.introspectionData.Schema.QueryType,
 		MutationType:     s.introspectionData.Schema.MutationType,
 		SubscriptionType: s.introspectionData.Schema.SubscriptionType,
 		Types:            types,
-		Directives:       s.introspectionData.Schema.Directives,
+		Directives:       directives,
 		TypeName:         s.introspectionData.Schema.TypeName,
 	}
 }
@@ -71,38 +81,45 @@ func (s *Source) writeNull(w io.Writer) error {
 	return err
 }
 
-func (s *Source) singleType(w io.Writer, typeName *string) error {
+func (s *Source) singleType(w io.Writer, typeName *string, includeDeprecatedInputFields bool) error {
 	typeInfo := s.typeInfo(typeName)
 	if typeInfo == nil {
 		return s.writeNull(w)
 	}
 
-	return json.NewEncoder(w).Encode(s.typeWithoutFieldAndEnumValues(typeInfo))
+	return json.NewEncoder(w).Encode(s.typeWithoutFieldAndEnumValues(typeInfo, includeDeprecatedInputFields))
 }
 
-func (s *Source) typeWithoutFieldAndEnumValues(typeInfo *introspection.FullType) *introspection.FullType {
+func (s *Source) typeWithoutFieldAndEnumValues(typeInfo *introspection.FullType, includeDeprecatedInputFields bool) *introspection.FullType {
 	typeInfoCopy := *typeInfo
 	typeInfoCopy.Fields = nil
 	typeInfoCopy.EnumValues = nil
+	if !includeDeprecatedInputFields && typeInfoCopy.InputFields != nil {
+		typeInfoCopy.InputFields = withoutDeprecatedInputValues(typeInfoCopy.InputFields)
+	}
 
 	return &typeInfoCopy
 }
 
-func (s *Source) fieldsForType(w io.Writer, typeName *string, includeDeprecated bool) error {
+func (s *Source) fieldsForType(w io.Writer, typeName *string, includeDeprecated, includeDeprecatedArgs bool) error {
 	typeInfo := s.typeInfo(typeName)
 	if typeInfo == nil || len(typeInfo.Fields) == 0 {
 		return s.writeNull(w)
 	}
 
-	if includeDeprecated {
+	if includeDeprecated && includeDeprecatedArgs {
 		return json.NewEncoder(w).Encode(typeInfo.Fields)
 	}
 
 	fields := make([]introspection.Field, 0, len(typeInfo.Fields))
 	for _, field := range typeInfo.Fields {
-		if !field.IsDeprecated {
-			fields = append(fields, field)
+		if field.IsDeprecated && !includeDeprecated {
+			continue
+		}
+		if !includeDeprecatedArgs {
+			field.Args = withoutDeprecatedInputValues(field.Args)
 		}
+		fields = append(fields, field)
 	}
 
 	return json.NewEncoder(w).Encode(fields)
@@ -127,3 +144,14 @@ func (s *Source) enumValuesForType(w io.Writer, typeName *string, includeDepreca
 
 	return json.NewEncoder(w).Encode(enumValues)
 }
+
+func withoutDeprecatedInputValues(inputValues []introspection.InputValue) []introspection.InputValue {
+	filtered := make([]introspection.InputValue, 0, len(inputValues))
+	for _, inputValue := range inputValues {
+		if !inputValue.IsDeprecated {
+			filtered = append(filtered, inputValue)
+		}
+	}
+
+	return filtered
+}
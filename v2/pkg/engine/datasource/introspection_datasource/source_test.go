@@ -57,6 +57,14 @@ func TestSource_Load(t *testing.T) {
 
 		t.Run("of not existing type", run(testSchema, `{"request_type":4,"on_type_name":"NotExisting","include_deprecated":true}`, `not_existing_type`))
 	})
+
+	t.Run("deprecated input values", func(t *testing.T) {
+		t.Run("args include deprecated", run(testSchemaWithDeprecatedInputValues, `{"request_type":3,"on_type_name":"Query","include_deprecated":false,"nested_include_deprecated":{"args":true}}`, `args_with_deprecated`))
+		t.Run("args no deprecated", run(testSchemaWithDeprecatedInputValues, `{"request_type":3,"on_type_name":"Query","include_deprecated":false}`, `args_without_deprecated`))
+		t.Run("input fields include deprecated", run(testSchemaWithDeprecatedInputValues, `{"request_type":2,"type_name":"DroidFilter","nested_include_deprecated":{"inputFields":true}}`, `input_fields_with_deprecated`))
+		t.Run("input fields no deprecated", run(testSchemaWithDeprecatedInputValues, `{"request_type":2,"type_name":"DroidFilter"}`, `input_fields_without_deprecated`))
+		t.Run("specified by url", run(testSchemaWithDeprecatedInputValues, `{"request_type":2,"type_name":"URL"}`, `scalar_specified_by_url`))
+	})
 }
 
 const testSchema = `
@@ -109,3 +117,25 @@ enum Episode {
 type Droid {
     name: String!
 }`
+
+const testSchemaWithDeprecatedInputValues = `
+"The droid schema"
+schema {
+    query: Query
+}
+
+scalar URL @specifiedBy(url: "https://url.spec.whatwg.org")
+
+type Query {
+    droids(filter: DroidFilter, first: Int @deprecated(reason: "use filter"), after: String): [Droid!]
+}
+
+input DroidFilter {
+    name: String
+    model: String @deprecated
+}
+
+type Droid {
+    name: String!
+    homepage: URL
+}`
@@ -0,0 +1,269 @@
+package mock_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+const (
+	defaultListLength = 2
+)
+
+type Configuration struct {
+	// Seed makes the generated responses deterministic, equal seeds generate equal responses for equal operations
+	// and equal entities for equal keys
+	Seed int64
+	// ListLength is the number of items generated for list fields. Defaults to 2.
+	ListLength int
+	// NullProbability is the probability of nullable fields to be null, between 0 and 1. Defaults to 0.
+	NullProbability float64
+	// Overrides maps field coordinates, e.g. User.name, to the JSON value returned for the field instead of a generated one.
+	// Fields of interfaces are overridden by the coordinate of the concrete type or of the interface.
+	Overrides map[string]json.RawMessage
+}
+
+func (c Configuration) listLength() int {
+	if c.ListLength <= 0 {
+		return defaultListLength
+	}
+	return c.ListLength
+}
+
+type Factory[T Configuration] struct {
+	executionContext context.Context
+}
+
+// NewFactory creates a new factory for the mock datasource planner
+func NewFactory(executionContext context.Context) (*Factory[Configuration], error) {
+	if executionContext == nil {
+		return nil, fmt.Errorf("execution context is required")
+	}
+
+	return &Factory[Configuration]{
+		executionContext: executionContext,
+	}, nil
+}
+
+func (f *Factory[T]) Planner(logger abstractlogger.Logger) plan.DataSourcePlanner[T] {
+	return &Planner[T]{}
+}
+
+func (f *Factory[T]) Context() context.Context {
+	return f.executionContext
+}
+
+func (f *Factory[T]) UpstreamSchema(dataSourceConfig plan.DataSourceConfiguration[T]) (*ast.Document, bool) {
+	return nil, false
+}
+
+type Planner[T Configuration] struct {
+	id                      int
+	v                       *plan.Visitor
+	config                  Configuration
+	dataSourcePlannerConfig plan.DataSourcePlannerConfiguration
+	parentField             int
+	selections              []*selection
+	stack                   []*selection
+}
+
+func (p *Planner[T]) SetID(id int) {
+	p.id = id
+}
+
+func (p *Planner[T]) ID() (id int) {
+	return p.id
+}
+
+func (p *Planner[T]) DownstreamResponseFieldAlias(_ int) (alias string, exists bool) {
+	// the mock DataSourcePlanner doesn't rewrite upstream fields: skip
+	return
+}
+
+func (p *Planner[T]) DataSourcePlanningBehavior() plan.DataSourcePlanningBehavior {
+	return plan.DataSourcePlanningBehavior{
+		MergeAliasedRootNodes:      true,
+		OverrideFieldPathFromAlias: true,
+		IncludeTypeNameFields:      true,
+	}
+}
+
+func (p *Planner[T]) Register(visitor *plan.Visitor, configuration plan.DataSourceConfiguration[T], dataSourcePlannerConfiguration plan.DataSourcePlannerConfiguration) error {
+	p.v = visitor
+	p.config = Configuration(configuration.CustomConfiguration())
+	p.dataSourcePlannerConfig = dataSourcePlannerConfiguration
+	p.parentField = ast.InvalidRef
+	visitor.Walker.RegisterFieldVisitor(p)
+	return nil
+}
+
+func (p *Planner[T]) EnterField(ref int) {
+	fieldAliasOrName := p.v.Operation.FieldAliasOrNameString(ref)
+	if len(p.stack) == 0 && plan.IsParentPathField(p.v, p.dataSourcePlannerConfig.ParentPath, ref) {
+		p.parentField = ref
+		return
+	}
+
+	enclosingTypeName := p.v.Walker.EnclosingTypeDefinition.NameString(p.v.Definition)
+	fieldName := p.v.Operation.FieldNameString(ref)
+	sel := &selection{
+		ResponseKey: fieldAliasOrName,
+		FieldName:   fieldName,
+		TypeName:    enclosingTypeName,
+		OnTypes:     p.possibleTypes(p.v.Walker.EnclosingTypeDefinition),
+	}
+
+	if fieldName == "__typename" {
+		sel.Type = &fieldType{Kind: kindTypeName}
+	} else {
+		fieldDefinition, ok := p.v.Walker.FieldDefinition(ref)
+		if !ok {
+			p.v.Walker.StopWithInternalErr(fmt.Errorf("field definition of %s.%s not found", enclosingTypeName, fieldName))
+			return
+		}
+		sel.Type = p.fieldType(p.v.Definition.FieldDefinitionType(fieldDefinition))
+	}
+
+	if len(p.stack) == 0 {
+		p.selections = append(p.selections, sel)
+	} else {
+		parent := p.stack[len(p.stack)-1]
+		parent.Selections = append(parent.Selections, sel)
+	}
+	p.stack = append(p.stack, sel)
+}
+
+func (p *Planner[T]) LeaveField(ref int) {
+	if ref == p.parentField {
+		p.parentField = ast.InvalidRef
+		return
+	}
+	if len(p.stack) != 0 {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+}
+
+func (p *Planner[T]) fieldType(typeRef int) *fieldType {
+	definition := p.v.Definition
+	switch definition.Types[typeRef].TypeKind {
+	case ast.TypeKindNonNull:
+		t := p.fieldType(definition.Types[typeRef].OfType)
+		t.NonNull = true
+		return t
+	case ast.TypeKindList:
+		return &fieldType{
+			Kind:   kindList,
+			OfType: p.fieldType(definition.Types[typeRef].OfType),
+		}
+	}
+
+	typeName := definition.ResolveTypeNameString(typeRef)
+	node, _ := definition.Index.FirstNodeByNameStr(typeName)
+	switch node.Kind {
+	case ast.NodeKindEnumTypeDefinition:
+		refs := definition.EnumTypeDefinitions[node.Ref].EnumValuesDefinition.Refs
+		values := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			values = append(values, definition.EnumValueDefinitionNameString(ref))
+		}
+		return &fieldType{Kind: kindEnum, Name: typeName, Values: values}
+	case ast.NodeKindObjectTypeDefinition:
+		return &fieldType{Kind: kindObject, Name: typeName}
+	case ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
+		return &fieldType{Kind: kindAbstract, Name: typeName, Values: p.possibleTypes(node)}
+	default:
+		return &fieldType{Kind: kindScalar, Name: typeName}
+	}
+}
+
+// possibleTypes returns the sorted object types of an abstract type, or the object type itself
+func (p *Planner[T]) possibleTypes(node ast.Node) []string {
+	var typeNames []string
+	switch node.Kind {
+	case ast.NodeKindInterfaceTypeDefinition:
+		typeNames, _ = p.v.Definition.InterfaceTypeDefinitionImplementedByObjectWithNames(node.Ref)
+	case ast.NodeKindUnionTypeDefinition:
+		typeNames, _ = p.v.Definition.UnionTypeDefinitionMemberTypeNames(node.Ref)
+	default:
+		return []string{node.NameString(p.v.Definition)}
+	}
+	slices.Sort(typeNames)
+	return typeNames
+}
+
+func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
+	source := &Source{
+		config: p.config,
+	}
+
+	if !p.dataSourcePlannerConfig.HasRequiredFields() {
+		input, _ := json.Marshal(sourceInput{
+			Selections: p.selections,
+		})
+		return resolve.FetchConfiguration{
+			Input:      string(input),
+			DataSource: source,
+			PostProcessing: resolve.PostProcessingConfiguration{
+				SelectResponseDataPath:   []string{"data"},
+				SelectResponseErrorsPath: []string{"errors"},
+			},
+		}
+	}
+
+	representation, err := p.representationVariable()
+	if err != nil {
+		p.v.Walker.StopWithInternalErr(err)
+		return resolve.FetchConfiguration{}
+	}
+	variables := resolve.Variables{}
+	variableName, _ := variables.AddVariable(resolve.NewResolvableObjectVariable(representation))
+
+	input, _ := json.Marshal(sourceInput{
+		Selections: p.selections,
+	})
+	isBatch := p.dataSourcePlannerConfig.PathType != plan.PlannerPathObject
+	dataPath := []string{"data", "_entities", "0"}
+	if isBatch {
+		dataPath = []string{"data", "_entities"}
+	}
+
+	return resolve.FetchConfiguration{
+		Input:                                 fmt.Sprintf(`%s,"representations":[%s]}`, input[:len(input)-1], variableName),
+		DataSource:                            source,
+		Variables:                             variables,
+		RequiresEntityFetch:                   !isBatch,
+		RequiresEntityBatchFetch:              isBatch,
+		SetTemplateOutputToNullOnVariableNull: true,
+		PostProcessing: resolve.PostProcessingConfiguration{
+			SelectResponseDataPath:   dataPath,
+			SelectResponseErrorsPath: []string{"errors"},
+		},
+	}
+}
+
+// representationVariable renders the __typename and the key fields of the entities
+func (p *Planner[T]) representationVariable() (*resolve.Object, error) {
+	var keys []plan.FederationFieldConfiguration
+	for _, cfg := range p.dataSourcePlannerConfig.RequiredFields {
+		if cfg.FieldName == "" {
+			keys = append(keys, cfg)
+		}
+	}
+	representation, err := plan.RepresentationVariable(keys...)
+	if err != nil {
+		return nil, fmt.Errorf("mock %w", err)
+	}
+	return representation, nil
+}
+
+func (p *Planner[T]) ConfigureSubscription() plan.SubscriptionConfiguration {
+	// the mock DataSourcePlanner doesn't have subscriptions
+	return plan.SubscriptionConfiguration{}
+}
@@ -0,0 +1,326 @@
+package mock_datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wundergraph/astjson"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasourcetesting"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/postprocess"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+const definition = `
+	type Query {
+		me: User!
+		users: [User!]
+		search: [SearchResult!]!
+		node: Node
+	}
+
+	type User implements Node {
+		id: ID!
+		name: String!
+		age: Int
+		score: Float!
+		active: Boolean!
+		role: Role!
+		friends: [User!]!
+		reviews: [Review!]!
+	}
+
+	type Review implements Node {
+		id: ID!
+		body: String!
+	}
+
+	interface Node {
+		id: ID!
+	}
+
+	union SearchResult = User | Review
+
+	enum Role {
+		ADMIN
+		VIEWER
+	}
+`
+
+func TestMockDataSourcePlanning(t *testing.T) {
+	factory, err := NewFactory(context.Background())
+	require.NoError(t, err)
+
+	users, err := plan.NewDataSourceConfiguration[Configuration](
+		"users",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"me"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"id", "name"}},
+			},
+		},
+		Configuration{},
+	)
+	require.NoError(t, err)
+
+	t.Run("root field", datasourcetesting.RunTest(definition, `query Me { user: me { name } }`, "Me",
+		&plan.SynchronousResponsePlan{
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fetches: []resolve.Fetch{
+						&resolve.SingleFetch{
+							DataSourceIdentifier: []byte("mock_datasource.Source"),
+							FetchConfiguration: resolve.FetchConfiguration{
+								Input:      `{"selections":[{"key":"user","field":"me","type":"Query","on":["Query"],"value":{"kind":"object","name":"User","non_null":true},"selections":[{"key":"name","field":"name","type":"User","on":["User"],"value":{"kind":"scalar","name":"String","non_null":true}}]}]}`,
+								DataSource: &Source{},
+								PostProcessing: resolve.PostProcessingConfiguration{
+									SelectResponseDataPath:   []string{"data"},
+									SelectResponseErrorsPath: []string{"errors"},
+								},
+							},
+						},
+					},
+					Fields: []*resolve.Field{
+						{
+							Name: []byte("user"),
+							Value: &resolve.Object{
+								Path: []string{"user"},
+								PossibleTypes: map[string]struct{}{
+									"User": {},
+								},
+								TypeName: "User",
+								Fields: []*resolve.Field{
+									{
+										Name: []byte("name"),
+										Value: &resolve.String{
+											Path: []string{"name"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		plan.Configuration{
+			DataSources:                  []plan.DataSource{users},
+			DisableResolveFieldPositions: true,
+		},
+	))
+}
+
+func TestMockDataSource(t *testing.T) {
+	factory, err := NewFactory(context.Background())
+	require.NoError(t, err)
+
+	keys := plan.FederationMetaData{
+		Keys: plan.FederationFieldConfigurations{
+			{TypeName: "User", SelectionSet: "id"},
+		},
+	}
+
+	dataSources := func(t *testing.T, config Configuration) []plan.DataSource {
+		users, err := plan.NewDataSourceConfiguration[Configuration](
+			"users",
+			factory,
+			&plan.DataSourceMetadata{
+				RootNodes: []plan.TypeField{
+					{TypeName: "Query", FieldNames: []string{"me", "users", "search", "node"}},
+					{TypeName: "User", FieldNames: []string{"id", "name", "age", "score", "active", "role", "friends"}},
+				},
+				ChildNodes: []plan.TypeField{
+					{TypeName: "Review", FieldNames: []string{"id", "body"}},
+					{TypeName: "Node", FieldNames: []string{"id"}},
+				},
+				FederationMetaData: keys,
+			},
+			config,
+		)
+		require.NoError(t, err)
+
+		reviews, err := plan.NewDataSourceConfiguration[Configuration](
+			"reviews",
+			factory,
+			&plan.DataSourceMetadata{
+				RootNodes: []plan.TypeField{
+					{TypeName: "User", FieldNames: []string{"id", "reviews"}},
+				},
+				ChildNodes: []plan.TypeField{
+					{TypeName: "Review", FieldNames: []string{"id", "body"}},
+				},
+				FederationMetaData: keys,
+			},
+			config,
+		)
+		require.NoError(t, err)
+
+		return []plan.DataSource{users, reviews}
+	}
+
+	t.Run("deterministic for equal seeds", func(t *testing.T) {
+		operation := `{ me { id name age score active role } }`
+		out := execute(t, dataSources(t, Configuration{Seed: 1}), operation)
+		assert.Equal(t, out, execute(t, dataSources(t, Configuration{Seed: 1}), operation))
+		assert.NotEqual(t, out, execute(t, dataSources(t, Configuration{Seed: 2}), operation))
+
+		me := parse(t, out).Get("data", "me")
+		assert.Equal(t, astjson.TypeString, me.Get("id").Type())
+		assert.Equal(t, astjson.TypeString, me.Get("name").Type())
+		assert.Equal(t, astjson.TypeNumber, me.Get("age").Type())
+		assert.Equal(t, astjson.TypeNumber, me.Get("score").Type())
+		assert.Contains(t, []astjson.Type{astjson.TypeTrue, astjson.TypeFalse}, me.Get("active").Type())
+		assert.Contains(t, []string{"ADMIN", "VIEWER"}, string(me.GetStringBytes("role")))
+	})
+
+	t.Run("lists", func(t *testing.T) {
+		out := parse(t, execute(t, dataSources(t, Configuration{ListLength: 3}), `{ users { friends { name } } }`))
+		users := out.GetArray("data", "users")
+		require.Len(t, users, 3)
+		for _, user := range users {
+			assert.Len(t, user.GetArray("friends"), 3)
+		}
+	})
+
+	t.Run("abstract types", func(t *testing.T) {
+		out := parse(t, execute(t, dataSources(t, Configuration{ListLength: 10}), `{ search { __typename ... on User { name } ... on Review { body } } node { __typename id } }`))
+		typeNames := map[string]bool{}
+		for _, result := range out.GetArray("data", "search") {
+			typeName := string(result.GetStringBytes("__typename"))
+			typeNames[typeName] = true
+			switch typeName {
+			case "User":
+				assert.True(t, result.Exists("name"))
+				assert.False(t, result.Exists("body"))
+			case "Review":
+				assert.True(t, result.Exists("body"))
+				assert.False(t, result.Exists("name"))
+			default:
+				t.Errorf("unexpected type %s", typeName)
+			}
+		}
+		assert.Equal(t, map[string]bool{"User": true, "Review": true}, typeNames)
+
+		node := out.Get("data", "node")
+		assert.Contains(t, []string{"User", "Review"}, string(node.GetStringBytes("__typename")))
+		assert.Equal(t, astjson.TypeString, node.Get("id").Type())
+	})
+
+	t.Run("nullability", func(t *testing.T) {
+		out := execute(t, dataSources(t, Configuration{NullProbability: 1}), `{ me { name age } users { id } }`)
+		assert.Regexp(t, `^\{"data":\{"me":\{"name":"name \d+","age":null\},"users":null\}\}$`, out)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		out := execute(t, dataSources(t, Configuration{
+			ListLength: 1,
+			Overrides: map[string]json.RawMessage{
+				"User.name":   json.RawMessage(`"Jens"`),
+				"Node.id":     json.RawMessage(`"1"`),
+				"Query.users": json.RawMessage(`[{"__typename":"User","name":"Stefan"}]`),
+			},
+		}), `{ me { name } users { name } node { id } }`)
+		assert.Regexp(t, `^\{"data":\{"me":\{"name":"Jens"\},"users":\[\{"name":"Stefan"\}\],"node":\{"id":"1"\}\}\}$`, out)
+	})
+
+	t.Run("entities", func(t *testing.T) {
+		out := parse(t, execute(t, dataSources(t, Configuration{ListLength: 2}), `{ users { id reviews { body } } }`))
+		users := out.GetArray("data", "users")
+		require.Len(t, users, 2)
+		for _, user := range users {
+			reviews := user.GetArray("reviews")
+			assert.Len(t, reviews, 2)
+			assert.Equal(t, astjson.TypeString, reviews[0].Get("body").Type())
+		}
+	})
+
+	t.Run("equal entities for equal keys", func(t *testing.T) {
+		out := parse(t, execute(t, dataSources(t, Configuration{
+			ListLength: 2,
+			Overrides: map[string]json.RawMessage{
+				"User.id": json.RawMessage(`"1"`),
+			},
+		}), `{ users { id reviews { body } } }`))
+		users := out.GetArray("data", "users")
+		require.Len(t, users, 2)
+		assert.Equal(t, string(users[0].MarshalTo(nil)), string(users[1].MarshalTo(nil)))
+	})
+}
+
+func TestSource_Load(t *testing.T) {
+	source := &Source{
+		config: Configuration{
+			Seed: 1,
+		},
+	}
+
+	load := func(t *testing.T, input string) (string, error) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(input), out)
+		return out.String(), err
+	}
+
+	t.Run("entity keys are returned from the representation", func(t *testing.T) {
+		out, err := load(t, `{"selections":[{"key":"id","field":"id","type":"User","on":["User"],"value":{"kind":"scalar","name":"ID","non_null":true}},{"key":"tag","field":"__typename","type":"User","on":["User"],"value":{"kind":"typename"}}],"representations":[{"__typename":"User","id":"1"},{"__typename":"User","id":"2"}]}`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"_entities":[{"__typename":"User","id":"1","tag":"User"},{"__typename":"User","id":"2","tag":"User"}]}}`, out)
+	})
+
+	t.Run("invalid representation", func(t *testing.T) {
+		_, err := load(t, `{"selections":[],"representations":[{"id":"1"}]}`)
+		assert.ErrorContains(t, err, "missing __typename")
+	})
+}
+
+func parse(t *testing.T, out string) *astjson.Value {
+	t.Helper()
+	value, err := astjson.Parse(out)
+	require.NoError(t, err)
+	return value
+}
+
+func execute(t *testing.T, dataSources []plan.DataSource, operation string) string {
+	t.Helper()
+
+	def := unsafeparser.ParseGraphqlDocumentString(definition)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+	op := unsafeparser.ParseGraphqlDocumentString(operation)
+
+	report := &operationreport.Report{}
+	normalizer := astnormalization.NewWithOpts(astnormalization.WithExtractVariables(), astnormalization.WithRemoveUnusedVariables())
+	normalizer.NormalizeOperation(&op, &def, report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	planner, err := plan.NewPlanner(plan.Configuration{
+		DataSources: dataSources,
+	})
+	require.NoError(t, err)
+	executionPlan := planner.Plan(&op, &def, "", report)
+	require.False(t, report.HasErrors(), report.Error())
+	postprocess.NewProcessor().Process(executionPlan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := resolve.New(ctx, resolve.ResolverOptions{
+		MaxConcurrency:          32,
+		PropagateSubgraphErrors: true,
+	})
+
+	out := &bytes.Buffer{}
+	_, err = resolver.ResolveGraphQLResponse(resolve.NewContext(ctx), executionPlan.(*plan.SynchronousResponsePlan).Response, nil, out)
+	require.NoError(t, err)
+	return out.String()
+}
@@ -0,0 +1,232 @@
+package mock_datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/literal"
+)
+
+const (
+	kindScalar   = "scalar"
+	kindEnum     = "enum"
+	kindObject   = "object"
+	kindAbstract = "abstract"
+	kindList     = "list"
+	kindTypeName = "typename"
+
+	typeNameField = "__typename"
+)
+
+type sourceInput struct {
+	Selections      []*selection      `json:"selections"`
+	Representations []json.RawMessage `json:"representations,omitempty"`
+}
+
+// selection is a field of the operation together with the schema information required to generate its value
+type selection struct {
+	ResponseKey string `json:"key"`
+	FieldName   string `json:"field"`
+	// TypeName is the enclosing type of the field, which might be an abstract type
+	TypeName string `json:"type"`
+	// OnTypes are the object types the field is selected on
+	OnTypes    []string     `json:"on"`
+	Type       *fieldType   `json:"value"`
+	Selections []*selection `json:"selections,omitempty"`
+}
+
+type fieldType struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name,omitempty"`
+	NonNull bool   `json:"non_null,omitempty"`
+	// Values are the enum values of enums or the possible object types of abstract types
+	Values []string   `json:"values,omitempty"`
+	OfType *fieldType `json:"of_type,omitempty"`
+}
+
+type Source struct {
+	config Configuration
+}
+
+// Load generates a response for the selections of the input.
+// Root fields are generated from the seed and the selections, entities from the seed and their representation,
+// so that the same entity is generated equally by each fetch.
+func (s *Source) Load(ctx context.Context, input []byte, out *bytes.Buffer) (err error) {
+	var in sourceInput
+	if err = json.Unmarshal(input, &in); err != nil {
+		return fmt.Errorf("invalid mock datasource input: %w", err)
+	}
+
+	if in.Representations != nil {
+		return s.loadEntities(&in, out)
+	}
+
+	g := s.generator(input)
+	_, _ = out.WriteString(`{"data":`)
+	g.writeSelections(in.Selections, out)
+	_, _ = out.WriteString(`}`)
+	return nil
+}
+
+func (s *Source) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) (err error) {
+	panic("not implemented")
+}
+
+func (s *Source) loadEntities(in *sourceInput, out *bytes.Buffer) error {
+	_, _ = out.WriteString(`{"data":{"_entities":[`)
+	for i, representation := range in.Representations {
+		if i != 0 {
+			_, _ = out.WriteString(`,`)
+		}
+
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(representation, &keys); err != nil {
+			return fmt.Errorf("invalid representation: %w", err)
+		}
+		var typeName string
+		if err := json.Unmarshal(keys[typeNameField], &typeName); err != nil {
+			return fmt.Errorf("invalid representation %s: missing %s", representation, typeNameField)
+		}
+
+		g := s.generator(representation)
+		g.keys = keys
+		g.writeObject(typeName, in.Selections, out)
+	}
+	_, _ = out.WriteString(`]}}`)
+	return nil
+}
+
+func (s *Source) generator(hashed []byte) *generator {
+	return &generator{
+		config: s.config,
+		rand:   rand.New(rand.NewSource(s.config.Seed ^ int64(xxhash.Sum64(hashed)))),
+	}
+}
+
+type generator struct {
+	config Configuration
+	rand   *rand.Rand
+	// keys are the key fields of the representation of an entity, they are returned as is
+	keys map[string]json.RawMessage
+}
+
+// writeObject renders the selections applying to the object type, the __typename is always rendered
+func (g *generator) writeObject(typeName string, selections []*selection, out *bytes.Buffer) {
+	// the keys belong to the entity itself, not to nested objects
+	keys := g.keys
+	g.keys = nil
+
+	encodedTypeName, _ := json.Marshal(typeName)
+	_, _ = out.WriteString(`{"` + typeNameField + `":`)
+	_, _ = out.Write(encodedTypeName)
+	for _, sel := range selections {
+		if !slices.Contains(sel.OnTypes, typeName) || sel.ResponseKey == typeNameField {
+			continue
+		}
+		_, _ = out.WriteString(`,`)
+		if value, ok := keys[sel.FieldName]; ok {
+			key, _ := json.Marshal(sel.ResponseKey)
+			_, _ = out.Write(key)
+			_, _ = out.WriteString(`:`)
+			_, _ = out.Write(value)
+			continue
+		}
+		g.writeField(typeName, sel, out)
+	}
+	_, _ = out.WriteString(`}`)
+}
+
+// writeSelections renders the root fields of an operation
+func (g *generator) writeSelections(selections []*selection, out *bytes.Buffer) {
+	_, _ = out.WriteString(`{`)
+	for i, sel := range selections {
+		if i != 0 {
+			_, _ = out.WriteString(`,`)
+		}
+		g.writeField(sel.TypeName, sel, out)
+	}
+	_, _ = out.WriteString(`}`)
+}
+
+func (g *generator) writeField(typeName string, sel *selection, out *bytes.Buffer) {
+	key, _ := json.Marshal(sel.ResponseKey)
+	_, _ = out.Write(key)
+	_, _ = out.WriteString(`:`)
+
+	if sel.Type.Kind == kindTypeName {
+		encodedTypeName, _ := json.Marshal(typeName)
+		_, _ = out.Write(encodedTypeName)
+		return
+	}
+	if value, ok := g.config.Overrides[typeName+"."+sel.FieldName]; ok {
+		_, _ = out.Write(value)
+		return
+	}
+	if value, ok := g.config.Overrides[sel.TypeName+"."+sel.FieldName]; ok {
+		_, _ = out.Write(value)
+		return
+	}
+
+	g.writeValue(sel, sel.Type, out)
+}
+
+func (g *generator) writeValue(sel *selection, t *fieldType, out *bytes.Buffer) {
+	if !t.NonNull && g.config.NullProbability > 0 && g.rand.Float64() < g.config.NullProbability {
+		_, _ = out.Write(literal.NULL)
+		return
+	}
+
+	switch t.Kind {
+	case kindList:
+		_, _ = out.WriteString(`[`)
+		for i := 0; i < g.config.listLength(); i++ {
+			if i != 0 {
+				_, _ = out.WriteString(`,`)
+			}
+			g.writeValue(sel, t.OfType, out)
+		}
+		_, _ = out.WriteString(`]`)
+	case kindObject:
+		g.writeObject(t.Name, sel.Selections, out)
+	case kindAbstract:
+		if len(t.Values) == 0 {
+			_, _ = out.Write(literal.NULL)
+			return
+		}
+		g.writeObject(t.Values[g.rand.Intn(len(t.Values))], sel.Selections, out)
+	case kindEnum:
+		if len(t.Values) == 0 {
+			_, _ = out.Write(literal.NULL)
+			return
+		}
+		value, _ := json.Marshal(t.Values[g.rand.Intn(len(t.Values))])
+		_, _ = out.Write(value)
+	default:
+		g.writeScalar(sel, t, out)
+	}
+}
+
+// writeScalar generates the built-in scalars, custom scalars are generated as strings
+func (g *generator) writeScalar(sel *selection, t *fieldType, out *bytes.Buffer) {
+	switch t.Name {
+	case "Int":
+		_, _ = out.WriteString(strconv.Itoa(g.rand.Intn(1000)))
+	case "Float":
+		_, _ = out.WriteString(strconv.FormatFloat(float64(g.rand.Intn(100000))/100, 'f', -1, 64))
+	case "Boolean":
+		_, _ = out.WriteString(strconv.FormatBool(g.rand.Intn(2) == 1))
+	case "ID":
+		_, _ = out.WriteString(strconv.Quote(strconv.Itoa(g.rand.Intn(100000))))
+	default:
+		value, _ := json.Marshal(fmt.Sprintf("%s %d", sel.FieldName, g.rand.Intn(1000)))
+		_, _ = out.Write(value)
+	}
+}
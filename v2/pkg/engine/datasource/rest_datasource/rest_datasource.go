@@ -0,0 +1,290 @@
+package rest_datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/literal"
+)
+
+type Configuration struct {
+	Fetch FetchConfiguration
+}
+
+type FetchConfiguration struct {
+	// URL of the upstream, supports templates for field arguments ({{ .arguments.id }}),
+	// fields of the parent object ({{ .object.id }}) and request headers ({{ .request.headers.Authorization }})
+	URL    string
+	Method string
+	Header http.Header
+	Query  []QueryConfiguration
+	Body   string
+	// StatusCodeErrors maps upstream status codes to the error returned for the field.
+	// Responses outside the 2xx range without a mapping are turned into a generic error.
+	StatusCodeErrors map[int]StatusCodeError
+}
+
+type QueryConfiguration struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type StatusCodeError struct {
+	Message string
+	// Code is added to the extensions of the error, it is omitted when empty
+	Code string
+}
+
+type Factory[T Configuration] struct {
+	executionContext context.Context
+	httpClient       *http.Client
+}
+
+// NewFactory creates a new factory for the REST datasource planner
+func NewFactory(executionContext context.Context, httpClient *http.Client) (*Factory[Configuration], error) {
+	if executionContext == nil {
+		return nil, fmt.Errorf("execution context is required")
+	}
+	if httpClient == nil {
+		return nil, fmt.Errorf("http client is required")
+	}
+
+	return &Factory[Configuration]{
+		executionContext: executionContext,
+		httpClient:       httpClient,
+	}, nil
+}
+
+func (f *Factory[T]) Planner(logger abstractlogger.Logger) plan.DataSourcePlanner[T] {
+	return &Planner[T]{
+		client: f.httpClient,
+	}
+}
+
+func (f *Factory[T]) Context() context.Context {
+	return f.executionContext
+}
+
+func (f *Factory[T]) UpstreamSchema(dataSourceConfig plan.DataSourceConfiguration[T]) (*ast.Document, bool) {
+	return nil, false
+}
+
+type Planner[T Configuration] struct {
+	id                  int
+	client              *http.Client
+	v                   *plan.Visitor
+	config              Configuration
+	rootField           int
+	rootFieldPath       string
+	parentPath          string
+	isArrayItem         bool
+	operationDefinition int
+}
+
+func (p *Planner[T]) SetID(id int) {
+	p.id = id
+}
+
+func (p *Planner[T]) ID() (id int) {
+	return p.id
+}
+
+func (p *Planner[T]) DownstreamResponseFieldAlias(_ int) (alias string, exists bool) {
+	// the REST DataSourcePlanner doesn't rewrite upstream fields: skip
+	return
+}
+
+func (p *Planner[T]) DataSourcePlanningBehavior() plan.DataSourcePlanningBehavior {
+	return plan.DataSourcePlanningBehavior{
+		MergeAliasedRootNodes:      false,
+		OverrideFieldPathFromAlias: true,
+	}
+}
+
+func (p *Planner[T]) Register(visitor *plan.Visitor, configuration plan.DataSourceConfiguration[T], dataSourcePlannerConfiguration plan.DataSourcePlannerConfiguration) error {
+	p.v = visitor
+	p.config = Configuration(configuration.CustomConfiguration())
+	p.rootField = ast.InvalidRef
+	p.parentPath = dataSourcePlannerConfiguration.ParentPath
+	p.isArrayItem = dataSourcePlannerConfiguration.PathType == plan.PlannerPathArrayItem
+	visitor.Walker.RegisterEnterFieldVisitor(p)
+	visitor.Walker.RegisterEnterOperationVisitor(p)
+	return nil
+}
+
+func (p *Planner[T]) EnterOperationDefinition(ref int) {
+	p.operationDefinition = ref
+}
+
+func (p *Planner[T]) EnterField(ref int) {
+	if p.rootField != ast.InvalidRef {
+		// nested fields are part of the upstream response
+		return
+	}
+
+	fieldAliasOrName := p.v.Operation.FieldAliasOrNameString(ref)
+	if plan.IsParentPathField(p.v, p.parentPath, ref) {
+		return
+	}
+
+	p.rootField = ref
+	p.rootFieldPath = fieldAliasOrName
+}
+
+func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
+	if p.rootField == ast.InvalidRef {
+		p.v.Walker.StopWithInternalErr(errors.New("rest root field is not set"))
+	}
+
+	return resolve.FetchConfiguration{
+		Input: string(p.configureInput()),
+		DataSource: &Source{
+			client:           p.client,
+			statusCodeErrors: p.config.Fetch.StatusCodeErrors,
+		},
+		RequiresParallelListItemFetch: p.isArrayItem,
+		PostProcessing: resolve.PostProcessingConfiguration{
+			SelectResponseDataPath:   []string{"data"},
+			SelectResponseErrorsPath: []string{"errors"},
+			MergePath:                []string{p.rootFieldPath},
+		},
+	}
+}
+
+func (p *Planner[T]) ConfigureSubscription() plan.SubscriptionConfiguration {
+	// the REST DataSourcePlanner doesn't have subscriptions
+	return plan.SubscriptionConfiguration{}
+}
+
+func (p *Planner[T]) configureInput() []byte {
+	input := httpclient.SetInputURL(nil, []byte(p.config.Fetch.URL))
+	input = httpclient.SetInputMethod(input, []byte(p.config.Fetch.Method))
+	input = httpclient.SetInputBody(input, []byte(p.config.Fetch.Body))
+
+	header, err := json.Marshal(p.config.Fetch.Header)
+	if err == nil && len(header) != 0 && !bytes.Equal(header, literal.NULL) {
+		input = httpclient.SetInputHeader(input, header)
+	}
+
+	preparedQuery := p.prepareQueryParams(p.rootField, p.config.Fetch.Query)
+	query, err := json.Marshal(preparedQuery)
+	if err == nil && len(preparedQuery) != 0 {
+		input = httpclient.SetInputQueryParams(input, query)
+	}
+	return input
+}
+
+var (
+	selectorRegex = regexp.MustCompile(`{{\s*(.*?)\s*}}`)
+)
+
+// prepareQueryParams omits query parameters which reference an optional argument that is not provided by the operation
+func (p *Planner[T]) prepareQueryParams(field int, query []QueryConfiguration) []QueryConfiguration {
+	out := make([]QueryConfiguration, 0, len(query))
+Next:
+	for i := range query {
+		matches := selectorRegex.FindAllStringSubmatch(query[i].Value, -1)
+		for j := range matches {
+			if len(matches[j]) != 2 {
+				continue
+			}
+			path := strings.TrimPrefix(matches[j][1], ".")
+			elements := strings.Split(path, ".")
+			if len(elements) < 2 || elements[0] != "arguments" {
+				continue
+			}
+			arg, ok := p.v.Operation.FieldArgument(field, []byte(elements[1]))
+			if !ok {
+				continue Next
+			}
+			value := p.v.Operation.ArgumentValue(arg)
+			if value.Kind != ast.ValueKindVariable {
+				continue
+			}
+			variableName := p.v.Operation.VariableValueNameString(value.Ref)
+			if !p.v.Operation.OperationDefinitionHasVariableDefinition(p.operationDefinition, variableName) {
+				continue Next
+			}
+		}
+		out = append(out, query[i])
+	}
+	return out
+}
+
+type Source struct {
+	client           *http.Client
+	statusCodeErrors map[int]StatusCodeError
+}
+
+// Load calls the upstream and renders its response as GraphQL response,
+// the body of a successful response becomes the data, other status codes are rendered as errors
+func (s *Source) Load(ctx context.Context, input []byte, out *bytes.Buffer) (err error) {
+	responseContext, ok := httpclient.ResponseContextFromContext(ctx)
+	if !ok {
+		ctx, responseContext = httpclient.InjectResponseContext(ctx)
+	}
+
+	body := &bytes.Buffer{}
+	if err = httpclient.Do(s.client, ctx, input, body); err != nil {
+		return err
+	}
+
+	return s.writeResponse(responseContext.StatusCode, bytes.TrimSpace(body.Bytes()), out)
+}
+
+func (s *Source) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) (err error) {
+	panic("not implemented")
+}
+
+func (s *Source) writeResponse(statusCode int, body []byte, out *bytes.Buffer) error {
+	if statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices {
+		if len(body) == 0 {
+			body = literal.NULL
+		}
+		_, _ = out.WriteString(`{"data":`)
+		_, _ = out.Write(body)
+		_, _ = out.WriteString(`}`)
+		return nil
+	}
+
+	statusCodeError, ok := s.statusCodeErrors[statusCode]
+	if !ok {
+		statusCodeError = StatusCodeError{
+			Message: fmt.Sprintf("upstream responded with status code %d", statusCode),
+		}
+	}
+
+	upstreamErr := upstreamError{
+		Message: statusCodeError.Message,
+	}
+	if statusCodeError.Code != "" {
+		upstreamErr.Extensions = map[string]string{
+			"code": statusCodeError.Code,
+		}
+	}
+
+	return json.NewEncoder(out).Encode(upstreamResponse{
+		Errors: []upstreamError{upstreamErr},
+	})
+}
+
+type upstreamResponse struct {
+	Errors []upstreamError `json:"errors"`
+}
+
+type upstreamError struct {
+	Message    string            `json:"message"`
+	Extensions map[string]string `json:"extensions,omitempty"`
+}
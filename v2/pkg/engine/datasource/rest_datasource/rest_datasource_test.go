@@ -0,0 +1,317 @@
+package rest_datasource
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasourcetesting"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+const (
+	definition = `
+		type Query {
+			user(id: ID!): User
+			users(limit: Int): [User!]!
+		}
+
+		type User {
+			id: ID!
+			name: String
+			friends: [User!]!
+		}
+	`
+
+	userOperation = `
+		query User($id: ID!) {
+			user(id: $id) {
+				name
+			}
+		}
+	`
+
+	usersOperation = `
+		query Users {
+			users {
+				id
+				friends {
+					name
+				}
+			}
+		}
+	`
+)
+
+func TestRestDataSourcePlanning(t *testing.T) {
+	factory, err := NewFactory(context.Background(), http.DefaultClient)
+	require.NoError(t, err)
+
+	dataSource := func(t *testing.T, id string, rootNodes []plan.TypeField, config Configuration) plan.DataSource {
+		t.Helper()
+		cfg, err := plan.NewDataSourceConfiguration[Configuration](
+			id,
+			factory,
+			&plan.DataSourceMetadata{
+				RootNodes: rootNodes,
+				ChildNodes: []plan.TypeField{
+					{TypeName: "User", FieldNames: []string{"id", "name"}},
+				},
+			},
+			config,
+		)
+		require.NoError(t, err)
+		return cfg
+	}
+
+	t.Run("get request with argument", datasourcetesting.RunTest(definition, userOperation, "User",
+		&plan.SynchronousResponsePlan{
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fetches: []resolve.Fetch{
+						&resolve.SingleFetch{
+							DataSourceIdentifier: []byte("rest_datasource.Source"),
+							FetchConfiguration: resolve.FetchConfiguration{
+								Input:      `{"method":"GET","url":"https://example.com/users/$$0$$"}`,
+								DataSource: &Source{},
+								Variables: resolve.NewVariables(
+									&resolve.ContextVariable{
+										Path:     []string{"id"},
+										Renderer: resolve.NewPlainVariableRenderer(),
+									},
+								),
+								PostProcessing: resolve.PostProcessingConfiguration{
+									SelectResponseDataPath:   []string{"data"},
+									SelectResponseErrorsPath: []string{"errors"},
+									MergePath:                []string{"user"},
+								},
+							},
+						},
+					},
+					Fields: []*resolve.Field{
+						{
+							Name: []byte("user"),
+							Value: &resolve.Object{
+								Path:     []string{"user"},
+								Nullable: true,
+								PossibleTypes: map[string]struct{}{
+									"User": {},
+								},
+								TypeName: "User",
+								Fields: []*resolve.Field{
+									{
+										Name: []byte("name"),
+										Value: &resolve.String{
+											Path:     []string{"name"},
+											Nullable: true,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		plan.Configuration{
+			DataSources: []plan.DataSource{
+				dataSource(t, "users", []plan.TypeField{{TypeName: "Query", FieldNames: []string{"user"}}}, Configuration{
+					Fetch: FetchConfiguration{
+						URL:    "https://example.com/users/{{ .arguments.id }}",
+						Method: "GET",
+					},
+				}),
+			},
+			DisableResolveFieldPositions: true,
+		},
+	))
+
+	t.Run("get request with query and nested fetch on parent object", datasourcetesting.RunTest(definition, usersOperation, "Users",
+		&plan.SynchronousResponsePlan{
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fetches: []resolve.Fetch{
+						&resolve.SingleFetch{
+							DataSourceIdentifier: []byte("rest_datasource.Source"),
+							FetchConfiguration: resolve.FetchConfiguration{
+								Input:      `{"method":"GET","url":"https://example.com/users"}`,
+								DataSource: &Source{},
+								PostProcessing: resolve.PostProcessingConfiguration{
+									SelectResponseDataPath:   []string{"data"},
+									SelectResponseErrorsPath: []string{"errors"},
+									MergePath:                []string{"users"},
+								},
+							},
+						},
+					},
+					Fields: []*resolve.Field{
+						{
+							Name: []byte("users"),
+							Value: &resolve.Array{
+								Path: []string{"users"},
+								Item: &resolve.Object{
+									PossibleTypes: map[string]struct{}{
+										"User": {},
+									},
+									TypeName: "User",
+									Fetches: []resolve.Fetch{
+										&resolve.SingleFetch{
+											FetchDependencies: resolve.FetchDependencies{
+												FetchID: 1,
+											},
+											DataSourceIdentifier: []byte("rest_datasource.Source"),
+											FetchConfiguration: resolve.FetchConfiguration{
+												Input:      `{"method":"GET","url":"https://example.com/users/$$0$$/friends"}`,
+												DataSource: &Source{},
+												Variables: resolve.NewVariables(
+													&resolve.ObjectVariable{
+														Path:     []string{"id"},
+														Renderer: resolve.NewPlainVariableRenderer(),
+													},
+												),
+												RequiresParallelListItemFetch: true,
+												PostProcessing: resolve.PostProcessingConfiguration{
+													SelectResponseDataPath:   []string{"data"},
+													SelectResponseErrorsPath: []string{"errors"},
+													MergePath:                []string{"friends"},
+												},
+											},
+										},
+									},
+									Fields: []*resolve.Field{
+										{
+											Name: []byte("id"),
+											Value: &resolve.Scalar{
+												Path: []string{"id"},
+											},
+										},
+										{
+											Name: []byte("friends"),
+											Value: &resolve.Array{
+												Path: []string{"friends"},
+												Item: &resolve.Object{
+													PossibleTypes: map[string]struct{}{
+														"User": {},
+													},
+													TypeName: "User",
+													Fields: []*resolve.Field{
+														{
+															Name: []byte("name"),
+															Value: &resolve.String{
+																Path:     []string{"name"},
+																Nullable: true,
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		plan.Configuration{
+			DataSources: []plan.DataSource{
+				dataSource(t, "users", []plan.TypeField{{TypeName: "Query", FieldNames: []string{"users"}}}, Configuration{
+					Fetch: FetchConfiguration{
+						URL:    "https://example.com/users",
+						Method: "GET",
+						Query: []QueryConfiguration{
+							{
+								Name:  "limit",
+								Value: "{{ .arguments.limit }}",
+							},
+						},
+					},
+				}),
+				dataSource(t, "friends", []plan.TypeField{{TypeName: "User", FieldNames: []string{"friends"}}}, Configuration{
+					Fetch: FetchConfiguration{
+						URL:    "https://example.com/users/{{ .object.id }}/friends",
+						Method: "GET",
+					},
+				}),
+			},
+			DisableResolveFieldPositions: true,
+		},
+	))
+}
+
+func TestSource_Load(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/1":
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"id":"1","name":"Jens"}`))
+		case "/users":
+			assert.Equal(t, "2", r.URL.Query().Get("limit"))
+			_, _ = w.Write([]byte(`[{"id":"1"},{"id":"2"}]`))
+		case "/users/2":
+			w.WriteHeader(http.StatusNoContent)
+		case "/users/3":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	source := &Source{
+		client: http.DefaultClient,
+		statusCodeErrors: map[int]StatusCodeError{
+			http.StatusNotFound: {
+				Message: "user not found",
+				Code:    "NOT_FOUND",
+			},
+		},
+	}
+
+	load := func(t *testing.T, input string) string {
+		t.Helper()
+		out := &bytes.Buffer{}
+		require.NoError(t, source.Load(context.Background(), []byte(input), out))
+		return out.String()
+	}
+
+	t.Run("object with header", func(t *testing.T) {
+		out := load(t, `{"method":"GET","url":"`+server.URL+`/users/1","header":{"Authorization":["Bearer token"]}}`)
+		assert.Equal(t, `{"data":{"id":"1","name":"Jens"}}`, out)
+	})
+
+	t.Run("list with query", func(t *testing.T) {
+		out := load(t, `{"method":"GET","url":"`+server.URL+`/users","query_params":[{"name":"limit","value":"2"}]}`)
+		assert.Equal(t, `{"data":[{"id":"1"},{"id":"2"}]}`, out)
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		out := load(t, `{"method":"GET","url":"`+server.URL+`/users/2"}`)
+		assert.Equal(t, `{"data":null}`, out)
+	})
+
+	t.Run("mapped status code", func(t *testing.T) {
+		out := load(t, `{"method":"GET","url":"`+server.URL+`/users/3"}`)
+		assert.JSONEq(t, `{"errors":[{"message":"user not found","extensions":{"code":"NOT_FOUND"}}]}`, out)
+	})
+
+	t.Run("unmapped status code", func(t *testing.T) {
+		out := load(t, `{"method":"GET","url":"`+server.URL+`/unknown"}`)
+		assert.JSONEq(t, `{"errors":[{"message":"upstream responded with status code 500"}]}`, out)
+	})
+
+	t.Run("status code is reported to the loader", func(t *testing.T) {
+		ctx, responseContext := httpclient.InjectResponseContext(context.Background())
+		out := &bytes.Buffer{}
+		require.NoError(t, source.Load(ctx, []byte(`{"method":"GET","url":"`+server.URL+`/users/3"}`), out))
+		assert.Equal(t, http.StatusNotFound, responseContext.StatusCode)
+	})
+}
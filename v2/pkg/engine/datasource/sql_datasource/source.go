@@ -0,0 +1,240 @@
+package sql_datasource
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/literal"
+)
+
+const (
+	resultList       = "list"
+	resultObject     = "object"
+	resultScalar     = "scalar"
+	resultScalarList = "scalar_list"
+
+	typeNameColumn = "__typename"
+)
+
+type sourceInput struct {
+	Statement string `json:"statement"`
+	// Field is the response key of the root field of the statement
+	Field string `json:"field,omitempty"`
+	// Result is the kind of the result of a root field statement
+	Result string `json:"result,omitempty"`
+	// TypeName is rendered as __typename of the rows
+	TypeName string `json:"type_name,omitempty"`
+	// Keys are the key fields of an entity statement
+	Keys []string `json:"keys,omitempty"`
+
+	Params          []json.RawMessage `json:"params,omitempty"`
+	Representations []json.RawMessage `json:"representations,omitempty"`
+}
+
+type Source struct {
+	db *sql.DB
+}
+
+// Load runs the statement of the input with the bound parameters and renders the rows as GraphQL response.
+// Entity statements run once per representation and render the entities in the order of the representations.
+func (s *Source) Load(ctx context.Context, input []byte, out *bytes.Buffer) (err error) {
+	var in sourceInput
+	if err = json.Unmarshal(input, &in); err != nil {
+		return fmt.Errorf("invalid sql datasource input: %w", err)
+	}
+
+	if in.Keys != nil {
+		return s.loadEntities(ctx, &in, out)
+	}
+
+	params, err := bindParams(in.Params)
+	if err != nil {
+		return err
+	}
+
+	data := &bytes.Buffer{}
+	if err = s.query(ctx, in.Statement, params, in.Result, in.TypeName, data); err != nil {
+		return err
+	}
+
+	field, _ := json.Marshal(in.Field)
+	_, _ = out.WriteString(`{"data":{`)
+	_, _ = out.Write(field)
+	_, _ = out.WriteString(`:`)
+	_, _ = out.Write(data.Bytes())
+	_, _ = out.WriteString(`}}`)
+	return nil
+}
+
+func (s *Source) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) (err error) {
+	panic("not implemented")
+}
+
+func (s *Source) loadEntities(ctx context.Context, in *sourceInput, out *bytes.Buffer) error {
+	data := &bytes.Buffer{}
+	_, _ = data.WriteString(`{"data":{"_entities":[`)
+	for i, representation := range in.Representations {
+		if i != 0 {
+			_, _ = data.WriteString(`,`)
+		}
+
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(representation, &values); err != nil {
+			return fmt.Errorf("invalid representation: %w", err)
+		}
+		keys := make([]json.RawMessage, 0, len(in.Keys))
+		for _, key := range in.Keys {
+			keys = append(keys, values[key])
+		}
+		params, err := bindParams(keys)
+		if err != nil {
+			return err
+		}
+
+		if err = s.query(ctx, in.Statement, params, resultObject, in.TypeName, data); err != nil {
+			return err
+		}
+	}
+	_, _ = data.WriteString(`]}}`)
+
+	_, _ = out.Write(data.Bytes())
+	return nil
+}
+
+// query runs the statement and renders the rows according to the result kind: all rows as list,
+// the first column of all rows as list of scalars, the first row as object or the first column of the first row as scalar
+func (s *Source) query(ctx context.Context, statement string, params []any, result, typeName string, out *bytes.Buffer) error {
+	rows, err := s.db.QueryContext(ctx, statement, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	var typeNameField string
+	if typeName != "" && !slices.Contains(columns, typeNameColumn) {
+		encoded, _ := json.Marshal(typeName)
+		typeNameField = `"` + typeNameColumn + `":` + string(encoded)
+	}
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	isList := result == resultList || result == resultScalarList
+	if isList {
+		_, _ = out.WriteString(`[`)
+	}
+	count := 0
+	for rows.Next() {
+		if !isList && count == 1 {
+			break
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if count != 0 {
+			_, _ = out.WriteString(`,`)
+		}
+		count++
+
+		if result == resultScalar || result == resultScalarList {
+			if err = writeValue(values[0], out); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = writeRow(typeNameField, columns, values, out); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	switch {
+	case isList:
+		_, _ = out.WriteString(`]`)
+	case count == 0:
+		_, _ = out.Write(literal.NULL)
+	}
+	return nil
+}
+
+func writeRow(typeNameField string, columns []string, values []any, out *bytes.Buffer) error {
+	_, _ = out.WriteString(`{`)
+	_, _ = out.WriteString(typeNameField)
+	for i := range columns {
+		if i != 0 || typeNameField != "" {
+			_, _ = out.WriteString(`,`)
+		}
+		name, _ := json.Marshal(columns[i])
+		_, _ = out.Write(name)
+		_, _ = out.WriteString(`:`)
+		if err := writeValue(values[i], out); err != nil {
+			return err
+		}
+	}
+	_, _ = out.WriteString(`}`)
+	return nil
+}
+
+// writeValue renders a column value as JSON, text columns are rendered as strings and timestamps in RFC 3339 format
+func writeValue(value any, out *bytes.Buffer) error {
+	switch v := value.(type) {
+	case nil:
+		_, _ = out.Write(literal.NULL)
+		return nil
+	case []byte:
+		value = string(v)
+	case time.Time:
+		value = v.Format(time.RFC3339Nano)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, _ = out.Write(encoded)
+	return nil
+}
+
+// bindParams converts the JSON parameters into values supported by database/sql,
+// lists and input objects are bound as JSON text
+func bindParams(params []json.RawMessage) ([]any, error) {
+	out := make([]any, 0, len(params))
+	for _, param := range params {
+		if len(param) == 0 {
+			out = append(out, nil)
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(param))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid sql parameter %s: %w", param, err)
+		}
+		switch v := value.(type) {
+		case json.Number:
+			if i, err := v.Int64(); err == nil && !strings.ContainsAny(v.String(), ".eE") {
+				value = i
+			} else {
+				value, _ = v.Float64()
+			}
+		case map[string]any, []any:
+			value = string(param)
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
@@ -0,0 +1,362 @@
+package sql_datasource
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/lexer/literal"
+)
+
+type Configuration struct {
+	// Statements maps the root fields of the datasource to SQL statements
+	Statements []StatementConfiguration
+	// Entities maps the entity types of the datasource to SQL statements which resolve an entity by its key
+	Entities []EntityConfiguration
+}
+
+type StatementConfiguration struct {
+	TypeName  string
+	FieldName string
+	// Statement is a parameterised SQL statement, e.g. SELECT id, name FROM users WHERE id = ?
+	// The placeholders depend on the database driver, e.g. ? for SQLite and $1 for Postgres.
+	// The columns of the result are mapped by name to the fields of the result type, use aliases to rename them
+	// and casts to match the GraphQL types, e.g. CAST(id AS TEXT) AS id for an integer column of an ID field.
+	Statement string
+	// Parameters are bound to the placeholders of the statement in order. They support templates for
+	// field arguments ({{ .arguments.id }}) and fields of the parent object ({{ .object.id }}).
+	// Parameters are always bound by the database driver and never interpolated into the statement,
+	// a missing argument is bound as NULL.
+	Parameters []string
+}
+
+type EntityConfiguration struct {
+	TypeName string
+	// Statement is a parameterised SQL statement which selects a single entity, the fields of the @key directive
+	// of the entity are bound to the placeholders in the order of the key, e.g. SELECT id, email FROM users WHERE id = ?
+	// Only keys without nested fields are supported.
+	Statement string
+}
+
+func (c *Configuration) statement(typeName, fieldName string) (StatementConfiguration, bool) {
+	for i := range c.Statements {
+		if c.Statements[i].TypeName == typeName && c.Statements[i].FieldName == fieldName {
+			return c.Statements[i], true
+		}
+	}
+	return StatementConfiguration{}, false
+}
+
+func (c *Configuration) entity(typeName string) (EntityConfiguration, bool) {
+	for i := range c.Entities {
+		if c.Entities[i].TypeName == typeName {
+			return c.Entities[i], true
+		}
+	}
+	return EntityConfiguration{}, false
+}
+
+type Factory[T Configuration] struct {
+	executionContext context.Context
+	db               *sql.DB
+}
+
+// NewFactory creates a new factory for the SQL datasource planner,
+// all datasources created by the factory run their statements against db
+func NewFactory(executionContext context.Context, db *sql.DB) (*Factory[Configuration], error) {
+	if executionContext == nil {
+		return nil, fmt.Errorf("execution context is required")
+	}
+	if db == nil {
+		return nil, fmt.Errorf("database is required")
+	}
+
+	return &Factory[Configuration]{
+		executionContext: executionContext,
+		db:               db,
+	}, nil
+}
+
+func (f *Factory[T]) Planner(logger abstractlogger.Logger) plan.DataSourcePlanner[T] {
+	return &Planner[T]{
+		db: f.db,
+	}
+}
+
+func (f *Factory[T]) Context() context.Context {
+	return f.executionContext
+}
+
+func (f *Factory[T]) UpstreamSchema(dataSourceConfig plan.DataSourceConfiguration[T]) (*ast.Document, bool) {
+	return nil, false
+}
+
+type Planner[T Configuration] struct {
+	id                      int
+	db                      *sql.DB
+	v                       *plan.Visitor
+	config                  Configuration
+	dataSourcePlannerConfig plan.DataSourcePlannerConfiguration
+	rootField               int
+	rootFieldPath           string
+	statement               StatementConfiguration
+	result                  string
+	typeName                string
+	// entity, key and keyFields are set when the planner resolves entities by their keys instead of a root field
+	entity    EntityConfiguration
+	key       plan.FederationFieldConfiguration
+	keyFields []string
+}
+
+func (p *Planner[T]) SetID(id int) {
+	p.id = id
+}
+
+func (p *Planner[T]) ID() (id int) {
+	return p.id
+}
+
+func (p *Planner[T]) DownstreamResponseFieldAlias(_ int) (alias string, exists bool) {
+	// the SQL DataSourcePlanner doesn't rewrite upstream fields: skip
+	return
+}
+
+func (p *Planner[T]) DataSourcePlanningBehavior() plan.DataSourcePlanningBehavior {
+	return plan.DataSourcePlanningBehavior{
+		MergeAliasedRootNodes:      false,
+		OverrideFieldPathFromAlias: true,
+		IncludeTypeNameFields:      true,
+	}
+}
+
+func (p *Planner[T]) Register(visitor *plan.Visitor, configuration plan.DataSourceConfiguration[T], dataSourcePlannerConfiguration plan.DataSourcePlannerConfiguration) error {
+	p.v = visitor
+	p.config = Configuration(configuration.CustomConfiguration())
+	p.dataSourcePlannerConfig = dataSourcePlannerConfiguration
+	p.rootField = ast.InvalidRef
+	if err := p.registerEntity(); err != nil {
+		return err
+	}
+	visitor.Walker.RegisterEnterFieldVisitor(p)
+	return nil
+}
+
+// registerEntity sets the entity and the key of entity fetches, keys with nested fields are rejected
+func (p *Planner[T]) registerEntity() error {
+	for _, cfg := range p.dataSourcePlannerConfig.RequiredFields {
+		if cfg.FieldName != "" {
+			continue
+		}
+		entity, ok := p.config.entity(cfg.TypeName)
+		if !ok {
+			continue
+		}
+		keyFields, err := plan.KeyFieldNames(cfg)
+		if err != nil {
+			return fmt.Errorf("sql %w", err)
+		}
+		p.entity, p.key, p.keyFields = entity, cfg, keyFields
+		return nil
+	}
+	return nil
+}
+
+func (p *Planner[T]) EnterField(ref int) {
+	if p.rootField != ast.InvalidRef || p.isEntityFetch() {
+		// nested fields are columns of the result
+		return
+	}
+
+	fieldAliasOrName := p.v.Operation.FieldAliasOrNameString(ref)
+	if plan.IsParentPathField(p.v, p.dataSourcePlannerConfig.ParentPath, ref) {
+		return
+	}
+
+	typeName := p.v.Walker.EnclosingTypeDefinition.NameString(p.v.Definition)
+	fieldName := p.v.Operation.FieldNameString(ref)
+	statement, ok := p.config.statement(typeName, fieldName)
+	if !ok {
+		p.v.Walker.StopWithInternalErr(fmt.Errorf("no sql statement configured for field %s.%s", typeName, fieldName))
+		return
+	}
+
+	fieldDefinition, ok := p.v.Walker.FieldDefinition(ref)
+	if !ok {
+		p.v.Walker.StopWithInternalErr(fmt.Errorf("field definition of %s.%s not found", typeName, fieldName))
+		return
+	}
+
+	p.rootField = ref
+	p.rootFieldPath = fieldAliasOrName
+	p.statement = statement
+	p.configureResult(p.v.Definition.FieldDefinitionType(fieldDefinition))
+}
+
+// configureResult sets how the rows of a statement are rendered for a field of the given type.
+// Scalars and lists of scalars are rendered from the first column of the rows.
+// Rows of object types get the name of the type as __typename, rows of abstract types must select a __typename column.
+func (p *Planner[T]) configureResult(typeRef int) {
+	node, ok := p.v.Definition.Index.FirstNodeByNameBytes(p.v.Definition.ResolveTypeNameBytes(typeRef))
+	if ok && node.Kind == ast.NodeKindObjectTypeDefinition {
+		p.typeName = node.NameString(p.v.Definition)
+	}
+
+	isScalar := ok && (node.Kind == ast.NodeKindScalarTypeDefinition || node.Kind == ast.NodeKindEnumTypeDefinition)
+	switch {
+	case p.v.Definition.TypeIsList(typeRef) && isScalar:
+		p.result = resultScalarList
+	case p.v.Definition.TypeIsList(typeRef):
+		p.result = resultList
+	case isScalar:
+		p.result = resultScalar
+	default:
+		p.result = resultObject
+	}
+}
+
+// isEntityFetch reports whether the planner resolves entities by their keys instead of a root field
+func (p *Planner[T]) isEntityFetch() bool {
+	return p.entity.TypeName != ""
+}
+
+func (p *Planner[T]) ConfigureFetch() resolve.FetchConfiguration {
+	if p.isEntityFetch() {
+		return p.configureEntityFetch()
+	}
+
+	if p.rootField == ast.InvalidRef {
+		p.v.Walker.StopWithInternalErr(errors.New("sql root field is not set"))
+		return resolve.FetchConfiguration{}
+	}
+
+	variables := resolve.Variables{}
+	params := make([]string, 0, len(p.statement.Parameters))
+	for _, parameter := range p.statement.Parameters {
+		param, err := p.configureParameter(parameter, &variables)
+		if err != nil {
+			p.v.Walker.StopWithInternalErr(err)
+			return resolve.FetchConfiguration{}
+		}
+		params = append(params, param)
+	}
+
+	return resolve.FetchConfiguration{
+		Input: renderInput(sourceInput{
+			Statement: p.statement.Statement,
+			Field:     p.rootFieldPath,
+			Result:    p.result,
+			TypeName:  p.typeName,
+		}, "params", params),
+		DataSource: &Source{
+			db: p.db,
+		},
+		Variables:                     variables,
+		RequiresParallelListItemFetch: p.dataSourcePlannerConfig.PathType == plan.PlannerPathArrayItem,
+		PostProcessing: resolve.PostProcessingConfiguration{
+			SelectResponseDataPath:   []string{"data"},
+			SelectResponseErrorsPath: []string{"errors"},
+		},
+	}
+}
+
+func (p *Planner[T]) configureEntityFetch() resolve.FetchConfiguration {
+	representationObject, err := plan.RepresentationVariable(p.key)
+	if err != nil {
+		p.v.Walker.StopWithInternalErr(fmt.Errorf("sql %w", err))
+		return resolve.FetchConfiguration{}
+	}
+	variables := resolve.Variables{}
+	representation, _ := variables.AddVariable(resolve.NewResolvableObjectVariable(representationObject))
+
+	isBatch := p.dataSourcePlannerConfig.PathType != plan.PlannerPathObject
+	dataPath := []string{"data", "_entities", "0"}
+	if isBatch {
+		dataPath = []string{"data", "_entities"}
+	}
+
+	return resolve.FetchConfiguration{
+		Input: renderInput(sourceInput{
+			Statement: p.entity.Statement,
+			TypeName:  p.entity.TypeName,
+			Keys:      p.keyFields,
+		}, "representations", []string{representation}),
+		DataSource: &Source{
+			db: p.db,
+		},
+		Variables:                             variables,
+		RequiresEntityFetch:                   !isBatch,
+		RequiresEntityBatchFetch:              isBatch,
+		SetTemplateOutputToNullOnVariableNull: true,
+		PostProcessing: resolve.PostProcessingConfiguration{
+			SelectResponseDataPath:   dataPath,
+			SelectResponseErrorsPath: []string{"errors"},
+		},
+	}
+}
+
+func (p *Planner[T]) ConfigureSubscription() plan.SubscriptionConfiguration {
+	// the SQL DataSourcePlanner doesn't have subscriptions
+	return plan.SubscriptionConfiguration{}
+}
+
+var (
+	parameterRegex = regexp.MustCompile(`^{{\s*\.(.*?)\s*}}$`)
+)
+
+// configureParameter returns the JSON template of a parameter,
+// arguments and fields of the parent object are rendered as JSON values to keep their types
+func (p *Planner[T]) configureParameter(parameter string, variables *resolve.Variables) (string, error) {
+	matches := parameterRegex.FindStringSubmatch(strings.TrimSpace(parameter))
+	if len(matches) != 2 {
+		// static parameter
+		value, err := json.Marshal(parameter)
+		return string(value), err
+	}
+
+	path := strings.Split(matches[1], ".")
+	if len(path) < 2 {
+		return "", fmt.Errorf("invalid sql parameter: %s", parameter)
+	}
+
+	switch path[0] {
+	case "object":
+		name, _ := variables.AddVariable(&resolve.ObjectVariable{
+			Path:     path[1:],
+			Renderer: resolve.NewJSONVariableRenderer(),
+		})
+		return name, nil
+	case "arguments":
+		arg, ok := p.v.Operation.FieldArgument(p.rootField, []byte(path[1]))
+		if !ok {
+			return string(literal.NULL), nil
+		}
+		value := p.v.Operation.ArgumentValue(arg)
+		if value.Kind != ast.ValueKindVariable {
+			out, err := p.v.Operation.ValueToJSON(value)
+			return string(out), err
+		}
+		variableName := p.v.Operation.VariableValueNameString(value.Ref)
+		name, _ := variables.AddVariable(&resolve.ContextVariable{
+			Path:     append([]string{variableName}, path[2:]...),
+			Renderer: resolve.NewJSONVariableRenderer(),
+		})
+		return name, nil
+	default:
+		return "", fmt.Errorf("unsupported sql parameter: %s", parameter)
+	}
+}
+
+// renderInput renders the input of the Source, the values are variable templates and therefore appended as raw JSON
+func renderInput(input sourceInput, valuesKey string, values []string) string {
+	encoded, _ := json.Marshal(input)
+	return fmt.Sprintf(`%s,"%s":[%s]}`, encoded[:len(encoded)-1], valuesKey, strings.Join(values, ","))
+}
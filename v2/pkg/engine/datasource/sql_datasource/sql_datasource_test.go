@@ -0,0 +1,474 @@
+package sql_datasource
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wundergraph/astjson"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasourcetesting"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/postprocess"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+const (
+	definition = `
+		type Query {
+			user(id: ID!): User
+			users(limit: Int): [User!]!
+			userCount: Int!
+			userNames: [String!]!
+			roles: [Role]
+		}
+
+		enum Role {
+			ADMIN
+			USER
+		}
+
+		type Mutation {
+			createUser(name: String!): User
+		}
+
+		type User {
+			id: ID!
+			name: String!
+			email: String
+			posts: [Post!]!
+		}
+
+		type Post {
+			id: ID!
+			title: String!
+		}
+	`
+
+	fixtures = `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL, title TEXT NOT NULL);
+		INSERT INTO users (id, name, email) VALUES (1, 'Jens', 'jens@example.com'), (2, 'Stefan', NULL);
+		INSERT INTO posts (id, user_id, title) VALUES (1, 1, 'Federation'), (2, 1, 'Subscriptions');
+	`
+)
+
+func TestSQLDataSourcePlanning(t *testing.T) {
+	factory, err := NewFactory(context.Background(), &sql.DB{})
+	require.NoError(t, err)
+
+	users, err := plan.NewDataSourceConfiguration[Configuration](
+		"users",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"user"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"id", "name"}},
+			},
+		},
+		Configuration{
+			Statements: []StatementConfiguration{
+				{
+					TypeName:   "Query",
+					FieldName:  "user",
+					Statement:  "SELECT id, name FROM users WHERE id = ?",
+					Parameters: []string{"{{ .arguments.id }}"},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	t.Run("root field with argument", datasourcetesting.RunTest(definition, `query User($id: ID!) { me: user(id: $id) { name } }`, "User",
+		&plan.SynchronousResponsePlan{
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fetches: []resolve.Fetch{
+						&resolve.SingleFetch{
+							DataSourceIdentifier: []byte("sql_datasource.Source"),
+							FetchConfiguration: resolve.FetchConfiguration{
+								Input:      `{"statement":"SELECT id, name FROM users WHERE id = ?","field":"me","result":"object","type_name":"User","params":[$$0$$]}`,
+								DataSource: &Source{},
+								Variables: resolve.NewVariables(
+									&resolve.ContextVariable{
+										Path:     []string{"id"},
+										Renderer: resolve.NewJSONVariableRenderer(),
+									},
+								),
+								PostProcessing: resolve.PostProcessingConfiguration{
+									SelectResponseDataPath:   []string{"data"},
+									SelectResponseErrorsPath: []string{"errors"},
+								},
+							},
+						},
+					},
+					Fields: []*resolve.Field{
+						{
+							Name: []byte("me"),
+							Value: &resolve.Object{
+								Path:     []string{"me"},
+								Nullable: true,
+								PossibleTypes: map[string]struct{}{
+									"User": {},
+								},
+								TypeName: "User",
+								Fields: []*resolve.Field{
+									{
+										Name: []byte("name"),
+										Value: &resolve.String{
+											Path: []string{"name"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		plan.Configuration{
+			DataSources:                  []plan.DataSource{users},
+			DisableResolveFieldPositions: true,
+		},
+	))
+}
+
+func TestSQLDataSource(t *testing.T) {
+	db := openDatabase(t)
+
+	factory, err := NewFactory(context.Background(), db)
+	require.NoError(t, err)
+
+	keys := plan.FederationMetaData{
+		Keys: plan.FederationFieldConfigurations{
+			{TypeName: "User", SelectionSet: "id"},
+		},
+	}
+
+	users, err := plan.NewDataSourceConfiguration[Configuration](
+		"users",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"user", "users", "userCount", "userNames", "roles"}},
+				{TypeName: "Mutation", FieldNames: []string{"createUser"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"id", "name"}},
+			},
+			FederationMetaData: keys,
+		},
+		Configuration{
+			Statements: []StatementConfiguration{
+				{
+					TypeName:   "Query",
+					FieldName:  "user",
+					Statement:  "SELECT CAST(id AS TEXT) AS id, name FROM users WHERE id = ?",
+					Parameters: []string{"{{ .arguments.id }}"},
+				},
+				{
+					TypeName:   "Query",
+					FieldName:  "users",
+					Statement:  "SELECT CAST(id AS TEXT) AS id, name FROM users ORDER BY id LIMIT coalesce(?, -1)",
+					Parameters: []string{"{{ .arguments.limit }}"},
+				},
+				{
+					TypeName:  "Query",
+					FieldName: "userCount",
+					Statement: "SELECT count(*) FROM users",
+				},
+				{
+					TypeName:  "Query",
+					FieldName: "userNames",
+					Statement: "SELECT name FROM users ORDER BY id",
+				},
+				{
+					TypeName:  "Query",
+					FieldName: "roles",
+					Statement: "SELECT 'ADMIN' UNION ALL SELECT 'USER'",
+				},
+				{
+					TypeName:   "Mutation",
+					FieldName:  "createUser",
+					Statement:  "INSERT INTO users (name) VALUES (?) RETURNING CAST(id AS TEXT) AS id, name",
+					Parameters: []string{"{{ .arguments.name }}"},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	emails, err := plan.NewDataSourceConfiguration[Configuration](
+		"emails",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"id", "email"}},
+			},
+			FederationMetaData: keys,
+		},
+		Configuration{
+			Entities: []EntityConfiguration{
+				{
+					TypeName:  "User",
+					Statement: "SELECT email FROM users WHERE id = ?",
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	posts, err := plan.NewDataSourceConfiguration[Configuration](
+		"posts",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"posts"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "Post", FieldNames: []string{"id", "title"}},
+			},
+		},
+		Configuration{
+			Statements: []StatementConfiguration{
+				{
+					TypeName:   "User",
+					FieldName:  "posts",
+					Statement:  "SELECT id, title FROM posts WHERE user_id = ? ORDER BY id",
+					Parameters: []string{"{{ .object.id }}"},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	dataSources := []plan.DataSource{users, emails, posts}
+
+	t.Run("object", func(t *testing.T) {
+		out := execute(t, dataSources, `{ user(id: "1") { id name } }`)
+		assert.Equal(t, `{"data":{"user":{"id":"1","name":"Jens"}}}`, out)
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		out := execute(t, dataSources, `{ user(id: "3") { id name } }`)
+		assert.Equal(t, `{"data":{"user":null}}`, out)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		out := execute(t, dataSources, `{ users(limit: 1) { name } }`)
+		assert.Equal(t, `{"data":{"users":[{"name":"Jens"}]}}`, out)
+	})
+
+	t.Run("missing argument is bound as null", func(t *testing.T) {
+		out := execute(t, dataSources, `{ users { name } }`)
+		assert.Equal(t, `{"data":{"users":[{"name":"Jens"},{"name":"Stefan"}]}}`, out)
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		out := execute(t, dataSources, `{ userCount }`)
+		assert.Equal(t, `{"data":{"userCount":2}}`, out)
+	})
+
+	t.Run("list of scalars", func(t *testing.T) {
+		out := execute(t, dataSources, `{ userNames roles }`)
+		assert.Equal(t, `{"data":{"userNames":["Jens","Stefan"],"roles":["ADMIN","USER"]}}`, out)
+	})
+
+	t.Run("arguments are bound as parameters", func(t *testing.T) {
+		out := execute(t, dataSources, `{ user(id: "1 OR 1=1") { name } }`)
+		assert.Equal(t, `{"data":{"user":null}}`, out)
+	})
+
+	t.Run("nested statement with parent object parameter", func(t *testing.T) {
+		out := execute(t, dataSources, `{ users { id posts { title } } }`)
+		assert.Equal(t, `{"data":{"users":[{"id":"1","posts":[{"title":"Federation"},{"title":"Subscriptions"}]},{"id":"2","posts":[]}]}}`, out)
+	})
+
+	t.Run("entity", func(t *testing.T) {
+		out := execute(t, dataSources, `{ user(id: "1") { name email } }`)
+		assert.Equal(t, `{"data":{"user":{"name":"Jens","email":"jens@example.com"}}}`, out)
+	})
+
+	t.Run("entity batch", func(t *testing.T) {
+		out := execute(t, dataSources, `{ users { name email } }`)
+		assert.Equal(t, `{"data":{"users":[{"name":"Jens","email":"jens@example.com"},{"name":"Stefan","email":null}]}}`, out)
+	})
+
+	t.Run("mutation", func(t *testing.T) {
+		out := execute(t, dataSources, `mutation { createUser(name: "Nithin") { id name } }`)
+		assert.Equal(t, `{"data":{"createUser":{"id":"3","name":"Nithin"}}}`, out)
+
+		_, err := db.Exec("DELETE FROM users WHERE id = 3")
+		require.NoError(t, err)
+	})
+}
+
+func TestSQLDataSourceEntityKeys(t *testing.T) {
+	db := openDatabase(t)
+
+	factory, err := NewFactory(context.Background(), db)
+	require.NoError(t, err)
+
+	dataSources := func(t *testing.T, key string, statement string) []plan.DataSource {
+		t.Helper()
+		keys := plan.FederationMetaData{
+			Keys: plan.FederationFieldConfigurations{
+				{TypeName: "User", SelectionSet: key},
+			},
+		}
+		users, err := plan.NewDataSourceConfiguration[Configuration](
+			"users",
+			factory,
+			&plan.DataSourceMetadata{
+				RootNodes: []plan.TypeField{
+					{TypeName: "Query", FieldNames: []string{"users"}},
+				},
+				ChildNodes: []plan.TypeField{
+					{TypeName: "User", FieldNames: []string{"id", "name", "posts"}},
+					{TypeName: "Post", FieldNames: []string{"id"}},
+				},
+				FederationMetaData: keys,
+			},
+			Configuration{
+				Statements: []StatementConfiguration{
+					{
+						TypeName:  "Query",
+						FieldName: "users",
+						Statement: "SELECT CAST(id AS TEXT) AS id, name FROM users ORDER BY id",
+					},
+				},
+			},
+		)
+		require.NoError(t, err)
+		emails, err := plan.NewDataSourceConfiguration[Configuration](
+			"emails",
+			factory,
+			&plan.DataSourceMetadata{
+				RootNodes: []plan.TypeField{
+					{TypeName: "User", FieldNames: []string{"id", "name", "email"}},
+				},
+				FederationMetaData: keys,
+			},
+			Configuration{
+				Entities: []EntityConfiguration{
+					{TypeName: "User", Statement: statement},
+				},
+			},
+		)
+		require.NoError(t, err)
+		return []plan.DataSource{users, emails}
+	}
+
+	t.Run("composite key", func(t *testing.T) {
+		out := execute(t, dataSources(t, "id name", "SELECT email FROM users WHERE id = ? AND name = ?"), `{ users { email } }`)
+		assert.Equal(t, `{"data":{"users":[{"email":"jens@example.com"},{"email":null}]}}`, out)
+	})
+
+	t.Run("nested key fields are rejected", func(t *testing.T) {
+		def := unsafeparser.ParseGraphqlDocumentString(definition)
+		require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+		op := unsafeparser.ParseGraphqlDocumentString(`{ users { email } }`)
+		report := &operationreport.Report{}
+		astnormalization.NormalizeOperation(&op, &def, report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		planner, err := plan.NewPlanner(plan.Configuration{
+			DataSources: dataSources(t, "id posts { id }", "SELECT email FROM users WHERE id = ?"),
+		})
+		require.NoError(t, err)
+		planner.Plan(&op, &def, "", report)
+		require.True(t, report.HasErrors())
+		assert.ErrorContains(t, report, "sql entity User: nested key fields are not supported: id posts { id }")
+	})
+}
+
+func TestSource_Load(t *testing.T) {
+	source := &Source{
+		db: openDatabase(t),
+	}
+
+	load := func(t *testing.T, input string) (string, error) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(input), out)
+		return out.String(), err
+	}
+
+	t.Run("column types", func(t *testing.T) {
+		out, err := load(t, `{"statement":"SELECT 1 AS i, 1.5 AS f, 'a' AS s, NULL AS n, CAST('b' AS BLOB) AS b, ? AS p","field":"row","result":"object","params":[{"a":1}]}`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"row":{"i":1,"f":1.5,"s":"a","n":null,"b":"b","p":"{\"a\":1}"}}}`, out)
+	})
+
+	t.Run("entities in order of representations", func(t *testing.T) {
+		out, err := load(t, `{"statement":"SELECT name FROM users WHERE id = ?","keys":["id"],"representations":[{"__typename":"User","id":"2"},{"__typename":"User","id":"3"},{"__typename":"User","id":"1"}]}`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"_entities":[{"name":"Stefan"},null,{"name":"Jens"}]}}`, out)
+	})
+
+	t.Run("invalid statement", func(t *testing.T) {
+		_, err := load(t, `{"statement":"SELECT * FROM unknown","result":"list"}`)
+		assert.ErrorContains(t, err, "no such table: unknown")
+	})
+}
+
+func openDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	_, err = db.Exec(fixtures)
+	require.NoError(t, err)
+	return db
+}
+
+func execute(t *testing.T, dataSources []plan.DataSource, operation string) string {
+	t.Helper()
+
+	def := unsafeparser.ParseGraphqlDocumentString(definition)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+	op := unsafeparser.ParseGraphqlDocumentString(operation)
+
+	report := &operationreport.Report{}
+	normalizer := astnormalization.NewWithOpts(astnormalization.WithExtractVariables(), astnormalization.WithRemoveUnusedVariables())
+	normalizer.NormalizeOperation(&op, &def, report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	planner, err := plan.NewPlanner(plan.Configuration{
+		DataSources: dataSources,
+	})
+	require.NoError(t, err)
+	executionPlan := planner.Plan(&op, &def, "", report)
+	require.False(t, report.HasErrors(), report.Error())
+	postprocess.NewProcessor().Process(executionPlan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := resolve.New(ctx, resolve.ResolverOptions{
+		MaxConcurrency:          32,
+		PropagateSubgraphErrors: true,
+	})
+
+	resolveCtx := resolve.NewContext(ctx)
+	if len(op.Input.Variables) > 0 {
+		resolveCtx.Variables = astjson.MustParseBytes(op.Input.Variables)
+	}
+
+	out := &bytes.Buffer{}
+	_, err = resolver.ResolveGraphQLResponse(resolveCtx, executionPlan.(*plan.SynchronousResponsePlan).Response, nil, out)
+	require.NoError(t, err)
+	return out.String()
+}
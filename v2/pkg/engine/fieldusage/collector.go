@@ -0,0 +1,107 @@
+package fieldusage
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+)
+
+// Exporter exports the aggregated usage reports, e.g. to a schema registry
+type Exporter interface {
+	Export(ctx context.Context, report *Report) error
+}
+
+// ExporterFunc adapts a function to an Exporter
+type ExporterFunc func(ctx context.Context, report *Report) error
+
+func (f ExporterFunc) Export(ctx context.Context, report *Report) error {
+	return f(ctx, report)
+}
+
+type Options struct {
+	// Exporter receives the aggregated reports on Flush
+	Exporter Exporter
+	// FlushInterval flushes the report periodically until the context of the collector is done
+	// If 0, the report is only flushed by calling Flush
+	FlushInterval time.Duration
+	// OnExportError is called when the periodic flush fails
+	OnExportError func(err error)
+	// ClientNameHeader is the header of the client name, defaults to DefaultClientNameHeader
+	ClientNameHeader string
+	// ClientVersionHeader is the header of the client version, defaults to DefaultClientVersionHeader
+	ClientVersionHeader string
+}
+
+// Collector aggregates the usage records of the requests in memory until they're flushed to the Exporter
+// It's safe for concurrent use
+type Collector struct {
+	options Options
+
+	mu     sync.Mutex
+	report *Report
+}
+
+// NewCollector creates a Collector, if a FlushInterval is configured, the report is flushed periodically until ctx is done
+func NewCollector(ctx context.Context, options Options) *Collector {
+	c := &Collector{
+		options: options,
+		report:  newReport(),
+	}
+	if options.FlushInterval > 0 {
+		go c.flushPeriodically(ctx)
+	}
+	return c
+}
+
+// CollectRequest records the usage of the normalized operation and its plan, the client is taken from the request headers
+func (c *Collector) CollectRequest(operation, definition *ast.Document, operationName string, p plan.Plan, header http.Header) *Usage {
+	usage := Collect(operation, definition, operationName, p, ClientInfoFromHeader(header, c.options.ClientNameHeader, c.options.ClientVersionHeader))
+	c.Record(usage)
+	return usage
+}
+
+// Record aggregates the usage record into the current report
+func (c *Collector) Record(usage *Usage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.report.add(usage)
+}
+
+// Flush hands the current report to the Exporter and starts a new one
+// Empty reports aren't exported
+func (c *Collector) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	report := c.report
+	c.report = newReport()
+	c.mu.Unlock()
+
+	if c.options.Exporter == nil || len(report.TracesPerQuery) == 0 {
+		return nil
+	}
+	return c.options.Exporter.Export(ctx, report)
+}
+
+func (c *Collector) flushPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(c.options.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// export the remaining usage, the context of the collector is already done
+			c.handleExportError(c.Flush(context.Background()))
+			return
+		case <-ticker.C:
+			c.handleExportError(c.Flush(ctx))
+		}
+	}
+}
+
+func (c *Collector) handleExportError(err error) {
+	if err != nil && c.options.OnExportError != nil {
+		c.options.OnExportError(err)
+	}
+}
@@ -0,0 +1,218 @@
+package fieldusage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/mock_datasource"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/postprocess"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+const definition = `
+	type Query {
+		products(filter: ProductFilter, first: Int, order: Order = ASC): [Product!]!
+	}
+
+	input ProductFilter {
+		name: String
+		category: Category
+	}
+
+	enum Category {
+		HATS
+		SHOES
+	}
+
+	enum Order {
+		ASC
+		DESC
+	}
+
+	type Product {
+		id: ID!
+		name: String!
+		stock: Int!
+	}
+`
+
+type planned struct {
+	operation, definition *ast.Document
+	plan                  plan.Plan
+}
+
+func planOperation(t *testing.T, operation, variables string) planned {
+	t.Helper()
+
+	keys := plan.FederationMetaData{
+		Keys: plan.FederationFieldConfigurations{
+			{TypeName: "Product", SelectionSet: "id"},
+		},
+	}
+	factory, err := mock_datasource.NewFactory(context.Background())
+	require.NoError(t, err)
+	products, err := plan.NewDataSourceConfiguration[mock_datasource.Configuration](
+		"products",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"products"}},
+				{TypeName: "Product", FieldNames: []string{"id", "name"}},
+			},
+			FederationMetaData: keys,
+		},
+		mock_datasource.Configuration{},
+	)
+	require.NoError(t, err)
+	inventory, err := plan.NewDataSourceConfiguration[mock_datasource.Configuration](
+		"inventory",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Product", FieldNames: []string{"id", "stock"}},
+			},
+			FederationMetaData: keys,
+		},
+		mock_datasource.Configuration{},
+	)
+	require.NoError(t, err)
+
+	def := unsafeparser.ParseGraphqlDocumentString(definition)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+	op := unsafeparser.ParseGraphqlDocumentString(operation)
+	op.Input.Variables = []byte(variables)
+
+	report := &operationreport.Report{}
+	normalizer := astnormalization.NewWithOpts(astnormalization.WithExtractVariables(), astnormalization.WithRemoveUnusedVariables())
+	normalizer.NormalizeOperation(&op, &def, report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	planner, err := plan.NewPlanner(plan.Configuration{
+		DataSources: []plan.DataSource{products, inventory},
+	})
+	require.NoError(t, err)
+	executionPlan := planner.Plan(&op, &def, "", report)
+	require.False(t, report.HasErrors(), report.Error())
+	postprocess.NewProcessor().Process(executionPlan)
+
+	return planned{operation: &op, definition: &def, plan: executionPlan}
+}
+
+func TestCollect(t *testing.T) {
+	p := planOperation(t,
+		`query Products($filter: ProductFilter) { products(filter: $filter, first: 1) { name stock __typename } }`,
+		`{"filter":{"category":"HATS","name":null}}`)
+
+	usage := Collect(p.operation, p.definition, "Products", p.plan, ClientInfo{Name: "web", Version: "1.0.0"})
+	assert.Equal(t, "Products", usage.OperationName)
+	assert.Equal(t, ClientInfo{Name: "web", Version: "1.0.0"}, usage.Client)
+	assert.Equal(t, []FieldUsage{
+		{TypeName: "Query", FieldName: "products", ReturnType: "[Product!]!", Subgraphs: []string{"products"}},
+		{TypeName: "Product", FieldName: "name", ReturnType: "String!", Subgraphs: []string{"products"}},
+		{TypeName: "Product", FieldName: "stock", ReturnType: "Int!", Subgraphs: []string{"inventory"}},
+	}, usage.Fields)
+	assert.ElementsMatch(t, []ArgumentUsage{
+		{TypeName: "Query", FieldName: "products", ArgumentName: "filter"},
+		{TypeName: "Query", FieldName: "products", ArgumentName: "first"},
+	}, usage.Arguments)
+	assert.ElementsMatch(t, []InputFieldUsage{
+		{InputTypeName: "ProductFilter", FieldName: "category"},
+		{InputTypeName: "ProductFilter", FieldName: "name"},
+	}, usage.InputFields)
+	assert.Equal(t, []EnumValueUsage{{EnumName: "Category", Value: "HATS"}}, usage.EnumValues)
+
+	t.Run("literal values and unset variables", func(t *testing.T) {
+		p := planOperation(t,
+			`query Products($filter: ProductFilter) { products(filter: $filter, order: DESC) { name } }`,
+			`{}`)
+
+		usage := Collect(p.operation, p.definition, "Products", p.plan, ClientInfo{})
+		assert.Equal(t, []ArgumentUsage{
+			{TypeName: "Query", FieldName: "products", ArgumentName: "order"},
+		}, usage.Arguments)
+		assert.Empty(t, usage.InputFields)
+		assert.Equal(t, []EnumValueUsage{{EnumName: "Order", Value: "DESC"}}, usage.EnumValues)
+	})
+}
+
+func TestClientInfoFromHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set(DefaultClientNameHeader, "web")
+	header.Set(DefaultClientVersionHeader, "1.0.0")
+	header.Set("X-Client", "ios")
+	assert.Equal(t, ClientInfo{Name: "web", Version: "1.0.0"}, ClientInfoFromHeader(header, "", ""))
+	assert.Equal(t, ClientInfo{Name: "ios"}, ClientInfoFromHeader(header, "X-Client", "X-Client-Version"))
+}
+
+func TestCollector(t *testing.T) {
+	var exported []*Report
+	collector := NewCollector(context.Background(), Options{
+		Exporter: ExporterFunc(func(ctx context.Context, report *Report) error {
+			exported = append(exported, report)
+			return nil
+		}),
+	})
+
+	p := planOperation(t,
+		`query Products($filter: ProductFilter) { products(filter: $filter) { name } }`,
+		`{"filter":{"category":"SHOES"}}`)
+	header := http.Header{}
+	header.Set(DefaultClientNameHeader, "web")
+	usage := collector.CollectRequest(p.operation, p.definition, "Products", p.plan, header)
+	assert.Equal(t, ClientInfo{Name: "web"}, usage.Client)
+	collector.CollectRequest(p.operation, p.definition, "Products", p.plan, header)
+	collector.CollectRequest(p.operation, p.definition, "Products", p.plan, http.Header{})
+
+	require.NoError(t, collector.Flush(context.Background()))
+	require.Len(t, exported, 1)
+
+	require.Len(t, exported[0].TracesPerQuery, 1)
+	out, err := json.Marshal(exported[0].TracesPerQuery[StatsReportKey("Products", usage.Signature)])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"statsWithContext": [
+			{
+				"context": {"clientName": "web", "clientVersion": ""},
+				"queryLatencyStats": {"requestCount": 2},
+				"perTypeStat": {
+					"Query": {"perFieldStat": {"products": {"returnType": "[Product!]!", "requestCount": 2, "subgraphs": {"products": 2}, "arguments": {"filter": {"requestCount": 2, "nullCount": 0}}}}},
+					"Product": {"perFieldStat": {"name": {"returnType": "String!", "requestCount": 2, "subgraphs": {"products": 2}}}}
+				},
+				"extendedReferences": {
+					"inputTypes": {"ProductFilter": {"fieldNames": {"category": {"refCount": 2}}}},
+					"enumValues": {"Category": {"enumValues": {"SHOES": {"requestCount": 2}}}}
+				}
+			},
+			{
+				"context": {"clientName": "", "clientVersion": ""},
+				"queryLatencyStats": {"requestCount": 1},
+				"perTypeStat": {
+					"Query": {"perFieldStat": {"products": {"returnType": "[Product!]!", "requestCount": 1, "subgraphs": {"products": 1}, "arguments": {"filter": {"requestCount": 1, "nullCount": 0}}}}},
+					"Product": {"perFieldStat": {"name": {"returnType": "String!", "requestCount": 1, "subgraphs": {"products": 1}}}}
+				},
+				"extendedReferences": {
+					"inputTypes": {"ProductFilter": {"fieldNames": {"category": {"refCount": 1}}}},
+					"enumValues": {"Category": {"enumValues": {"SHOES": {"requestCount": 1}}}}
+				}
+			}
+		],
+		"referencedFieldsByType": {
+			"Query": {"fieldNames": ["products"], "isInterface": false},
+			"Product": {"fieldNames": ["name"], "isInterface": false}
+		}
+	}`, string(out))
+
+	// the report is reset after the flush, so an empty report isn't exported
+	require.NoError(t, collector.Flush(context.Background()))
+	assert.Len(t, exported, 1)
+}
@@ -0,0 +1,204 @@
+package fieldusage
+
+import (
+	"slices"
+)
+
+// Report is the aggregate of the usage records since the last export
+// Its JSON shape follows the Apollo usage report, the stats are keyed by "# OperationName\nSignature"
+// The subgraphs and arguments of a field are additions to the Apollo shape
+type Report struct {
+	TracesPerQuery map[string]*TracesAndStats `json:"tracesPerQuery"`
+}
+
+type TracesAndStats struct {
+	StatsWithContext       []*ContextualizedStats              `json:"statsWithContext"`
+	ReferencedFieldsByType map[string]*ReferencedFieldsForType `json:"referencedFieldsByType"`
+}
+
+// ContextualizedStats are the stats of an operation sent by a client
+type ContextualizedStats struct {
+	Context            StatsContext         `json:"context"`
+	QueryLatencyStats  QueryLatencyStats    `json:"queryLatencyStats"`
+	PerTypeStat        map[string]*TypeStat `json:"perTypeStat"`
+	ExtendedReferences ExtendedReferences   `json:"extendedReferences"`
+}
+
+type StatsContext struct {
+	ClientName    string `json:"clientName"`
+	ClientVersion string `json:"clientVersion"`
+}
+
+type QueryLatencyStats struct {
+	RequestCount uint64 `json:"requestCount"`
+}
+
+type TypeStat struct {
+	PerFieldStat map[string]*FieldStat `json:"perFieldStat"`
+}
+
+type FieldStat struct {
+	ReturnType string `json:"returnType"`
+	// RequestCount is the number of requests that referenced the field
+	RequestCount uint64 `json:"requestCount"`
+	// Subgraphs counts the requests by the subgraph that resolved the field
+	Subgraphs map[string]uint64 `json:"subgraphs,omitempty"`
+	// Arguments counts the requests by the argument of the field that was set
+	Arguments map[string]*ArgumentStat `json:"arguments,omitempty"`
+}
+
+type ArgumentStat struct {
+	RequestCount uint64 `json:"requestCount"`
+	NullCount    uint64 `json:"nullCount"`
+}
+
+type ReferencedFieldsForType struct {
+	FieldNames  []string `json:"fieldNames"`
+	IsInterface bool     `json:"isInterface"`
+}
+
+type ExtendedReferences struct {
+	InputTypes map[string]*InputTypeStats `json:"inputTypes"`
+	EnumValues map[string]*EnumStats      `json:"enumValues"`
+}
+
+type InputTypeStats struct {
+	FieldNames map[string]*InputFieldStats `json:"fieldNames"`
+}
+
+type InputFieldStats struct {
+	RefCount uint64 `json:"refCount"`
+}
+
+type EnumStats struct {
+	EnumValues map[string]*EnumValueStats `json:"enumValues"`
+}
+
+type EnumValueStats struct {
+	RequestCount uint64 `json:"requestCount"`
+}
+
+func newReport() *Report {
+	return &Report{
+		TracesPerQuery: make(map[string]*TracesAndStats),
+	}
+}
+
+// StatsReportKey is the key of the stats of an operation in Report.TracesPerQuery
+func StatsReportKey(operationName, signature string) string {
+	return "# " + operationName + "\n" + signature
+}
+
+// add aggregates the usage record into the report
+func (r *Report) add(usage *Usage) {
+	key := StatsReportKey(usage.OperationName, usage.Signature)
+	traces, ok := r.TracesPerQuery[key]
+	if !ok {
+		traces = &TracesAndStats{
+			ReferencedFieldsByType: make(map[string]*ReferencedFieldsForType),
+		}
+		r.TracesPerQuery[key] = traces
+	}
+
+	for _, field := range usage.Fields {
+		referenced, ok := traces.ReferencedFieldsByType[field.TypeName]
+		if !ok {
+			referenced = &ReferencedFieldsForType{IsInterface: field.IsInterface}
+			traces.ReferencedFieldsByType[field.TypeName] = referenced
+		}
+		if !slices.Contains(referenced.FieldNames, field.FieldName) {
+			referenced.FieldNames = append(referenced.FieldNames, field.FieldName)
+			slices.Sort(referenced.FieldNames)
+		}
+	}
+
+	stats := traces.stats(usage.Client)
+	stats.QueryLatencyStats.RequestCount++
+	for _, field := range usage.Fields {
+		fieldStat := stats.fieldStat(field.TypeName, field.FieldName)
+		fieldStat.ReturnType = field.ReturnType
+		fieldStat.RequestCount++
+		for _, subgraph := range field.Subgraphs {
+			if fieldStat.Subgraphs == nil {
+				fieldStat.Subgraphs = make(map[string]uint64)
+			}
+			fieldStat.Subgraphs[subgraph]++
+		}
+	}
+	for _, argument := range usage.Arguments {
+		fieldStat := stats.fieldStat(argument.TypeName, argument.FieldName)
+		if fieldStat.Arguments == nil {
+			fieldStat.Arguments = make(map[string]*ArgumentStat)
+		}
+		argumentStat, ok := fieldStat.Arguments[argument.ArgumentName]
+		if !ok {
+			argumentStat = &ArgumentStat{}
+			fieldStat.Arguments[argument.ArgumentName] = argumentStat
+		}
+		argumentStat.RequestCount++
+		if argument.IsNull {
+			argumentStat.NullCount++
+		}
+	}
+	for _, inputField := range usage.InputFields {
+		inputType, ok := stats.ExtendedReferences.InputTypes[inputField.InputTypeName]
+		if !ok {
+			inputType = &InputTypeStats{FieldNames: make(map[string]*InputFieldStats)}
+			stats.ExtendedReferences.InputTypes[inputField.InputTypeName] = inputType
+		}
+		fieldStats, ok := inputType.FieldNames[inputField.FieldName]
+		if !ok {
+			fieldStats = &InputFieldStats{}
+			inputType.FieldNames[inputField.FieldName] = fieldStats
+		}
+		fieldStats.RefCount++
+	}
+	for _, enumValue := range usage.EnumValues {
+		enum, ok := stats.ExtendedReferences.EnumValues[enumValue.EnumName]
+		if !ok {
+			enum = &EnumStats{EnumValues: make(map[string]*EnumValueStats)}
+			stats.ExtendedReferences.EnumValues[enumValue.EnumName] = enum
+		}
+		valueStats, ok := enum.EnumValues[enumValue.Value]
+		if !ok {
+			valueStats = &EnumValueStats{}
+			enum.EnumValues[enumValue.Value] = valueStats
+		}
+		valueStats.RequestCount++
+	}
+}
+
+func (t *TracesAndStats) stats(client ClientInfo) *ContextualizedStats {
+	for _, stats := range t.StatsWithContext {
+		if stats.Context.ClientName == client.Name && stats.Context.ClientVersion == client.Version {
+			return stats
+		}
+	}
+	stats := &ContextualizedStats{
+		Context: StatsContext{
+			ClientName:    client.Name,
+			ClientVersion: client.Version,
+		},
+		PerTypeStat: make(map[string]*TypeStat),
+		ExtendedReferences: ExtendedReferences{
+			InputTypes: make(map[string]*InputTypeStats),
+			EnumValues: make(map[string]*EnumStats),
+		},
+	}
+	t.StatsWithContext = append(t.StatsWithContext, stats)
+	return stats
+}
+
+func (s *ContextualizedStats) fieldStat(typeName, fieldName string) *FieldStat {
+	typeStat, ok := s.PerTypeStat[typeName]
+	if !ok {
+		typeStat = &TypeStat{PerFieldStat: make(map[string]*FieldStat)}
+		s.PerTypeStat[typeName] = typeStat
+	}
+	fieldStat, ok := typeStat.PerFieldStat[fieldName]
+	if !ok {
+		fieldStat = &FieldStat{}
+		typeStat.PerFieldStat[fieldName] = fieldStat
+	}
+	return fieldStat
+}
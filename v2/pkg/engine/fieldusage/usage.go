@@ -0,0 +1,315 @@
+// Package fieldusage records which types, fields, arguments and enum values are used by the operations of which clients
+// The usages are aggregated in memory to reports in the shape of the Apollo usage reports and handed to an Exporter
+package fieldusage
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+// Default headers of the client name and version, as sent by the Apollo clients
+const (
+	DefaultClientNameHeader    = "apollographql-client-name"
+	DefaultClientVersionHeader = "apollographql-client-version"
+)
+
+// ClientInfo identifies the client that sent an operation
+type ClientInfo struct {
+	Name    string
+	Version string
+}
+
+// Usage is the usage record of a single request
+type Usage struct {
+	OperationName string
+	// Signature is the printed normalized operation, literal values are extracted to variables by the normalization
+	Signature   string
+	Client      ClientInfo
+	Fields      []FieldUsage
+	Arguments   []ArgumentUsage
+	InputFields []InputFieldUsage
+	EnumValues  []EnumValueUsage
+}
+
+// FieldUsage is the usage of the field TypeName.FieldName
+type FieldUsage struct {
+	TypeName    string
+	FieldName   string
+	ReturnType  string
+	IsInterface bool
+	// Subgraphs are the names of the datasources that resolved the field
+	Subgraphs []string
+}
+
+// ArgumentUsage is the usage of the argument TypeName.FieldName(ArgumentName:)
+type ArgumentUsage struct {
+	TypeName     string
+	FieldName    string
+	ArgumentName string
+	// IsNull is true if the argument was explicitly set to null
+	IsNull bool
+}
+
+// InputFieldUsage is the usage of the field InputTypeName.FieldName of an input object in an argument
+type InputFieldUsage struct {
+	InputTypeName string
+	FieldName     string
+}
+
+// EnumValueUsage is the usage of the value EnumName.Value in an argument
+type EnumValueUsage struct {
+	EnumName string
+	Value    string
+}
+
+// Collect returns the usage record of the normalized operation and its plan
+// The fields are taken from the plan, so each field has the subgraphs that resolve it
+// The arguments, input fields and enum values are taken from the operation and its variables
+func Collect(operation, definition *ast.Document, operationName string, p plan.Plan, client ClientInfo) *Usage {
+	usage := &Usage{
+		OperationName: operationName,
+		Client:        client,
+	}
+	usage.Signature, _ = astprinter.PrintString(operation)
+
+	fields := &fieldCollector{
+		definition: definition,
+		seen:       make(map[string]int),
+	}
+	switch p := p.(type) {
+	case *plan.SynchronousResponsePlan:
+		if p.Response != nil {
+			fields.collectNode(p.Response.Data)
+		}
+	case *plan.SubscriptionResponsePlan:
+		if p.Response != nil && p.Response.Response != nil {
+			fields.collectNode(p.Response.Response.Data)
+		}
+	}
+	usage.Fields = fields.fields
+
+	walker := astvisitor.NewWalker(8)
+	visitor := &argumentVisitor{
+		Walker:        &walker,
+		operation:     operation,
+		definition:    definition,
+		operationName: operationName,
+		usage:         usage,
+		seen:          make(map[string]struct{}),
+	}
+	walker.RegisterEnterOperationVisitor(visitor)
+	walker.RegisterEnterArgumentVisitor(visitor)
+	walker.Walk(operation, definition, nil)
+
+	return usage
+}
+
+// ClientInfoFromHeader returns the client name and version from the given headers
+// If a header name is empty, the default header is used
+func ClientInfoFromHeader(header http.Header, nameHeader, versionHeader string) ClientInfo {
+	if nameHeader == "" {
+		nameHeader = DefaultClientNameHeader
+	}
+	if versionHeader == "" {
+		versionHeader = DefaultClientVersionHeader
+	}
+	return ClientInfo{
+		Name:    header.Get(nameHeader),
+		Version: header.Get(versionHeader),
+	}
+}
+
+type fieldCollector struct {
+	definition *ast.Document
+	fields     []FieldUsage
+	// seen maps the coordinate of a field to its position in fields
+	seen map[string]int
+}
+
+func (c *fieldCollector) collectNode(node resolve.Node) {
+	switch node := node.(type) {
+	case *resolve.Object:
+		for _, field := range node.Fields {
+			c.collectField(field)
+			c.collectNode(field.Value)
+		}
+	case *resolve.Array:
+		c.collectNode(node.Item)
+	}
+}
+
+func (c *fieldCollector) collectField(field *resolve.Field) {
+	if field.Info == nil || strings.HasPrefix(field.Info.Name, "__") {
+		return
+	}
+	typeName := field.Info.ExactParentTypeName
+	coordinate := typeName + "." + field.Info.Name
+	i, ok := c.seen[coordinate]
+	if !ok {
+		i = len(c.fields)
+		c.seen[coordinate] = i
+		usage := FieldUsage{
+			TypeName:   typeName,
+			FieldName:  field.Info.Name,
+			ReturnType: c.returnType(typeName, field.Info.Name),
+		}
+		if node, exists := c.definition.Index.FirstNodeByNameStr(typeName); exists {
+			usage.IsInterface = node.Kind == ast.NodeKindInterfaceTypeDefinition
+		}
+		c.fields = append(c.fields, usage)
+	}
+	for _, name := range field.Info.Source.Names {
+		if !slices.Contains(c.fields[i].Subgraphs, name) {
+			c.fields[i].Subgraphs = append(c.fields[i].Subgraphs, name)
+		}
+	}
+	slices.Sort(c.fields[i].Subgraphs)
+}
+
+// returnType is the printed type of the field definition, e.g. [Review!]
+func (c *fieldCollector) returnType(typeName, fieldName string) string {
+	node, exists := c.definition.Index.FirstNodeByNameStr(typeName)
+	if !exists {
+		return ""
+	}
+	fieldDefinition, exists := c.definition.NodeFieldDefinitionByName(node, []byte(fieldName))
+	if !exists {
+		return ""
+	}
+	typeString, err := c.definition.PrintTypeBytes(c.definition.FieldDefinitionType(fieldDefinition), nil)
+	if err != nil {
+		return ""
+	}
+	return string(typeString)
+}
+
+type argumentVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	operationName         string
+	usage                 *Usage
+	seen                  map[string]struct{}
+}
+
+func (v *argumentVisitor) EnterOperationDefinition(ref int) {
+	if v.operationName != "" && v.operation.OperationDefinitionNameString(ref) != v.operationName {
+		v.SkipNode()
+	}
+}
+
+func (v *argumentVisitor) EnterArgument(ref int) {
+	ancestor := v.Ancestors[len(v.Ancestors)-1]
+	if ancestor.Kind != ast.NodeKindField {
+		// directive arguments aren't part of the schema usage
+		return
+	}
+	inputValueDefinition, ok := v.ArgumentInputValueDefinition(ref)
+	if !ok {
+		return
+	}
+
+	value := v.operation.Arguments[ref].Value
+	var (
+		data      []byte
+		valueType jsonparser.ValueType
+		err       error
+	)
+	if value.Kind == ast.ValueKindVariable {
+		data, valueType, _, err = jsonparser.Get(v.operation.Input.Variables, v.operation.VariableValueNameString(value.Ref))
+		if err != nil {
+			// the variable isn't set, so the argument isn't used
+			return
+		}
+	} else {
+		data, err = v.operation.ValueToJSON(value)
+		if err != nil {
+			return
+		}
+		data, valueType, _, err = jsonparser.Get(data)
+		if err != nil {
+			return
+		}
+	}
+
+	typeName := v.definition.NodeNameString(v.TypeDefinitions[len(v.TypeDefinitions)-2])
+	argument := ArgumentUsage{
+		TypeName:     typeName,
+		FieldName:    v.operation.FieldNameString(ancestor.Ref),
+		ArgumentName: v.operation.ArgumentNameString(ref),
+		IsNull:       valueType == jsonparser.Null,
+	}
+	if v.markSeen("argument", argument.TypeName, argument.FieldName, argument.ArgumentName, strconv.FormatBool(argument.IsNull)) {
+		v.usage.Arguments = append(v.usage.Arguments, argument)
+	}
+	v.collectInputValue(v.definition.InputValueDefinitionType(inputValueDefinition), data, valueType)
+}
+
+// collectInputValue records the input fields and enum values of a value of the given type
+func (v *argumentVisitor) collectInputValue(typeRef int, data []byte, valueType jsonparser.ValueType) {
+	if valueType == jsonparser.Null {
+		return
+	}
+	switch v.definition.Types[typeRef].TypeKind {
+	case ast.TypeKindNonNull:
+		v.collectInputValue(v.definition.Types[typeRef].OfType, data, valueType)
+	case ast.TypeKindList:
+		if valueType != jsonparser.Array {
+			// a single value is coerced to a list
+			v.collectInputValue(v.definition.Types[typeRef].OfType, data, valueType)
+			return
+		}
+		_, _ = jsonparser.ArrayEach(data, func(item []byte, itemType jsonparser.ValueType, _ int, _ error) {
+			v.collectInputValue(v.definition.Types[typeRef].OfType, item, itemType)
+		})
+	case ast.TypeKindNamed:
+		typeName := v.definition.TypeNameString(typeRef)
+		node, exists := v.definition.Index.FirstNodeByNameStr(typeName)
+		if !exists {
+			return
+		}
+		switch node.Kind {
+		case ast.NodeKindEnumTypeDefinition:
+			if valueType != jsonparser.String {
+				return
+			}
+			if v.markSeen("enum", typeName, string(data)) {
+				v.usage.EnumValues = append(v.usage.EnumValues, EnumValueUsage{EnumName: typeName, Value: string(data)})
+			}
+		case ast.NodeKindInputObjectTypeDefinition:
+			if valueType != jsonparser.Object {
+				return
+			}
+			_ = jsonparser.ObjectEach(data, func(key []byte, value []byte, fieldType jsonparser.ValueType, _ int) error {
+				fieldDefinition := v.definition.InputObjectTypeDefinitionInputValueDefinitionByName(node.Ref, key)
+				if fieldDefinition == -1 {
+					return nil
+				}
+				if v.markSeen("input", typeName, string(key)) {
+					v.usage.InputFields = append(v.usage.InputFields, InputFieldUsage{InputTypeName: typeName, FieldName: string(key)})
+				}
+				v.collectInputValue(v.definition.InputValueDefinitionType(fieldDefinition), value, fieldType)
+				return nil
+			})
+		}
+	}
+}
+
+// markSeen returns true the first time the key is seen, so each usage is only recorded once per request
+func (v *argumentVisitor) markSeen(parts ...string) bool {
+	key := strings.Join(parts, "\x00")
+	if _, ok := v.seen[key]; ok {
+		return false
+	}
+	v.seen[key] = struct{}{}
+	return true
+}
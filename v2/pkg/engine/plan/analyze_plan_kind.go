@@ -46,6 +46,10 @@ func (p *planKindVisitor) EnterDirective(ref int) {
 		case "stream":
 			p.hasStreamDirective = true
 		}
+	case ast.NodeKindInlineFragment, ast.NodeKindFragmentSpread:
+		if directiveName == "defer" {
+			p.hasDeferDirective = true
+		}
 	}
 }
 
@@ -135,6 +135,21 @@ func TestAnalyzePlanKind(t *testing.T) {
 		mustStreaming(false),
 		mustSubscription(false),
 	))
+	t.Run("query defer on inline fragment", run(testDefinition, `
+		query MyQuery($id: ID!) {
+			droid(id: $id){
+				name
+				... @defer(label: "details") {
+					primaryFunction
+					favoriteEpisode
+				}
+			}
+		}`,
+		"MyQuery",
+		mustNotErr(),
+		mustStreaming(true),
+		mustSubscription(false),
+	))
 	t.Run("query defer different name", run(testDefinition, `
 		query MyQuery($id: ID!) {
 			droid(id: $id){
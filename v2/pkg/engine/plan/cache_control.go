@@ -0,0 +1,111 @@
+package plan
+
+import (
+	"time"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+const cacheControlDirectiveName = "cacheControl"
+
+// cacheControlHint is a @cacheControl(maxAge: Int, scope: CacheControlScope, inheritMaxAge: Boolean) directive
+type cacheControlHint struct {
+	maxAge        time.Duration
+	hasMaxAge     bool
+	scope         resolve.CacheControlScope
+	inheritMaxAge bool
+}
+
+// hasCacheControlHints returns true if the schema uses @cacheControl on any type or field
+func (v *Visitor) hasCacheControlHints() bool {
+	for i := range v.Definition.Directives {
+		if v.Definition.DirectiveNameString(i) == cacheControlDirectiveName {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Visitor) cacheControlHint(directiveRefs []int) (hint cacheControlHint, ok bool) {
+	for _, ref := range directiveRefs {
+		if v.Definition.DirectiveNameString(ref) != cacheControlDirectiveName {
+			continue
+		}
+		if value, exists := v.Definition.DirectiveArgumentValueByName(ref, []byte("maxAge")); exists && value.Kind == ast.ValueKindInteger {
+			hint.maxAge = time.Duration(v.Definition.IntValueAsInt(value.Ref)) * time.Second
+			hint.hasMaxAge = true
+		}
+		if value, exists := v.Definition.DirectiveArgumentValueByName(ref, []byte("scope")); exists && value.Kind == ast.ValueKindEnum {
+			hint.scope = resolve.CacheControlScope(v.Definition.EnumValueNameString(value.Ref))
+		}
+		if value, exists := v.Definition.DirectiveArgumentValueByName(ref, []byte("inheritMaxAge")); exists && value.Kind == ast.ValueKindBoolean {
+			hint.inheritMaxAge = bool(v.Definition.BooleanValue(value.Ref))
+		}
+		return hint, true
+	}
+	return hint, false
+}
+
+// resolveCacheControl computes the cache policy of a field following the rules of @cacheControl:
+// a max age on the field wins over a max age on the returned composite type,
+// root fields and fields returning composite types default to a max age of 0 unless they set inheritMaxAge,
+// all other fields inherit the max age of their parent field
+// It returns nil when the field inherits the policy of its parent, because the parent already restricts the response
+func (v *Visitor) resolveCacheControl(fieldRef, fieldDefinitionRef int) *resolve.CacheControlPolicy {
+	if !v.cacheControlEnabled {
+		return nil
+	}
+
+	fieldHint, _ := v.cacheControlHint(v.Definition.FieldDefinitionDirectives(fieldDefinitionRef))
+
+	var typeHint cacheControlHint
+	typeNode := v.Definition.FieldDefinitionTypeNode(fieldDefinitionRef)
+	isComposite := false
+	switch typeNode.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
+		isComposite = true
+		typeHint, _ = v.cacheControlHint(v.Definition.NodeDirectives(typeNode))
+	}
+
+	parent, isRoot := v.parentCacheControl()
+
+	policy := resolve.CacheControlPolicy{
+		Scope: fieldHint.scope,
+	}
+	if policy.Scope == "" {
+		policy.Scope = typeHint.scope
+	}
+
+	switch {
+	case fieldHint.hasMaxAge:
+		policy.MaxAge, policy.HasMaxAge = fieldHint.maxAge, true
+	case typeHint.hasMaxAge:
+		policy.MaxAge, policy.HasMaxAge = typeHint.maxAge, true
+	case fieldHint.inheritMaxAge || (!isComposite && !isRoot):
+		policy.MaxAge, policy.HasMaxAge = parent.MaxAge, parent.HasMaxAge
+		v.cacheControlPolicies[fieldRef] = policy
+		if policy.Scope == "" {
+			return nil
+		}
+		// the max age is already restricted by the parent
+		return &resolve.CacheControlPolicy{Scope: policy.Scope}
+	default:
+		policy.HasMaxAge = true
+	}
+
+	v.cacheControlPolicies[fieldRef] = policy
+	return &policy
+}
+
+// parentCacheControl returns the policy of the closest enclosing field
+// isRoot is true when the field is a root field of the operation
+func (v *Visitor) parentCacheControl() (parent resolve.CacheControlPolicy, isRoot bool) {
+	for i := len(v.Walker.Ancestors) - 1; i >= 0; i-- {
+		if v.Walker.Ancestors[i].Kind != ast.NodeKindField {
+			continue
+		}
+		return v.cacheControlPolicies[v.Walker.Ancestors[i].Ref], false
+	}
+	return parent, true
+}
@@ -0,0 +1,120 @@
+package plan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astvalidation"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+func TestPlanner_CacheControl(t *testing.T) {
+	const definition = `
+		directive @cacheControl(maxAge: Int, scope: CacheControlScope, inheritMaxAge: Boolean) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+		enum CacheControlScope { PUBLIC PRIVATE }
+
+		type Query {
+			product: Product @cacheControl(maxAge: 60)
+			me: User
+			cart: Cart
+		}
+		type Product @cacheControl(maxAge: 120) {
+			name: String
+			price: Int @cacheControl(maxAge: 30)
+			reviews: [Review]
+			secret: String @cacheControl(scope: PRIVATE)
+			details: Details @cacheControl(inheritMaxAge: true)
+		}
+		type Review {
+			body: String
+		}
+		type Details {
+			weight: Int
+		}
+		type User @cacheControl(maxAge: 10, scope: PRIVATE) {
+			name: String
+		}
+		type Cart {
+			total: Int
+		}
+	`
+	const operation = `{
+		product { name price reviews { body } secret details { weight } }
+		me { name }
+		cart { total }
+	}`
+
+	def := unsafeparser.ParseGraphqlDocumentString(definition)
+	op := unsafeparser.ParseGraphqlDocumentString(operation)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+	report := &operationreport.Report{}
+	astnormalization.NewNormalizer(true, true).NormalizeOperation(&op, &def, report)
+	astvalidation.DefaultOperationValidator().Validate(&op, &def, report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	planner, err := NewPlanner(Configuration{
+		DisableResolveFieldPositions: true,
+		DisableIncludeInfo:           true,
+		DataSources: []DataSource{
+			dsb().Schema(definition).
+				RootNode("Query", "product", "me", "cart").
+				ChildNode("Product", "name", "price", "reviews", "secret", "details").
+				ChildNode("Review", "body").
+				ChildNode("Details", "weight").
+				ChildNode("User", "name").
+				ChildNode("Cart", "total").
+				DS(),
+		},
+	})
+	require.NoError(t, err)
+	result := planner.Plan(&op, &def, "", report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	policies := map[string]*resolve.CacheControlPolicy{}
+	var collect func(prefix string, node resolve.Node)
+	collect = func(prefix string, node resolve.Node) {
+		switch n := node.(type) {
+		case *resolve.Object:
+			for _, field := range n.Fields {
+				path := prefix + string(field.Name)
+				policies[path] = field.CacheControl
+				collect(path+".", field.Value)
+			}
+		case *resolve.Array:
+			collect(prefix, n.Item)
+		}
+	}
+	collect("", result.(*SynchronousResponsePlan).Response.Data)
+
+	maxAge := func(d time.Duration) *resolve.CacheControlPolicy {
+		return &resolve.CacheControlPolicy{MaxAge: d, HasMaxAge: true}
+	}
+
+	assert.Equal(t, map[string]*resolve.CacheControlPolicy{
+		// field hints win over type hints
+		"product":      maxAge(60 * time.Second),
+		"product.name": nil,
+		// scalar field hints lower the max age
+		"product.price": maxAge(30 * time.Second),
+		// composite fields without hints are not cacheable
+		"product.reviews":      maxAge(0),
+		"product.reviews.body": nil,
+		"product.secret":       {Scope: resolve.CacheControlScopePrivate},
+		// inheritMaxAge keeps the max age of the parent
+		"product.details":        nil,
+		"product.details.weight": nil,
+		// type hints apply to fields returning the type
+		"me":      {MaxAge: 10 * time.Second, HasMaxAge: true, Scope: resolve.CacheControlScopePrivate},
+		"me.name": nil,
+		// root fields without hints are not cacheable
+		"cart":       maxAge(0),
+		"cart.total": nil,
+	}, policies)
+}
@@ -1,6 +1,8 @@
 package plan
 
 import (
+	"time"
+
 	"github.com/jensneuse/abstractlogger"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
@@ -106,6 +108,9 @@ type FieldConfiguration struct {
 	UnescapeResponseJson bool
 	// HasAuthorizationRule needs to be set to true if the Authorizer should be called for this field
 	HasAuthorizationRule bool
+	// Timeout limits the duration of fetches resolving this field as a root field
+	// It takes precedence over the FetchTimeout of the DataSource
+	Timeout time.Duration
 
 	SubscriptionFilterCondition *SubscriptionFilterCondition
 }
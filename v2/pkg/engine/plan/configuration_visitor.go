@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/jensneuse/abstractlogger"
 
@@ -110,6 +111,8 @@ type objectFetchConfiguration struct {
 	dependsOnFetchIDs  []int
 	rootFields         []resolve.GraphCoordinate
 	operationType      ast.OperationType
+	entityCache        *resolve.EntityCacheConfiguration
+	timeout            time.Duration
 }
 
 func (c *configurationVisitor) currentSelectionSet() int {
@@ -694,7 +697,7 @@ func (c *configurationVisitor) planWithExistingPlanners(fieldRef int, typeName,
 }
 
 func (c *configurationVisitor) isParentPathIsRootOperationPath(parentPath string) bool {
-	return parentPath == "query" || parentPath == "mutation" || parentPath == "subscription"
+	return isRootOperationPath(parentPath)
 }
 
 func (c *configurationVisitor) allowNewPlannerForTypenameField(fieldName string, typeName string, parentPath string, dsCfg DataSource) bool {
@@ -824,6 +827,8 @@ func (c *configurationVisitor) addNewPlanner(fieldRef int, typeName, fieldName,
 		sourceName:         dsConfig.Name(),
 		operationType:      c.resolveRootFieldOperationType(typeName),
 		filter:             c.resolveSubscriptionFilterCondition(typeName, fieldName),
+		entityCache:        dsConfig.FederationConfiguration().EntityCaching.CacheConfiguration(),
+		timeout:            dataSourceFetchTimeout(dsConfig),
 	}
 
 	plannerPathConfig := newPlannerPathsConfiguration(
@@ -3,6 +3,7 @@ package plan
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/jensneuse/abstractlogger"
@@ -42,6 +43,9 @@ type DataSourceMetadata struct {
 	// Note: Unions are not present in the child or root nodes
 	ChildNodes TypeFields
 	Directives *DirectiveConfigurations
+	// FetchTimeout limits the duration of each fetch to the DataSource
+	// If zero, fetches are only limited by the deadline of the request and the timeout of the client
+	FetchTimeout time.Duration
 
 	rootNodesIndex  map[string]fieldsIndex
 	childNodesIndex map[string]fieldsIndex
@@ -230,6 +234,11 @@ type DataSourceUpstreamSchema interface {
 	UpstreamSchema() (*ast.Document, bool)
 }
 
+// DataSourceFetchTimeout is optionally implemented by a DataSource to limit the duration of each of its fetches
+type DataSourceFetchTimeout interface {
+	Timeout() time.Duration
+}
+
 type DataSource interface {
 	FederationInfo
 	NodesInfo
@@ -280,6 +289,21 @@ func (d *dataSourceConfiguration[T]) FederationConfiguration() FederationMetaDat
 	return d.FederationMetaData
 }
 
+func (d *dataSourceConfiguration[T]) Timeout() time.Duration {
+	if d.DataSourceMetadata == nil {
+		return 0
+	}
+	return d.FetchTimeout
+}
+
+// dataSourceFetchTimeout returns the fetch timeout of the DataSource, or zero if it doesn't implement DataSourceFetchTimeout
+func dataSourceFetchTimeout(ds DataSource) time.Duration {
+	if timeout, ok := ds.(DataSourceFetchTimeout); ok {
+		return timeout.Timeout()
+	}
+	return 0
+}
+
 func (d *dataSourceConfiguration[T]) Hash() DSHash {
 	return d.hash
 }
@@ -16,6 +16,8 @@ type nodesCollector struct {
 	dataSources []DataSource
 	nodes       *NodeSuggestions
 	report      *operationreport.Report
+
+	overrideLabels map[string]struct{}
 }
 
 func (c *nodesCollector) CollectNodes() *NodeSuggestions {
@@ -35,6 +37,7 @@ func (c *nodesCollector) CollectNodes() *NodeSuggestions {
 func (c *nodesCollector) collectNodes() {
 
 	info := getFieldInfo(c.operation, c.definition)
+	overridden := c.overriddenFields()
 
 	wg := &sync.WaitGroup{}
 	wg.Add(len(c.dataSources))
@@ -49,6 +52,7 @@ func (c *nodesCollector) collectNodes() {
 			walker:     walker,
 			nodes:      c.nodes,
 			info:       info,
+			overridden: overridden[dataSource.Hash()],
 		}
 		walker.RegisterFieldVisitor(visitor)
 		visitor.dataSource = dataSource
@@ -81,6 +85,43 @@ func (c *nodesCollector) collectNodes() {
 	}
 }
 
+// overriddenFields returns per data source the fields which must not be resolved by it because of @override:
+// the field of the subgraph named in from when the override is active,
+// the field of the overriding subgraph when the override has an inactive label
+func (c *nodesCollector) overriddenFields() map[DSHash]map[overriddenField]struct{} {
+	var out map[DSHash]map[overriddenField]struct{}
+	add := func(hash DSHash, field overriddenField) {
+		if out == nil {
+			out = make(map[DSHash]map[overriddenField]struct{})
+		}
+		if out[hash] == nil {
+			out[hash] = make(map[overriddenField]struct{})
+		}
+		out[hash][field] = struct{}{}
+	}
+
+	for _, dataSource := range c.dataSources {
+		for _, override := range dataSource.FederationConfiguration().Overrides {
+			field := overriddenField{typeName: override.TypeName, fieldName: override.FieldName}
+			if !override.IsActive(c.overrideLabels) {
+				add(dataSource.Hash(), field)
+				continue
+			}
+			for _, from := range c.dataSources {
+				if from.Name() == override.From {
+					add(from.Hash(), field)
+				}
+			}
+		}
+	}
+	return out
+}
+
+type overriddenField struct {
+	typeName  string
+	fieldName string
+}
+
 func (c *nodesCollector) buildTree() {
 	walker := astvisitor.NewWalker(32)
 	visitor := &treeBuilderVisitor{
@@ -146,8 +187,9 @@ type collectNodesVisitor struct {
 
 	nodes *NodeSuggestions
 
-	keyPaths map[string]struct{}
-	info     map[int]fieldInfo
+	keyPaths   map[string]struct{}
+	info       map[int]fieldInfo
+	overridden map[overriddenField]struct{}
 }
 
 func (f *collectNodesVisitor) hasSuggestionForFieldOnCurrentDataSource(itemIds []int, ref int) (itemID int, ok bool) {
@@ -365,6 +407,11 @@ func (f *collectNodesVisitor) EnterField(fieldRef int) {
 		return
 	}
 
+	if _, ok := f.overridden[overriddenField{typeName: info.typeName, fieldName: info.fieldName}]; ok {
+		// the field is resolved by another subgraph because of @override
+		return
+	}
+
 	// hasRootNode is true when:
 	// - ds config has a root node for the field
 	// - we have a root node with typename and the field is a __typename field
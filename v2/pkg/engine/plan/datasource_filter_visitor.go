@@ -25,6 +25,7 @@ type DataSourceFilter struct {
 
 	fieldDependsOn map[int][]int
 	dataSources    []DataSource
+	overrideLabels map[string]struct{}
 }
 
 func NewDataSourceFilter(operation, definition *ast.Document, report *operationreport.Report) *DataSourceFilter {
@@ -35,6 +36,14 @@ func NewDataSourceFilter(operation, definition *ast.Document, report *operationr
 	}
 }
 
+// SetOverrideLabels sets the labels of the progressive overrides which are active for the operation
+func (f *DataSourceFilter) SetOverrideLabels(labels []string) {
+	f.overrideLabels = make(map[string]struct{}, len(labels))
+	for _, label := range labels {
+		f.overrideLabels[label] = struct{}{}
+	}
+}
+
 func (f *DataSourceFilter) EnableSelectionReasons() {
 	f.enableSelectionReasons = true
 }
@@ -122,6 +131,8 @@ func (f *DataSourceFilter) collectNodes(dataSources []DataSource, existingNodes
 		dataSources: dataSources,
 		nodes:       existingNodes,
 		report:      f.report,
+
+		overrideLabels: f.overrideLabels,
 	}
 
 	return nodesCollector.CollectNodes()
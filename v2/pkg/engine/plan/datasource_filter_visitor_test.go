@@ -55,6 +55,11 @@ func (b *dsBuilder) Hash(hash DSHash) *dsBuilder {
 	return b
 }
 
+func (b *dsBuilder) Name(name string) *dsBuilder {
+	b.ds.name = name
+	return b
+}
+
 func (b *dsBuilder) DS() DataSource {
 	b.ds.DataSourceMetadata.InitNodesIndex()
 	return b.ds
@@ -1239,3 +1244,94 @@ var conflictingShareablePathsRelayStyleDefinition = `
 		users: PaginatedUser!
 		address(id: ID!): Address!
 	}`
+
+func TestDataSourceFilter_Override(t *testing.T) {
+	definition := `
+		type Query {
+			product: Product
+		}
+		type Product {
+			id: ID!
+			name: String
+			price: Int
+		}
+	`
+	operation := `
+		query {
+			product {
+				name
+				price
+			}
+		}
+	`
+
+	dataSources := func(label string) []DataSource {
+		return []DataSource{
+			dsb().Hash(11).Name("products").Schema(`
+				type Query {
+					product: Product
+				}
+				type Product @key(fields: "id") {
+					id: ID!
+					name: String
+					price: Int
+				}
+			`).RootNode("Query", "product").
+				RootNode("Product", "id", "name", "price").
+				KeysMetadata(FederationFieldConfigurations{{TypeName: "Product", SelectionSet: "id"}}).DS(),
+			dsb().Hash(22).Name("pricing").Schema(`
+				type Product @key(fields: "id") {
+					id: ID!
+					price: Int @override(from: "products", label: "`+label+`")
+				}
+			`).RootNode("Product", "id", "price").
+				KeysMetadata(FederationFieldConfigurations{{TypeName: "Product", SelectionSet: "id"}}).
+				WithMetadata(func(data *FederationMetaData) {
+					data.Overrides = OverrideConfigurations{{TypeName: "Product", FieldName: "price", From: "products", Label: label}}
+				}).DS(),
+		}
+	}
+
+	priceDataSource := func(t *testing.T, dataSources []DataSource, labels ...string) DSHash {
+		t.Helper()
+
+		def := unsafeparser.ParseGraphqlDocumentStringWithBaseSchema(definition)
+		op := unsafeparser.ParseGraphqlDocumentString(operation)
+		report := operationreport.Report{}
+
+		dsFilter := NewDataSourceFilter(&op, &def, &report)
+		dsFilter.SetOverrideLabels(labels)
+		_, suggestions := dsFilter.FilterDataSources(dataSources, nil, nil, nil)
+		if report.HasErrors() {
+			t.Fatal(report.Error())
+		}
+
+		var hashes []DSHash
+		for _, item := range suggestions.items {
+			if item.Selected && item.FieldName == "price" {
+				hashes = append(hashes, item.DataSourceHash)
+			}
+		}
+		assert.Len(t, hashes, 1)
+		return hashes[0]
+	}
+
+	t.Run("inactive label keeps the field on the old subgraph", func(t *testing.T) {
+		assert.Equal(t, DSHash(11), priceDataSource(t, dataSources("percent(10)")))
+		assert.Equal(t, DSHash(11), priceDataSource(t, dataSources("percent(10)"), "percent(50)"))
+	})
+
+	t.Run("active label moves the field to the new subgraph", func(t *testing.T) {
+		assert.Equal(t, DSHash(22), priceDataSource(t, dataSources("percent(10)"), "percent(10)"))
+	})
+
+	t.Run("override without label is always active", func(t *testing.T) {
+		assert.Equal(t, DSHash(22), priceDataSource(t, dataSources("")))
+	})
+
+	t.Run("override labels", func(t *testing.T) {
+		assert.Nil(t, OverrideLabels(dataSources("percent(10)"), nil))
+		assert.Nil(t, OverrideLabels(dataSources("percent(10)"), []string{"percent(50)"}))
+		assert.Equal(t, []string{"percent(10)"}, OverrideLabels(dataSources("percent(10)"), []string{"percent(50)", "percent(10)"}))
+	})
+}
@@ -0,0 +1,68 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+// IsParentPathField reports whether the field is the field of the parent path of a nested planner.
+// Nested planners walk the field of their parent path first, it belongs to the parent planner.
+func IsParentPathField(visitor *Visitor, parentPath string, ref int) bool {
+	if isRootOperationPath(parentPath) {
+		// planners of root fields have no parent field
+		return false
+	}
+	return parentPath == visitor.Walker.Path.DotDelimitedString()+"."+visitor.Operation.FieldAliasOrNameString(ref)
+}
+
+// RepresentationVariable renders the __typename and the key fields of the entity representations of the keys,
+// only keys without nested fields are supported
+func RepresentationVariable(keys ...FederationFieldConfiguration) (*resolve.Object, error) {
+	object := &resolve.Object{
+		Nullable: true,
+	}
+	for _, key := range keys {
+		fieldNames, err := KeyFieldNames(key)
+		if err != nil {
+			return nil, err
+		}
+		onTypeNames := [][]byte{[]byte(key.TypeName)}
+		object.Fields = append(object.Fields, &resolve.Field{
+			Name:        []byte("__typename"),
+			Value:       &resolve.String{Path: []string{"__typename"}},
+			OnTypeNames: onTypeNames,
+		})
+		for _, field := range fieldNames {
+			object.Fields = append(object.Fields, &resolve.Field{
+				Name:        []byte(field),
+				Value:       &resolve.Scalar{Path: []string{field}},
+				OnTypeNames: onTypeNames,
+			})
+		}
+	}
+	return object, nil
+}
+
+// KeyFieldNames returns the names of the fields of the key in the order of its selection set,
+// only keys without nested fields are supported
+func KeyFieldNames(key FederationFieldConfiguration) ([]string, error) {
+	fragment, report := RequiredFieldsFragment(key.TypeName, key.SelectionSet, false)
+	if report.HasErrors() {
+		return nil, fmt.Errorf("entity %s: invalid key %s: %w", key.TypeName, key.SelectionSet, report)
+	}
+	selectionSet := fragment.FragmentDefinitions[0].SelectionSet
+	fieldNames := make([]string, 0, len(fragment.SelectionSets[selectionSet].SelectionRefs))
+	for _, selection := range fragment.SelectionSets[selectionSet].SelectionRefs {
+		if fragment.SelectionKind(selection) != ast.SelectionKindField || fragment.FieldHasSelections(fragment.Selections[selection].Ref) {
+			return nil, fmt.Errorf("entity %s: nested key fields are not supported: %s", key.TypeName, key.SelectionSet)
+		}
+		fieldNames = append(fieldNames, fragment.FieldNameString(fragment.Selections[selection].Ref))
+	}
+	return fieldNames, nil
+}
+
+func isRootOperationPath(path string) bool {
+	return path == "query" || path == "mutation" || path == "subscription"
+}
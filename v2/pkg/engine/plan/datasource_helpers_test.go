@@ -0,0 +1,78 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+)
+
+func TestIsParentPathField(t *testing.T) {
+	operation := unsafeparser.ParseGraphqlDocumentString(`{ user { friends: users { id } } }`)
+	friends := 1
+	require.Equal(t, "friends", operation.FieldAliasOrNameString(friends))
+
+	walker := astvisitor.NewWalker(4)
+	visitor := &Visitor{Walker: &walker, Operation: &operation}
+	walker.Path = ast.Path{
+		{Kind: ast.FieldName, FieldName: []byte("query")},
+		{Kind: ast.FieldName, FieldName: []byte("user")},
+	}
+
+	assert.True(t, IsParentPathField(visitor, "query.user.friends", friends))
+	assert.False(t, IsParentPathField(visitor, "query.user", friends))
+
+	walker.Path = ast.Path{{Kind: ast.FieldName, FieldName: []byte("query")}}
+	// planners of root fields have no parent field
+	assert.False(t, IsParentPathField(visitor, "query", friends))
+}
+
+func TestRepresentationVariable(t *testing.T) {
+	t.Run("keys of multiple entities", func(t *testing.T) {
+		representation, err := RepresentationVariable(
+			FederationFieldConfiguration{TypeName: "User", SelectionSet: "id"},
+			FederationFieldConfiguration{TypeName: "Product", SelectionSet: "upc sku"},
+		)
+		require.NoError(t, err)
+
+		user, product := [][]byte{[]byte("User")}, [][]byte{[]byte("Product")}
+		assert.Equal(t, &resolve.Object{
+			Nullable: true,
+			Fields: []*resolve.Field{
+				{Name: []byte("__typename"), Value: &resolve.String{Path: []string{"__typename"}}, OnTypeNames: user},
+				{Name: []byte("id"), Value: &resolve.Scalar{Path: []string{"id"}}, OnTypeNames: user},
+				{Name: []byte("__typename"), Value: &resolve.String{Path: []string{"__typename"}}, OnTypeNames: product},
+				{Name: []byte("upc"), Value: &resolve.Scalar{Path: []string{"upc"}}, OnTypeNames: product},
+				{Name: []byte("sku"), Value: &resolve.Scalar{Path: []string{"sku"}}, OnTypeNames: product},
+			},
+		}, representation)
+	})
+
+	t.Run("nested key fields", func(t *testing.T) {
+		_, err := RepresentationVariable(FederationFieldConfiguration{TypeName: "User", SelectionSet: "id organization { id }"})
+		assert.EqualError(t, err, "entity User: nested key fields are not supported: id organization { id }")
+	})
+}
+
+func TestKeyFieldNames(t *testing.T) {
+	t.Run("composite key", func(t *testing.T) {
+		fieldNames, err := KeyFieldNames(FederationFieldConfiguration{TypeName: "Product", SelectionSet: "upc, sku"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"upc", "sku"}, fieldNames)
+	})
+
+	t.Run("nested key fields", func(t *testing.T) {
+		_, err := KeyFieldNames(FederationFieldConfiguration{TypeName: "Product", SelectionSet: "organization { id } sku"})
+		assert.EqualError(t, err, "entity Product: nested key fields are not supported: organization { id } sku")
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := KeyFieldNames(FederationFieldConfiguration{TypeName: "Product", SelectionSet: "sku {"})
+		assert.Error(t, err)
+	})
+}
@@ -3,6 +3,9 @@ package plan
 import (
 	"encoding/json"
 	"slices"
+	"time"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
 )
 
 type FederationMetaData struct {
@@ -11,6 +14,10 @@ type FederationMetaData struct {
 	Provides         FederationFieldConfigurations
 	EntityInterfaces []EntityInterfaceConfiguration
 	InterfaceObjects []EntityInterfaceConfiguration
+	// EntityCaching enables the entity cache of the resolver for entity fetches of the listed types
+	EntityCaching EntityCachingConfigurations
+	// Overrides are the fields this subgraph takes over from other subgraphs with @override
+	Overrides OverrideConfigurations
 }
 
 type FederationInfo interface {
@@ -50,6 +57,76 @@ func (d *FederationMetaData) HasEntityInterface(typeName string) bool {
 	})
 }
 
+// EntityCachingConfiguration is the equivalent of @cacheControl(maxAge: ...) on an entity type,
+// entities of the type resolved by the subgraph are cached for MaxAge
+type EntityCachingConfiguration struct {
+	TypeName string
+	MaxAge   time.Duration
+}
+
+type EntityCachingConfigurations []EntityCachingConfiguration
+
+// CacheConfiguration returns the configuration of the entity cache for entity fetches of the subgraph
+// or nil when no entity type is cacheable
+func (c EntityCachingConfigurations) CacheConfiguration() *resolve.EntityCacheConfiguration {
+	var cfg *resolve.EntityCacheConfiguration
+	for _, caching := range c {
+		if caching.MaxAge <= 0 {
+			continue
+		}
+		if cfg == nil {
+			cfg = &resolve.EntityCacheConfiguration{
+				MaxAge: make(map[string]time.Duration, len(c)),
+			}
+		}
+		cfg.MaxAge[caching.TypeName] = caching.MaxAge
+	}
+	return cfg
+}
+
+// OverrideConfiguration is the equivalent of @override(from: ..., label: ...) on a field of the subgraph
+// An override without a label is always active,
+// an override with a label is only active for requests which have the label set, see WithOverrideLabels
+type OverrideConfiguration struct {
+	TypeName  string
+	FieldName string
+	// From is the name of the subgraph the field was migrated from
+	From string
+	// Label enables the override per request, e.g. percent(10)
+	Label string
+}
+
+type OverrideConfigurations []OverrideConfiguration
+
+// IsActive returns true if requests with the given labels are routed to the overriding subgraph
+func (o OverrideConfiguration) IsActive(labels map[string]struct{}) bool {
+	if o.Label == "" {
+		return true
+	}
+	_, ok := labels[o.Label]
+	return ok
+}
+
+// OverrideLabels returns the sorted labels of all progressive overrides declared by the data sources
+// which are contained in labels
+// Only these labels change the plan of an operation, so they can be used as part of a plan cache key
+func OverrideLabels(dataSources []DataSource, labels []string) []string {
+	if len(labels) == 0 {
+		return nil
+	}
+	var out []string
+	for _, ds := range dataSources {
+		for _, override := range ds.FederationConfiguration().Overrides {
+			if override.Label == "" || !slices.Contains(labels, override.Label) || slices.Contains(out, override.Label) {
+				continue
+			}
+			out = append(out, override.Label)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
 type EntityInterfaceConfiguration struct {
 	InterfaceTypeName string
 	ConcreteTypeNames []string
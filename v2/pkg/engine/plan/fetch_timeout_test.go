@@ -0,0 +1,86 @@
+package plan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astvalidation"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+func TestPlanner_FetchTimeout(t *testing.T) {
+	const definition = `
+		type Query {
+			users: [String]
+			report: String
+			health: String
+		}
+	`
+
+	newDataSource := func(t *testing.T, id string, metadata *DataSourceMetadata) DataSource {
+		t.Helper()
+		def := unsafeparser.ParseGraphqlDocumentString(definition)
+		ds, err := NewDataSourceConfiguration[any](id, &FakeFactory[any]{upstreamSchema: &def}, metadata, nil)
+		require.NoError(t, err)
+		return ds
+	}
+
+	planTimeouts := func(t *testing.T, operation string, fields FieldConfigurations) []time.Duration {
+		t.Helper()
+		def := unsafeparser.ParseGraphqlDocumentString(definition)
+		op := unsafeparser.ParseGraphqlDocumentString(operation)
+		require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+		report := &operationreport.Report{}
+		astnormalization.NewNormalizer(true, true).NormalizeOperation(&op, &def, report)
+		astvalidation.DefaultOperationValidator().Validate(&op, &def, report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		planner, err := NewPlanner(Configuration{
+			DisableResolveFieldPositions: true,
+			DisableIncludeInfo:           true,
+			Fields:                       fields,
+			DataSources: []DataSource{
+				newDataSource(t, "users", &DataSourceMetadata{
+					RootNodes:    []TypeField{{TypeName: "Query", FieldNames: []string{"users", "report"}}},
+					FetchTimeout: 2 * time.Second,
+				}),
+				newDataSource(t, "health", &DataSourceMetadata{
+					RootNodes: []TypeField{{TypeName: "Query", FieldNames: []string{"health"}}},
+				}),
+			},
+		})
+		require.NoError(t, err)
+		result := planner.Plan(&op, &def, "", report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		var timeouts []time.Duration
+		for _, fetch := range result.(*SynchronousResponsePlan).Response.Data.Fetches {
+			timeouts = append(timeouts, fetch.(*resolve.SingleFetch).Timeout)
+		}
+		return timeouts
+	}
+
+	reportTimeout := FieldConfigurations{
+		{TypeName: "Query", FieldName: "report", Timeout: 30 * time.Second},
+	}
+
+	t.Run("timeout of the datasource", func(t *testing.T) {
+		assert.Equal(t, []time.Duration{2 * time.Second}, planTimeouts(t, `{ users }`, reportTimeout))
+	})
+
+	t.Run("timeout of the root field overrides the timeout of the datasource", func(t *testing.T) {
+		assert.Equal(t, []time.Duration{30 * time.Second}, planTimeouts(t, `{ report }`, reportTimeout))
+		assert.Equal(t, []time.Duration{2 * time.Second, 30 * time.Second}, planTimeouts(t, `{ users report }`, reportTimeout))
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		assert.Equal(t, []time.Duration{0}, planTimeouts(t, `{ health }`, reportTimeout))
+	})
+}
@@ -23,6 +23,8 @@ type Planner struct {
 	planningVisitor       *Visitor
 
 	prepareOperationWalker *astvisitor.Walker
+
+	overrideLabels []string
 }
 
 // NewPlanner creates a new Planner from the Configuration
@@ -106,6 +108,7 @@ func (p *Planner) SetDebugConfig(config DebugConfiguration) {
 
 type _opts struct {
 	includeQueryPlanInResponse bool
+	overrideLabels             []string
 }
 
 type Opts func(*_opts)
@@ -116,6 +119,14 @@ func IncludeQueryPlanInResponse() Opts {
 	}
 }
 
+// WithOverrideLabels activates the progressive overrides with the given labels for the operation
+// Fields with an inactive override are planned on the subgraph they were migrated from
+func WithOverrideLabels(labels ...string) Opts {
+	return func(o *_opts) {
+		o.overrideLabels = labels
+	}
+}
+
 func (p *Planner) Plan(operation, definition *ast.Document, operationName string, report *operationreport.Report, options ...Opts) (plan Plan) {
 
 	var opts _opts
@@ -124,6 +135,7 @@ func (p *Planner) Plan(operation, definition *ast.Document, operationName string
 	}
 
 	p.planningVisitor.includeQueryPlans = opts.includeQueryPlanInResponse
+	p.overrideLabels = opts.overrideLabels
 
 	p.selectOperation(operation, operationName, report)
 	if report.HasErrors() {
@@ -216,6 +228,7 @@ func (p *Planner) findPlanningPaths(operation, definition *ast.Document, report
 func (p *Planner) selectNodes(operation, definition *ast.Document, report *operationreport.Report) {
 	resolvableWalker := astvisitor.NewWalker(32)
 	dsFilter := NewDataSourceFilter(operation, definition, report)
+	dsFilter.SetOverrideLabels(p.overrideLabels)
 
 	if p.config.Debug.NodeSuggestion.SelectionReasons {
 		dsFilter.EnableSelectionReasons()
@@ -142,6 +142,63 @@ func TestPlanner_Plan(t *testing.T) {
 		DataSources:                  []DataSource{testDefinitionDSConfiguration},
 	}))
 
+	t.Run("Fragment on the concrete type of the enclosing object keeps its type condition", test(testDefinition, `
+		query Droid($withFunction: Boolean!) {
+			droid(id: "R2D2") {
+				name
+				... on Droid @include(if: $withFunction) {
+					primaryFunction
+				}
+			}
+		}
+	`, "Droid", &SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Nullable: false,
+				Fields: []*resolve.Field{
+					{
+						Name: []byte("droid"),
+						Value: &resolve.Object{
+							Path:          []string{"droid"},
+							Nullable:      true,
+							TypeName:      "Droid",
+							PossibleTypes: map[string]struct{}{"Droid": {}},
+							Fields: []*resolve.Field{
+								{
+									Name: []byte("name"),
+									Value: &resolve.String{
+										Path:     []string{"name"},
+										Nullable: false,
+									},
+								},
+								{
+									Name: []byte("primaryFunction"),
+									Value: &resolve.String{
+										Path:     []string{"primaryFunction"},
+										Nullable: false,
+									},
+									OnTypeNames: [][]byte{[]byte("Droid")},
+								},
+							},
+						},
+					},
+				},
+				Fetches: []resolve.Fetch{
+					&resolve.SingleFetch{
+						FetchConfiguration: resolve.FetchConfiguration{
+							DataSource: &FakeDataSource{&StatefulSource{}},
+						},
+						DataSourceIdentifier: []byte("plan.FakeDataSource"),
+					},
+				},
+			},
+		},
+	}, Configuration{
+		DisableResolveFieldPositions: true,
+		DisableIncludeInfo:           true,
+		DataSources:                  []DataSource{testDefinitionDSConfiguration},
+	}))
+
 	t.Run("Merging duplicate fields in response should not happen", func(t *testing.T) {
 		t.Run("Interface response type with type fragments and shared field", test(testDefinition, `
 			query Hero {
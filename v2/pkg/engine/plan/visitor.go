@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/astimport"
@@ -46,6 +47,13 @@ type Visitor struct {
 	includeQueryPlans            bool
 	indirectInterfaceFields      map[int]indirectInterfaceField
 	pathCache                    map[astvisitor.VisitorKind]map[int]string
+	deferredFragments            map[ast.Node]*resolve.DeferField
+	initialFetchIDs              map[int]struct{}
+	deferredFetchIDs             map[*resolve.DeferField]map[int]struct{}
+	streamedFields               map[int]*resolve.StreamField
+	streamedFetchIDs             map[*resolve.StreamField]map[int]struct{}
+	cacheControlEnabled          bool
+	cacheControlPolicies         map[int]resolve.CacheControlPolicy
 }
 
 type indirectInterfaceField struct {
@@ -242,21 +250,6 @@ func (v *Visitor) EnterDirective(ref int) {
 				}
 			}
 		}
-	case ast.NodeKindField:
-		switch directiveName {
-		case "stream":
-			initialBatchSize := 0
-			if value, ok := v.Operation.DirectiveArgumentValueByName(ref, literal.INITIAL_BATCH_SIZE); ok {
-				if value.Kind == ast.ValueKindInteger {
-					initialBatchSize = int(v.Operation.IntValueAsInt32(value.Ref))
-				}
-			}
-			v.currentField.Stream = &resolve.StreamField{
-				InitialBatchSize: initialBatchSize,
-			}
-		case "defer":
-			v.currentField.Defer = &resolve.DeferField{}
-		}
 	}
 }
 
@@ -327,12 +320,16 @@ func (v *Visitor) EnterField(ref int) {
 	fieldDefinitionTypeRef := v.Definition.FieldDefinitionType(fieldDefinition)
 
 	onTypeNames := v.resolveOnTypeNames(ref)
+	fieldDefer, enclosingDefer := v.resolveDefer(ref)
+	v.trackFetchUsage(ref, enclosingDefer, v.resolveEnclosingStream())
 
 	v.currentField = &resolve.Field{
-		Name:        fieldAliasOrName,
-		OnTypeNames: onTypeNames,
-		Position:    v.resolveFieldPosition(ref),
-		Info:        v.resolveFieldInfo(ref, fieldDefinitionTypeRef, onTypeNames),
+		Name:         fieldAliasOrName,
+		OnTypeNames:  onTypeNames,
+		Position:     v.resolveFieldPosition(ref),
+		Info:         v.resolveFieldInfo(ref, fieldDefinitionTypeRef, onTypeNames),
+		Defer:        fieldDefer,
+		CacheControl: v.resolveCacheControl(ref, fieldDefinition),
 	}
 
 	if bytes.Equal(fieldName, literal.TYPENAME) {
@@ -345,6 +342,9 @@ func (v *Visitor) EnterField(ref int) {
 	} else {
 		path := v.resolveFieldPath(ref)
 		v.currentField.Value = v.resolveFieldValue(ref, fieldDefinitionTypeRef, true, path)
+		if v.currentField.Value.NodeKind() == resolve.NodeKindArray {
+			v.currentField.Stream = v.streamedField(ref)
+		}
 	}
 
 	// append the field to the current object
@@ -463,6 +463,134 @@ func (v *Visitor) resolveFieldPosition(ref int) resolve.Position {
 	}
 }
 
+// resolveDefer returns the deferred fragment the field is directly part of
+// and the innermost deferred fragment which encloses the field, e.g. a deferred parent field
+func (v *Visitor) resolveDefer(fieldRef int) (fieldDefer, enclosingDefer *resolve.DeferField) {
+	if fieldDefer = v.deferredFragment(ast.Node{Kind: ast.NodeKindField, Ref: fieldRef}); fieldDefer != nil {
+		return fieldDefer, fieldDefer
+	}
+	isDirectChild := true
+	for i := len(v.Walker.Ancestors) - 1; i >= 0; i-- {
+		ancestor := v.Walker.Ancestors[i]
+		if deferred := v.deferredFragment(ancestor); deferred != nil {
+			if isDirectChild {
+				return deferred, deferred
+			}
+			return nil, deferred
+		}
+		if ancestor.Kind == ast.NodeKindField {
+			isDirectChild = false
+		}
+	}
+	return nil, nil
+}
+
+// deferredFragment returns the DeferField for a field or inline fragment annotated with @defer
+// All fields of the same fragment share the same DeferField
+// @defer(if: false) disables deferring, a variable value for "if" is treated as true,
+// because the plan is cached independent of variables
+func (v *Visitor) deferredFragment(node ast.Node) *resolve.DeferField {
+	var directives []int
+	switch node.Kind {
+	case ast.NodeKindField:
+		directives = v.Operation.Fields[node.Ref].Directives.Refs
+	case ast.NodeKindInlineFragment:
+		directives = v.Operation.InlineFragments[node.Ref].Directives.Refs
+	default:
+		return nil
+	}
+	if deferred, ok := v.deferredFragments[node]; ok {
+		return deferred
+	}
+	for _, ref := range directives {
+		if !bytes.Equal(v.Operation.DirectiveNameBytes(ref), literal.DEFER) {
+			continue
+		}
+		if value, ok := v.Operation.DirectiveArgumentValueByName(ref, literal.IF); ok && value.Kind == ast.ValueKindBoolean {
+			if !v.Operation.BooleanValue(value.Ref) {
+				return nil
+			}
+		}
+		deferred := &resolve.DeferField{}
+		if value, ok := v.Operation.DirectiveArgumentValueByName(ref, literal.LABEL); ok && value.Kind == ast.ValueKindString {
+			deferred.Label = v.Operation.StringValueContentString(value.Ref)
+		}
+		v.deferredFragments[node] = deferred
+		return deferred
+	}
+	return nil
+}
+
+// streamedField returns the StreamField for a list field annotated with @stream
+// @stream(if: false) disables streaming, a variable value for "if" is treated as true
+// initialBatchSize is supported as an alias for initialCount
+func (v *Visitor) streamedField(fieldRef int) *resolve.StreamField {
+	for _, ref := range v.Operation.Fields[fieldRef].Directives.Refs {
+		if !bytes.Equal(v.Operation.DirectiveNameBytes(ref), literal.STREAM) {
+			continue
+		}
+		if value, ok := v.Operation.DirectiveArgumentValueByName(ref, literal.IF); ok && value.Kind == ast.ValueKindBoolean {
+			if !v.Operation.BooleanValue(value.Ref) {
+				return nil
+			}
+		}
+		stream := &resolve.StreamField{}
+		for _, argName := range [][]byte{literal.INITIAL_COUNT, literal.INITIAL_BATCH_SIZE} {
+			if value, ok := v.Operation.DirectiveArgumentValueByName(ref, argName); ok && value.Kind == ast.ValueKindInteger {
+				stream.InitialBatchSize = max(int(v.Operation.IntValueAsInt32(value.Ref)), 0)
+				break
+			}
+		}
+		if value, ok := v.Operation.DirectiveArgumentValueByName(ref, literal.LABEL); ok && value.Kind == ast.ValueKindString {
+			stream.Label = v.Operation.StringValueContentString(value.Ref)
+		}
+		v.streamedFields[fieldRef] = stream
+		return stream
+	}
+	return nil
+}
+
+// resolveEnclosingStream returns the innermost streamed list field which encloses the current field
+// Only streams without initial items are returned, otherwise the initial items require the same fetches
+func (v *Visitor) resolveEnclosingStream() *resolve.StreamField {
+	for i := len(v.Walker.Ancestors) - 1; i >= 0; i-- {
+		ancestor := v.Walker.Ancestors[i]
+		if ancestor.Kind != ast.NodeKindField {
+			continue
+		}
+		if stream, ok := v.streamedFields[ancestor.Ref]; ok && stream.InitialBatchSize == 0 {
+			return stream
+		}
+	}
+	return nil
+}
+
+// trackFetchUsage records which fetches are required by the initial response
+// and which are only required by deferred fragments or streamed list items
+// Deferred fragments render nested streams inline, so the enclosing defer takes precedence
+func (v *Visitor) trackFetchUsage(fieldRef int, enclosingDefer *resolve.DeferField, enclosingStream *resolve.StreamField) {
+	for i := range v.planners {
+		if !v.planners[i].HasPathWithFieldRef(fieldRef) {
+			continue
+		}
+		fetchID := v.planners[i].ObjectFetchConfiguration().fetchID
+		switch {
+		case enclosingDefer != nil:
+			if v.deferredFetchIDs[enclosingDefer] == nil {
+				v.deferredFetchIDs[enclosingDefer] = map[int]struct{}{}
+			}
+			v.deferredFetchIDs[enclosingDefer][fetchID] = struct{}{}
+		case enclosingStream != nil:
+			if v.streamedFetchIDs[enclosingStream] == nil {
+				v.streamedFetchIDs[enclosingStream] = map[int]struct{}{}
+			}
+			v.streamedFetchIDs[enclosingStream][fetchID] = struct{}{}
+		default:
+			v.initialFetchIDs[fetchID] = struct{}{}
+		}
+	}
+}
+
 func (v *Visitor) resolveSkipIncludeOnParent() (info skipIncludeInfo, ok bool) {
 	if len(v.skipIncludeOnFragments) == 0 {
 		return skipIncludeInfo{}, false
@@ -493,6 +621,13 @@ func (v *Visitor) resolveOnTypeNames(fieldRef int) [][]byte {
 	if typeName == nil {
 		typeName = v.Walker.EnclosingTypeDefinition.NameBytes(v.Definition)
 	}
+	if _, isDeferred := v.Operation.DirectiveWithNameBytes(v.Operation.InlineFragments[inlineFragment.Ref].Directives.Refs, literal.DEFER); isDeferred && len(v.Walker.TypeDefinitions) > 1 {
+		parent := v.Walker.TypeDefinitions[len(v.Walker.TypeDefinitions)-2]
+		if parent.Kind == ast.NodeKindObjectTypeDefinition && bytes.Equal(parent.NameBytes(v.Definition), typeName) {
+			// deferred fragments on the concrete type of the enclosing object always apply
+			return nil
+		}
+	}
 	node, exists := v.Definition.NodeByName(typeName)
 	// If not an interface, return the concrete type
 	if !exists || !node.Kind.IsAbstractType() {
@@ -925,6 +1060,13 @@ func (v *Visitor) EnterDocument(operation, definition *ast.Document) {
 	v.skipIncludeOnFragments = map[int]skipIncludeInfo{}
 	v.indirectInterfaceFields = map[int]indirectInterfaceField{}
 	v.pathCache = map[astvisitor.VisitorKind]map[int]string{}
+	v.deferredFragments = map[ast.Node]*resolve.DeferField{}
+	v.initialFetchIDs = map[int]struct{}{}
+	v.deferredFetchIDs = map[*resolve.DeferField]map[int]struct{}{}
+	v.streamedFields = map[int]*resolve.StreamField{}
+	v.streamedFetchIDs = map[*resolve.StreamField]map[int]struct{}{}
+	v.cacheControlEnabled = v.hasCacheControlHints()
+	v.cacheControlPolicies = map[int]resolve.CacheControlPolicy{}
 }
 
 func (v *Visitor) LeaveDocument(_, _ *ast.Document) {
@@ -935,6 +1077,31 @@ func (v *Visitor) LeaveDocument(_, _ *ast.Document) {
 			v.configureObjectFetch(v.planners[i].ObjectFetchConfiguration())
 		}
 	}
+	v.configureIncrementalFetchIDs()
+}
+
+// configureIncrementalFetchIDs assigns the fetches which are not required by the initial response
+// to the streamed lists and deferred fragments
+// A fetch can be required by multiple streams or fragments, postprocessing assigns it to the first one which is resolved
+func (v *Visitor) configureIncrementalFetchIDs() {
+	for stream, fetchIDs := range v.streamedFetchIDs {
+		stream.FetchIDs = v.fetchIDsWithoutInitial(fetchIDs)
+	}
+	for deferred, fetchIDs := range v.deferredFetchIDs {
+		deferred.FetchIDs = v.fetchIDsWithoutInitial(fetchIDs)
+	}
+}
+
+func (v *Visitor) fetchIDsWithoutInitial(fetchIDs map[int]struct{}) []int {
+	out := make([]int, 0, len(fetchIDs))
+	for fetchID := range fetchIDs {
+		if _, ok := v.initialFetchIDs[fetchID]; ok {
+			continue
+		}
+		out = append(out, fetchID)
+	}
+	slices.Sort(out)
+	return out
 }
 
 var (
@@ -1162,6 +1329,22 @@ func (v *Visitor) configureObjectFetch(config *objectFetchConfiguration) {
 	config.object.Fetches = append(config.object.Fetches, fetch)
 }
 
+// fetchTimeout returns the longest timeout configured for the root fields of the fetch,
+// or the timeout of the DataSource when none of the root fields has a timeout
+func (v *Visitor) fetchTimeout(internal *objectFetchConfiguration) time.Duration {
+	var timeout time.Duration
+	for _, rootField := range internal.rootFields {
+		fieldConfig := v.Config.Fields.ForTypeField(rootField.TypeName, rootField.FieldName)
+		if fieldConfig != nil && fieldConfig.Timeout > timeout {
+			timeout = fieldConfig.Timeout
+		}
+	}
+	if timeout == 0 {
+		return internal.timeout
+	}
+	return timeout
+}
+
 func (v *Visitor) configureFetch(internal *objectFetchConfiguration, external resolve.FetchConfiguration) *resolve.SingleFetch {
 	dataSourceType := reflect.TypeOf(external.DataSource).String()
 	dataSourceType = strings.TrimPrefix(dataSourceType, "*")
@@ -1175,6 +1358,14 @@ func (v *Visitor) configureFetch(internal *objectFetchConfiguration, external re
 		DataSourceIdentifier: []byte(dataSourceType),
 	}
 
+	if external.RequiresEntityFetch || external.RequiresEntityBatchFetch {
+		singleFetch.EntityCache = internal.entityCache
+	}
+
+	if singleFetch.Timeout == 0 {
+		singleFetch.Timeout = v.fetchTimeout(internal)
+	}
+
 	if !v.Config.DisableIncludeInfo {
 		singleFetch.Info = &resolve.FetchInfo{
 			DataSourceID:   internal.sourceID,
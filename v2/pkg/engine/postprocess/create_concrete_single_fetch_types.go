@@ -106,6 +106,8 @@ func (d *createConcreteSingleFetchTypes) createEntityBatchFetch(fetch *resolve.S
 		},
 		DataSource:     fetch.DataSource,
 		PostProcessing: fetch.PostProcessing,
+		Cache:          fetch.EntityCache,
+		Timeout:        fetch.Timeout,
 	}
 }
 
@@ -139,5 +141,7 @@ func (d *createConcreteSingleFetchTypes) createEntityFetch(fetch *resolve.Single
 		},
 		DataSource:     fetch.DataSource,
 		PostProcessing: fetch.PostProcessing,
+		Cache:          fetch.EntityCache,
+		Timeout:        fetch.Timeout,
 	}
 }
@@ -0,0 +1,186 @@
+package postprocess
+
+import (
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+// createIncrementalDelivery collects the fields of all @defer fragments into DeferredFragments
+// and moves the fetches which are only required by a deferred fragment or by streamed list items out of the initial fetch tree
+type createIncrementalDelivery struct {
+	fragments        []*resolve.DeferredFragment
+	fragmentByDefer  map[*resolve.DeferField]*resolve.DeferredFragment
+	streamFetchIDs   []int
+	hasStreams       bool
+	currentFetchPath []resolve.FetchItemPathElement
+}
+
+const (
+	initialFetchGroup = iota
+	streamedFetchGroup
+	// deferredFetchGroup is the group of the first deferred fragment, the following fragments use consecutive groups
+	deferredFetchGroup
+)
+
+func (c *createIncrementalDelivery) Process(res *resolve.GraphQLResponse) {
+	c.fragments = nil
+	c.fragmentByDefer = map[*resolve.DeferField]*resolve.DeferredFragment{}
+	c.streamFetchIDs = nil
+	c.hasStreams = false
+	c.currentFetchPath = c.currentFetchPath[:0]
+
+	c.traverseNode(res.Data)
+	if len(c.fragments) == 0 && !c.hasStreams {
+		return
+	}
+	res.DeferredFragments = c.fragments
+	res.HasStreamedFields = c.hasStreams
+
+	if res.Fetches == nil {
+		return
+	}
+	groups := c.partitionFetches(res.Fetches.ChildNodes)
+	res.Fetches.ChildNodes = groups[initialFetchGroup]
+	if len(groups[streamedFetchGroup]) != 0 {
+		res.StreamedFetches = &resolve.FetchTreeNode{
+			Kind:       resolve.FetchTreeNodeKindSequence,
+			ChildNodes: groups[streamedFetchGroup],
+		}
+	}
+	for i := range c.fragments {
+		c.fragments[i].Fetches = &resolve.FetchTreeNode{
+			Kind:       resolve.FetchTreeNodeKindSequence,
+			ChildNodes: groups[deferredFetchGroup+i],
+		}
+	}
+}
+
+func (c *createIncrementalDelivery) traverseNode(node resolve.Node) {
+	switch n := node.(type) {
+	case *resolve.Object:
+		hasPath := len(n.Path) > 0
+		if hasPath {
+			c.currentFetchPath = append(c.currentFetchPath, resolve.FetchItemPathElement{
+				Kind: resolve.FetchItemPathElementKindObject,
+				Path: n.Path,
+			})
+		}
+		for i := range n.Fields {
+			if n.Fields[i].Defer != nil {
+				fragment := c.fragment(n, n.Fields[i].Defer)
+				fragment.Data.Fields = append(fragment.Data.Fields, n.Fields[i])
+			}
+			if n.Fields[i].Stream != nil {
+				c.hasStreams = true
+				c.streamFetchIDs = append(c.streamFetchIDs, n.Fields[i].Stream.FetchIDs...)
+			}
+			c.traverseNode(n.Fields[i].Value)
+		}
+		if hasPath {
+			c.currentFetchPath = c.currentFetchPath[:len(c.currentFetchPath)-1]
+		}
+	case *resolve.Array:
+		c.currentFetchPath = append(c.currentFetchPath, resolve.FetchItemPathElement{
+			Kind: resolve.FetchItemPathElementKindArray,
+			Path: n.Path,
+		})
+		c.traverseNode(n.Item)
+		c.currentFetchPath = c.currentFetchPath[:len(c.currentFetchPath)-1]
+	}
+}
+
+// fragment returns the fragment of a DeferField, the fragment is created on first use
+// As the response tree is walked depth first, outer fragments are created before nested fragments
+func (c *createIncrementalDelivery) fragment(parent *resolve.Object, deferField *resolve.DeferField) *resolve.DeferredFragment {
+	if fragment, ok := c.fragmentByDefer[deferField]; ok {
+		return fragment
+	}
+	path := make([]resolve.FetchItemPathElement, len(c.currentFetchPath))
+	copy(path, c.currentFetchPath)
+	fragment := &resolve.DeferredFragment{
+		Defer: deferField,
+		Path:  path,
+		Data: &resolve.Object{
+			Nullable:      parent.Nullable,
+			TypeName:      parent.TypeName,
+			PossibleTypes: parent.PossibleTypes,
+			SourceName:    parent.SourceName,
+		},
+	}
+	c.fragmentByDefer[deferField] = fragment
+	c.fragments = append(c.fragments, fragment)
+	return fragment
+}
+
+// partitionFetches splits the fetches into the initial fetches, the fetches of the streamed items and the fetches of each fragment
+// Groups are loaded in this order, so a fetch belongs to the first group which requires it
+// When a fetch depends on a fetch of a later group, the dependency is moved into the group of the fetch
+func (c *createIncrementalDelivery) partitionFetches(nodes []*resolve.FetchTreeNode) [][]*resolve.FetchTreeNode {
+	groupByFetchID := make(map[int]int, len(nodes))
+	for i := len(c.fragments) - 1; i >= 0; i-- {
+		for _, fetchID := range c.fragments[i].Defer.FetchIDs {
+			groupByFetchID[fetchID] = deferredFetchGroup + i
+		}
+	}
+	for _, fetchID := range c.streamFetchIDs {
+		groupByFetchID[fetchID] = streamedFetchGroup
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, node := range nodes {
+			deps := c.dependencies(node)
+			if deps == nil {
+				continue
+			}
+			group := groupByFetchID[deps.FetchID]
+			for _, dependsOn := range deps.DependsOnFetchIDs {
+				if groupByFetchID[dependsOn] > group {
+					groupByFetchID[dependsOn] = group
+					changed = true
+				}
+			}
+		}
+	}
+
+	groups := make([][]*resolve.FetchTreeNode, deferredFetchGroup+len(c.fragments))
+	for _, node := range nodes {
+		deps := c.dependencies(node)
+		if deps == nil {
+			// nodes without a fetch can't be deferred
+			groups[initialFetchGroup] = append(groups[initialFetchGroup], node)
+			continue
+		}
+		group := groupByFetchID[deps.FetchID]
+		if group != initialFetchGroup {
+			// dependencies on fetches of previous groups are already resolved when the group is loaded
+			var dependsOn []int
+			for _, fetchID := range deps.DependsOnFetchIDs {
+				if groupByFetchID[fetchID] == group {
+					dependsOn = append(dependsOn, fetchID)
+				}
+			}
+			deps.DependsOnFetchIDs = dependsOn
+		}
+		groups[group] = append(groups[group], node)
+	}
+	return groups
+}
+
+// dependencies returns the dependencies of the fetch of the node, it's nil for nodes without a fetch
+func (c *createIncrementalDelivery) dependencies(node *resolve.FetchTreeNode) *resolve.FetchDependencies {
+	if node.Item == nil {
+		return nil
+	}
+	switch fetch := node.Item.Fetch.(type) {
+	case *resolve.SingleFetch:
+		return &fetch.FetchDependencies
+	case *resolve.EntityFetch:
+		return &fetch.FetchDependencies
+	case *resolve.BatchEntityFetch:
+		return &fetch.FetchDependencies
+	case *resolve.ParallelListItemFetch:
+		return &fetch.Fetch.FetchDependencies
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,191 @@
+package postprocess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+func TestCreateIncrementalDelivery_Process(t *testing.T) {
+	fetch := func(fetchID int, dependsOn ...int) *resolve.FetchTreeNode {
+		return &resolve.FetchTreeNode{
+			Kind: resolve.FetchTreeNodeKindSingle,
+			Item: &resolve.FetchItem{
+				Fetch: &resolve.SingleFetch{
+					FetchDependencies: resolve.FetchDependencies{
+						FetchID:           fetchID,
+						DependsOnFetchIDs: dependsOn,
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("no deferred fields", func(t *testing.T) {
+		res := &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fields: []*resolve.Field{
+					{Name: []byte("a"), Value: &resolve.String{Path: []string{"a"}}},
+				},
+			},
+			Fetches: &resolve.FetchTreeNode{
+				Kind:       resolve.FetchTreeNodeKindSequence,
+				ChildNodes: []*resolve.FetchTreeNode{fetch(0)},
+			},
+		}
+
+		processor := &createIncrementalDelivery{}
+		processor.Process(res)
+
+		assert.Nil(t, res.DeferredFragments)
+		assert.Len(t, res.Fetches.ChildNodes, 1)
+	})
+
+	t.Run("nested fragments with fetches", func(t *testing.T) {
+		outer := &resolve.DeferField{Label: "outer", FetchIDs: []int{1, 2}}
+		inner := &resolve.DeferField{FetchIDs: []int{2, 3}}
+
+		name := &resolve.Field{Name: []byte("name"), Value: &resolve.String{Path: []string{"name"}}, Defer: outer}
+		reviews := &resolve.Field{Name: []byte("reviews"), Value: &resolve.Array{
+			Path: []string{"reviews"},
+			Item: &resolve.Object{
+				Fields: []*resolve.Field{
+					{Name: []byte("body"), Value: &resolve.String{Path: []string{"body"}}, Defer: inner},
+				},
+			},
+		}, Defer: outer}
+
+		res := &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fields: []*resolve.Field{
+					{
+						Name: []byte("user"),
+						Value: &resolve.Object{
+							Path:     []string{"user"},
+							TypeName: "User",
+							Fields: []*resolve.Field{
+								{Name: []byte("id"), Value: &resolve.String{Path: []string{"id"}}},
+								name,
+								reviews,
+							},
+						},
+					},
+				},
+			},
+			Fetches: &resolve.FetchTreeNode{
+				Kind:       resolve.FetchTreeNodeKindSequence,
+				ChildNodes: []*resolve.FetchTreeNode{fetch(0), fetch(1, 0), fetch(2, 0), fetch(3, 2), fetch(4, 3)},
+			},
+		}
+
+		processor := &createIncrementalDelivery{}
+		processor.Process(res)
+
+		if !assert.Len(t, res.DeferredFragments, 2) {
+			return
+		}
+
+		// fetch 4 is part of the initial response and depends on fetch 3, which depends on fetch 2
+		// so both dependencies have to be moved into the initial fetch tree
+		assert.Equal(t, []*resolve.FetchTreeNode{fetch(0), fetch(2, 0), fetch(3, 2), fetch(4, 3)}, res.Fetches.ChildNodes)
+
+		assert.Equal(t, &resolve.DeferredFragment{
+			Defer: outer,
+			Path:  []resolve.FetchItemPathElement{{Kind: resolve.FetchItemPathElementKindObject, Path: []string{"user"}}},
+			Data: &resolve.Object{
+				TypeName: "User",
+				Fields:   []*resolve.Field{name, reviews},
+			},
+			Fetches: &resolve.FetchTreeNode{
+				Kind:       resolve.FetchTreeNodeKindSequence,
+				ChildNodes: []*resolve.FetchTreeNode{fetch(1)},
+			},
+		}, res.DeferredFragments[0])
+
+		assert.Equal(t, inner, res.DeferredFragments[1].Defer)
+		assert.Equal(t, []resolve.FetchItemPathElement{
+			{Kind: resolve.FetchItemPathElementKindObject, Path: []string{"user"}},
+			{Kind: resolve.FetchItemPathElementKindArray, Path: []string{"reviews"}},
+		}, res.DeferredFragments[1].Path)
+		assert.Len(t, res.DeferredFragments[1].Data.Fields, 1)
+		assert.Len(t, res.DeferredFragments[1].Fetches.ChildNodes, 0)
+	})
+
+	t.Run("streamed list with entity fetch", func(t *testing.T) {
+		stream := &resolve.StreamField{FetchIDs: []int{1}}
+
+		res := &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fields: []*resolve.Field{
+					{
+						Name: []byte("users"),
+						Value: &resolve.Array{
+							Path: []string{"users"},
+							Item: &resolve.Object{
+								Fields: []*resolve.Field{
+									{Name: []byte("name"), Value: &resolve.String{Path: []string{"name"}}},
+								},
+							},
+						},
+						Stream: stream,
+					},
+				},
+			},
+			Fetches: &resolve.FetchTreeNode{
+				Kind:       resolve.FetchTreeNodeKindSequence,
+				ChildNodes: []*resolve.FetchTreeNode{fetch(0), fetch(1, 0)},
+			},
+		}
+
+		processor := &createIncrementalDelivery{}
+		processor.Process(res)
+
+		assert.True(t, res.HasStreamedFields)
+		assert.Nil(t, res.DeferredFragments)
+		assert.Equal(t, []*resolve.FetchTreeNode{fetch(0)}, res.Fetches.ChildNodes)
+		assert.Equal(t, &resolve.FetchTreeNode{
+			Kind:       resolve.FetchTreeNodeKindSequence,
+			ChildNodes: []*resolve.FetchTreeNode{fetch(1)},
+		}, res.StreamedFetches)
+	})
+
+	t.Run("fragment with entity fetches", func(t *testing.T) {
+		deferField := &resolve.DeferField{FetchIDs: []int{1, 2}}
+		entityFetch := &resolve.FetchTreeNode{
+			Kind: resolve.FetchTreeNodeKindSingle,
+			Item: &resolve.FetchItem{
+				Fetch: &resolve.EntityFetch{FetchDependencies: resolve.FetchDependencies{FetchID: 1, DependsOnFetchIDs: []int{0}}},
+			},
+		}
+		batchEntityFetch := &resolve.FetchTreeNode{
+			Kind: resolve.FetchTreeNodeKindSingle,
+			Item: &resolve.FetchItem{
+				Fetch: &resolve.BatchEntityFetch{FetchDependencies: resolve.FetchDependencies{FetchID: 2, DependsOnFetchIDs: []int{0, 1}}},
+			},
+		}
+
+		res := &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fields: []*resolve.Field{
+					{Name: []byte("name"), Value: &resolve.String{Path: []string{"name"}}, Defer: deferField},
+				},
+			},
+			Fetches: &resolve.FetchTreeNode{
+				Kind:       resolve.FetchTreeNodeKindSequence,
+				ChildNodes: []*resolve.FetchTreeNode{fetch(0), entityFetch, batchEntityFetch},
+			},
+		}
+
+		processor := &createIncrementalDelivery{}
+		processor.Process(res)
+
+		if !assert.Len(t, res.DeferredFragments, 1) {
+			return
+		}
+		assert.Equal(t, []*resolve.FetchTreeNode{fetch(0)}, res.Fetches.ChildNodes)
+		assert.Equal(t, []*resolve.FetchTreeNode{entityFetch, batchEntityFetch}, res.DeferredFragments[0].Fetches.ChildNodes)
+		// the dependency on the initial fetch is resolved before the fragment is loaded
+		assert.Equal(t, []int{1}, batchEntityFetch.Item.Fetch.Dependencies().DependsOnFetchIDs)
+	})
+}
@@ -39,11 +39,14 @@ func (m *mergeFields) traverseNode(node resolve.Node) {
 				n.Fields[i].OnTypeNames = [][]byte{n.Fields[i].OnTypeNames[0]}
 				for j := 0; j < len(additionalTypeNames); j++ {
 					additionalField := &resolve.Field{
-						Name:        n.Fields[i].Name,
-						Value:       n.Fields[i].Value.Copy(),
-						Position:    n.Fields[i].Position,
-						OnTypeNames: [][]byte{additionalTypeNames[j]},
-						Info:        n.Fields[i].Info,
+						Name:         n.Fields[i].Name,
+						Value:        n.Fields[i].Value.Copy(),
+						Position:     n.Fields[i].Position,
+						Defer:        n.Fields[i].Defer,
+						Stream:       n.Fields[i].Stream,
+						CacheControl: n.Fields[i].CacheControl,
+						OnTypeNames:  [][]byte{additionalTypeNames[j]},
+						Info:         n.Fields[i].Info,
 					}
 					n.Fields = append(n.Fields[:i+1], append([]*resolve.Field{additionalField}, n.Fields[i+1:]...)...)
 				}
@@ -134,6 +137,8 @@ func (m *mergeFields) canMergeScalars(left, right *resolve.Field) bool {
 }
 
 func (m *mergeFields) mergeScalars(left, right *resolve.Field) {
+	m.mergeDefer(left, right)
+	m.mergeCacheControl(left, right)
 	// when left has no type conditions, it will overwrite right
 	if left.OnTypeNames == nil && left.ParentOnTypeNames == nil {
 		return
@@ -238,6 +243,8 @@ func (m *mergeFields) sameParentOnTypeNames(left, right *resolve.Field) bool {
 }
 
 func (m *mergeFields) mergeValues(left, right *resolve.Field) {
+	m.mergeDefer(left, right)
+	m.mergeCacheControl(left, right)
 	switch l := left.Value.(type) {
 	case *resolve.Object:
 		r := right.Value.(*resolve.Object)
@@ -253,6 +260,29 @@ func (m *mergeFields) mergeValues(left, right *resolve.Field) {
 	}
 }
 
+// mergeDefer keeps the field deferred only when both fields are deferred
+// a field which is also selected without @defer has to be part of the initial response
+// when both fields are part of different fragments, the field is resolved with the fragment of the left field
+func (m *mergeFields) mergeDefer(left, right *resolve.Field) {
+	if right.Defer == nil {
+		left.Defer = nil
+	}
+}
+
+// mergeCacheControl restricts the cache hint of the left field with the hint of the right field
+// the policy is copied because the hints can be shared between fields
+func (m *mergeFields) mergeCacheControl(left, right *resolve.Field) {
+	if right.CacheControl == nil {
+		return
+	}
+	policy := *right.CacheControl
+	if left.CacheControl != nil {
+		policy = *left.CacheControl
+		policy.Restrict(right.CacheControl)
+	}
+	left.CacheControl = &policy
+}
+
 func (m *mergeFields) nodeIsScalar(node resolve.Node) bool {
 	switch node.(type) {
 	case *resolve.Object, *resolve.Array:
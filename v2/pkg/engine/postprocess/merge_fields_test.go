@@ -2,6 +2,7 @@ package postprocess
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -273,4 +274,57 @@ func TestMergeFields_Process(t *testing.T) {
 			},
 		},
 	))
+
+	t.Run("merge fields restricts the cache hints", runTest(
+		&resolve.Object{
+			Fields: []*resolve.Field{
+				{
+					Name:         []byte(`a`),
+					Value:        &resolve.Integer{},
+					CacheControl: &resolve.CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true},
+				},
+				{
+					Name:         []byte(`a`),
+					Value:        &resolve.Integer{},
+					CacheControl: &resolve.CacheControlPolicy{MaxAge: 2 * time.Minute, HasMaxAge: true, Scope: resolve.CacheControlScopePrivate},
+					OnTypeNames: [][]byte{
+						[]byte(`A`),
+					},
+				},
+				{
+					Name:         []byte(`b`),
+					Value:        &resolve.Integer{},
+					CacheControl: &resolve.CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true},
+					OnTypeNames: [][]byte{
+						[]byte(`A`), []byte(`B`),
+					},
+				},
+			},
+		},
+		&resolve.Object{
+			Fields: []*resolve.Field{
+				{
+					Name:         []byte(`a`),
+					Value:        &resolve.Integer{},
+					CacheControl: &resolve.CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true, Scope: resolve.CacheControlScopePrivate},
+				},
+				{
+					Name:         []byte(`b`),
+					Value:        &resolve.Integer{},
+					CacheControl: &resolve.CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true},
+					OnTypeNames: [][]byte{
+						[]byte(`A`),
+					},
+				},
+				{
+					Name:         []byte(`b`),
+					Value:        &resolve.Integer{},
+					CacheControl: &resolve.CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true},
+					OnTypeNames: [][]byte{
+						[]byte(`B`),
+					},
+				},
+			},
+		},
+	))
 }
@@ -23,6 +23,7 @@ type Processor struct {
 	collectDataSourceInfo bool
 	resolveInputTemplates *resolveInputTemplates
 	dedupe                *deduplicateSingleFetches
+	incrementalDelivery   *createIncrementalDelivery
 	processResponseTree   []ResponseTreeProcessor
 	processFetchTree      []FetchTreeProcessor
 }
@@ -103,6 +104,7 @@ func NewProcessor(options ...ProcessorOption) *Processor {
 		dedupe: &deduplicateSingleFetches{
 			disable: opts.disableDeduplicateSingleFetches,
 		},
+		incrementalDelivery: &createIncrementalDelivery{},
 		processFetchTree: []FetchTreeProcessor{
 			// this must go first, as we need to deduplicate fetches so that subsequent processors can work correctly
 			&addMissingNestedDependencies{
@@ -136,8 +138,15 @@ func (p *Processor) Process(pre plan.Plan) plan.Plan {
 		p.createFetchTree(t.Response)
 		p.dedupe.ProcessFetchTree(t.Response.Fetches)
 		p.resolveInputTemplates.ProcessFetchTree(t.Response.Fetches)
+		p.createIncrementalDelivery(t.Response)
 		for i := range p.processFetchTree {
 			p.processFetchTree[i].ProcessFetchTree(t.Response.Fetches)
+			if t.Response.StreamedFetches != nil {
+				p.processFetchTree[i].ProcessFetchTree(t.Response.StreamedFetches)
+			}
+			for _, fragment := range t.Response.DeferredFragments {
+				p.processFetchTree[i].ProcessFetchTree(fragment.Fetches)
+			}
 		}
 	case *plan.SubscriptionResponsePlan:
 		for i := range p.processResponseTree {
@@ -188,6 +197,15 @@ func (p *Processor) createFetchTree(res *resolve.GraphQLResponse) {
 	}
 }
 
+// createIncrementalDelivery must run after the fetch tree was created and deduplicated,
+// but before the fetch tree gets restructured, as it relies on the flat sequence of fetches
+func (p *Processor) createIncrementalDelivery(res *resolve.GraphQLResponse) {
+	if p.disableExtractFetches {
+		return
+	}
+	p.incrementalDelivery.Process(res)
+}
+
 func (p *Processor) appendTriggerToFetchTree(res *resolve.GraphQLSubscription) {
 	var input struct {
 		Body struct {
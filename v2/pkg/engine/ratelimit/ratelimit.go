@@ -0,0 +1,221 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+const (
+	defaultKeyPrefix = "graphql_rate_limit"
+
+	// maxSwapAttempts limits the attempts to update a key which is concurrently updated by other requests
+	maxSwapAttempts = 16
+
+	reasonRateLimitExceeded = "rate limit exceeded"
+)
+
+// ErrRateLimitExceeded is returned for fetches exceeding the limit when resolve.RateLimitOptions.RejectExceedingRequests is set,
+// it fails the whole request instead of skipping the fetch
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+type Options struct {
+	// Storage persists the limits, defaults to a MemoryStorage
+	Storage Storage
+	// KeyPrefix is prepended to resolve.RateLimitOptions.RateLimitKey, defaults to "graphql_rate_limit"
+	KeyPrefix string
+	// SubgraphCosts are the costs of a fetch to the subgraph by data source name. Fetches to other subgraphs cost 1.
+	SubgraphCosts map[string]int
+	// FieldCosts are added to the cost of a fetch for each of its root fields, keyed by Type.field, e.g. Query.search
+	FieldCosts map[string]int
+}
+
+// RateLimiter is a resolve.RateLimiter using the generic cell rate algorithm (GCRA), a token bucket without background refills.
+//
+// The limit is configured per request with resolve.RateLimitOptions: Rate fetches per Period with bursts of up to Burst fetches.
+// Each fetch of the request takes its cost from the bucket of the RateLimitKey, e.g. the client id.
+// Fetches exceeding the limit are skipped with a rate limit error, introspection fetches are not limited.
+//
+// To render the stats with resolve.RateLimitOptions.IncludeStatsInResponseExtension, the request context needs to be prepared with WithStats.
+type RateLimiter struct {
+	storage       Storage
+	keyPrefix     string
+	subgraphCosts map[string]int
+	fieldCosts    map[string]int
+	now           func() time.Time
+}
+
+func NewRateLimiter(options Options) *RateLimiter {
+	limiter := &RateLimiter{
+		storage:       options.Storage,
+		keyPrefix:     options.KeyPrefix,
+		subgraphCosts: options.SubgraphCosts,
+		fieldCosts:    options.FieldCosts,
+		now:           time.Now,
+	}
+	if limiter.storage == nil {
+		limiter.storage = NewMemoryStorage()
+	}
+	if limiter.keyPrefix == "" {
+		limiter.keyPrefix = defaultKeyPrefix
+	}
+	return limiter
+}
+
+// Stats are the rate limit stats of a request, rendered as extensions.rateLimit
+type Stats struct {
+	Key string `json:"key"`
+	// RequestRate is the total cost of the fetches of the request
+	RequestRate int `json:"requestRate"`
+	// Remaining is the cost which could still be taken after the last fetch
+	Remaining    int   `json:"remaining"`
+	RetryAfterMs int64 `json:"retryAfterMs"`
+	ResetAfterMs int64 `json:"resetAfterMs"`
+
+	mu sync.Mutex
+}
+
+type statsKey struct{}
+
+// WithStats prepares the request context to collect the rate limit stats,
+// use it with resolve.Context.WithContext before resolving the request
+func WithStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, statsKey{}, &Stats{})
+}
+
+// GetStats returns the stats collected for the request, nil if the context was not prepared with WithStats
+func GetStats(ctx context.Context) *Stats {
+	stats, _ := ctx.Value(statsKey{}).(*Stats)
+	return stats
+}
+
+type result struct {
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+	resetAfter time.Duration
+}
+
+func (l *RateLimiter) RateLimitPreFetch(ctx *resolve.Context, info *resolve.FetchInfo, input json.RawMessage) (*resolve.RateLimitDeny, error) {
+	if isIntrospection(info) {
+		return nil, nil
+	}
+	options := ctx.RateLimitOptions
+	if options.Rate <= 0 || options.Period <= 0 {
+		return nil, fmt.Errorf("invalid rate limit options: rate %d per %s", options.Rate, options.Period)
+	}
+	key := l.keyPrefix + ":" + options.RateLimitKey
+	cost := l.cost(info)
+
+	res, err := l.take(ctx.Context(), key, options, cost)
+	if err != nil {
+		return nil, err
+	}
+	if stats := GetStats(ctx.Context()); stats != nil {
+		stats.mu.Lock()
+		stats.Key = options.RateLimitKey
+		stats.RequestRate += cost
+		stats.Remaining = res.remaining
+		stats.RetryAfterMs = res.retryAfter.Milliseconds()
+		stats.ResetAfterMs = res.resetAfter.Milliseconds()
+		stats.mu.Unlock()
+	}
+	if res.allowed {
+		return nil, nil
+	}
+	if options.RejectExceedingRequests {
+		return nil, ErrRateLimitExceeded
+	}
+	return &resolve.RateLimitDeny{Reason: reasonRateLimitExceeded}, nil
+}
+
+func (l *RateLimiter) RenderResponseExtension(ctx *resolve.Context, out io.Writer) error {
+	stats := GetStats(ctx.Context())
+	if stats == nil {
+		stats = &Stats{}
+	}
+	stats.mu.Lock()
+	data, err := json.Marshal(stats)
+	stats.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// take takes the cost from the bucket of the key, see https://en.wikipedia.org/wiki/Generic_cell_rate_algorithm
+func (l *RateLimiter) take(ctx context.Context, key string, options resolve.RateLimitOptions, cost int) (result, error) {
+	burst := options.Burst
+	if burst <= 0 {
+		burst = options.Rate
+	}
+	emissionInterval := options.Period / time.Duration(options.Rate)
+	burstOffset := emissionInterval * time.Duration(burst)
+	increment := emissionInterval * time.Duration(cost)
+
+	for attempt := 0; attempt < maxSwapAttempts; attempt++ {
+		stored, err := l.storage.Get(ctx, key)
+		if err != nil {
+			return result{}, err
+		}
+		now := l.now()
+		tat := stored
+		if tat.Before(now) {
+			tat = now
+		}
+		newTat := tat.Add(increment)
+		diff := now.Sub(newTat.Add(-burstOffset))
+		if diff < 0 {
+			return result{
+				remaining:  max(int(now.Sub(tat.Add(-burstOffset))/emissionInterval), 0),
+				retryAfter: -diff,
+				resetAfter: tat.Sub(now),
+			}, nil
+		}
+		ttl := newTat.Sub(now)
+		swapped, err := l.storage.CompareAndSwap(ctx, key, stored, newTat, ttl)
+		if err != nil {
+			return result{}, err
+		}
+		if swapped {
+			return result{
+				allowed:    true,
+				remaining:  int(diff / emissionInterval),
+				resetAfter: ttl,
+			}, nil
+		}
+	}
+	return result{}, fmt.Errorf("rate limit key %s is updated concurrently too often", key)
+}
+
+// cost returns the cost of the subgraph plus the costs of the root fields of the fetch
+func (l *RateLimiter) cost(info *resolve.FetchInfo) int {
+	cost, ok := l.subgraphCosts[info.DataSourceName]
+	if !ok {
+		cost = 1
+	}
+	for _, field := range info.RootFields {
+		cost += l.fieldCosts[field.TypeName+"."+field.FieldName]
+	}
+	return cost
+}
+
+func isIntrospection(info *resolve.FetchInfo) bool {
+	if len(info.RootFields) == 0 {
+		return false
+	}
+	for _, field := range info.RootFields {
+		if !strings.HasPrefix(field.FieldName, "__") {
+			return false
+		}
+	}
+	return true
+}
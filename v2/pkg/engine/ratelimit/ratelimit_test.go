@@ -0,0 +1,276 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/mock_datasource"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/postprocess"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+func TestRateLimiter_RateLimitPreFetch(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	newLimiter := func(options Options) *RateLimiter {
+		limiter := NewRateLimiter(options)
+		limiter.now = func() time.Time { return now }
+		return limiter
+	}
+	newContext := func(options resolve.RateLimitOptions) *resolve.Context {
+		ctx := resolve.NewContext(WithStats(context.Background()))
+		options.Enable = true
+		ctx.RateLimitOptions = options
+		return ctx
+	}
+	fetch := &resolve.FetchInfo{
+		DataSourceName: "products",
+		RootFields:     []resolve.GraphCoordinate{{TypeName: "Query", FieldName: "products"}},
+	}
+
+	t.Run("allows bursts and refills with the rate", func(t *testing.T) {
+		limiter := newLimiter(Options{})
+		ctx := newContext(resolve.RateLimitOptions{Rate: 2, Burst: 2, Period: time.Second, RateLimitKey: "client"})
+
+		for i := 0; i < 2; i++ {
+			deny, err := limiter.RateLimitPreFetch(ctx, fetch, nil)
+			require.NoError(t, err)
+			assert.Nil(t, deny)
+		}
+		deny, err := limiter.RateLimitPreFetch(ctx, fetch, nil)
+		require.NoError(t, err)
+		assert.Equal(t, &resolve.RateLimitDeny{Reason: "rate limit exceeded"}, deny)
+
+		stats := GetStats(ctx.Context())
+		assert.Equal(t, "client", stats.Key)
+		assert.Equal(t, 3, stats.RequestRate)
+		assert.Equal(t, 0, stats.Remaining)
+		assert.Equal(t, int64(500), stats.RetryAfterMs)
+		assert.Equal(t, int64(1000), stats.ResetAfterMs)
+
+		now = now.Add(500 * time.Millisecond)
+		deny, err = limiter.RateLimitPreFetch(ctx, fetch, nil)
+		require.NoError(t, err)
+		assert.Nil(t, deny)
+	})
+
+	t.Run("keys are limited independently", func(t *testing.T) {
+		limiter := newLimiter(Options{})
+		a := newContext(resolve.RateLimitOptions{Rate: 1, Period: time.Minute, RateLimitKey: "a"})
+		b := newContext(resolve.RateLimitOptions{Rate: 1, Period: time.Minute, RateLimitKey: "b"})
+
+		deny, err := limiter.RateLimitPreFetch(a, fetch, nil)
+		require.NoError(t, err)
+		assert.Nil(t, deny)
+		deny, err = limiter.RateLimitPreFetch(b, fetch, nil)
+		require.NoError(t, err)
+		assert.Nil(t, deny)
+		deny, err = limiter.RateLimitPreFetch(a, fetch, nil)
+		require.NoError(t, err)
+		assert.NotNil(t, deny)
+	})
+
+	t.Run("subgraph and field costs", func(t *testing.T) {
+		limiter := newLimiter(Options{
+			SubgraphCosts: map[string]int{"products": 2},
+			FieldCosts:    map[string]int{"Query.products": 3},
+		})
+		ctx := newContext(resolve.RateLimitOptions{Rate: 10, Period: time.Second})
+
+		deny, err := limiter.RateLimitPreFetch(ctx, fetch, nil)
+		require.NoError(t, err)
+		assert.Nil(t, deny)
+		deny, err = limiter.RateLimitPreFetch(ctx, &resolve.FetchInfo{DataSourceName: "reviews"}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, deny)
+
+		stats := GetStats(ctx.Context())
+		assert.Equal(t, 6, stats.RequestRate)
+		assert.Equal(t, 4, stats.Remaining)
+
+		deny, err = limiter.RateLimitPreFetch(ctx, fetch, nil)
+		require.NoError(t, err)
+		assert.NotNil(t, deny)
+	})
+
+	t.Run("reject exceeding requests", func(t *testing.T) {
+		limiter := newLimiter(Options{})
+		ctx := newContext(resolve.RateLimitOptions{Rate: 1, Period: time.Second, RejectExceedingRequests: true})
+
+		_, err := limiter.RateLimitPreFetch(ctx, fetch, nil)
+		require.NoError(t, err)
+		_, err = limiter.RateLimitPreFetch(ctx, fetch, nil)
+		assert.ErrorIs(t, err, ErrRateLimitExceeded)
+	})
+
+	t.Run("introspection is not limited", func(t *testing.T) {
+		limiter := newLimiter(Options{})
+		ctx := newContext(resolve.RateLimitOptions{Rate: 1, Period: time.Second})
+		introspection := &resolve.FetchInfo{
+			RootFields: []resolve.GraphCoordinate{{TypeName: "Query", FieldName: "__schema"}},
+		}
+
+		for i := 0; i < 3; i++ {
+			deny, err := limiter.RateLimitPreFetch(ctx, introspection, nil)
+			require.NoError(t, err)
+			assert.Nil(t, deny)
+		}
+		assert.Equal(t, 0, GetStats(ctx.Context()).RequestRate)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		limiter := newLimiter(Options{})
+		_, err := limiter.RateLimitPreFetch(newContext(resolve.RateLimitOptions{}), fetch, nil)
+		assert.EqualError(t, err, "invalid rate limit options: rate 0 per 0s")
+	})
+}
+
+func TestRateLimiter_Resolve(t *testing.T) {
+	definition := `
+		type Query {
+			products: [Product!]!
+		}
+
+		type Product {
+			id: ID!
+			name: String!
+			stock: Int!
+		}
+	`
+	keys := plan.FederationMetaData{
+		Keys: plan.FederationFieldConfigurations{
+			{TypeName: "Product", SelectionSet: "id"},
+		},
+	}
+
+	factory, err := mock_datasource.NewFactory(context.Background())
+	require.NoError(t, err)
+	products, err := plan.NewDataSourceConfiguration[mock_datasource.Configuration](
+		"products",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"products"}},
+				{TypeName: "Product", FieldNames: []string{"id", "name"}},
+			},
+			FederationMetaData: keys,
+		},
+		mock_datasource.Configuration{
+			ListLength: 1,
+			Overrides: map[string]json.RawMessage{
+				"Product.id":   json.RawMessage(`"1"`),
+				"Product.name": json.RawMessage(`"Trilby"`),
+			},
+		},
+	)
+	require.NoError(t, err)
+	inventory, err := plan.NewDataSourceConfiguration[mock_datasource.Configuration](
+		"inventory",
+		factory,
+		&plan.DataSourceMetadata{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Product", FieldNames: []string{"id", "stock"}},
+			},
+			FederationMetaData: keys,
+		},
+		mock_datasource.Configuration{
+			Overrides: map[string]json.RawMessage{
+				"Product.stock": json.RawMessage(`3`),
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	limiter := NewRateLimiter(Options{
+		SubgraphCosts: map[string]int{"inventory": 5},
+	})
+	limiter.now = func() time.Time { return now }
+
+	execute := func(t *testing.T, operation string) string {
+		t.Helper()
+
+		def := unsafeparser.ParseGraphqlDocumentString(definition)
+		require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&def))
+		op := unsafeparser.ParseGraphqlDocumentString(operation)
+
+		report := &operationreport.Report{}
+		normalizer := astnormalization.NewWithOpts(astnormalization.WithExtractVariables(), astnormalization.WithRemoveUnusedVariables())
+		normalizer.NormalizeOperation(&op, &def, report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		planner, err := plan.NewPlanner(plan.Configuration{
+			DataSources: []plan.DataSource{products, inventory},
+		})
+		require.NoError(t, err)
+		executionPlan := planner.Plan(&op, &def, "", report)
+		require.False(t, report.HasErrors(), report.Error())
+		postprocess.NewProcessor().Process(executionPlan)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := resolve.New(ctx, resolve.ResolverOptions{
+			MaxConcurrency: 32,
+		})
+
+		resolveCtx := resolve.NewContext(WithStats(ctx))
+		resolveCtx.RateLimitOptions = resolve.RateLimitOptions{
+			Enable:                          true,
+			IncludeStatsInResponseExtension: true,
+			Rate:                            5,
+			Period:                          time.Second,
+			RateLimitKey:                    "client",
+		}
+		resolveCtx.SetRateLimiter(limiter)
+
+		out := &bytes.Buffer{}
+		_, err = resolver.ResolveGraphQLResponse(resolveCtx, executionPlan.(*plan.SynchronousResponsePlan).Response, nil, out)
+		require.NoError(t, err)
+		return out.String()
+	}
+
+	out := execute(t, `{ products { name } }`)
+	assert.Equal(t, `{"data":{"products":[{"name":"Trilby"}]},"extensions":{"rateLimit":{"key":"client","requestRate":1,"remaining":4,"retryAfterMs":0,"resetAfterMs":200}}}`, out)
+
+	out = execute(t, `{ products { name stock } }`)
+	assert.Equal(t, `{"errors":[{"message":"Rate limit exceeded for Subgraph 'inventory' at Path 'products', Reason: rate limit exceeded."}],"data":null,"extensions":{"rateLimit":{"key":"client","requestRate":6,"remaining":3,"retryAfterMs":400,"resetAfterMs":400}}}`, out)
+}
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	storage := NewMemoryStorage()
+	storage.now = func() time.Time { return now }
+
+	tat, err := storage.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, tat.IsZero())
+
+	swapped, err := storage.CompareAndSwap(ctx, "key", time.Time{}, now.Add(time.Second), time.Second)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	swapped, err = storage.CompareAndSwap(ctx, "key", time.Time{}, now.Add(2*time.Second), time.Second)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	tat, err = storage.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Second), tat)
+
+	now = now.Add(time.Second)
+	tat, err = storage.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, tat.IsZero())
+	assert.Empty(t, storage.keys)
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Storage persists the theoretical arrival time (TAT) of the rate limit keys.
+// Implementations must be safe for concurrent use, shared stores like Redis allow multiple instances to share the limits.
+// A Redis compatible store can implement CompareAndSwap with a small script comparing and setting the key with PX ttl.
+type Storage interface {
+	// Get returns the theoretical arrival time of the key, the zero time if the key is unknown or expired
+	Get(ctx context.Context, key string) (time.Time, error)
+	// CompareAndSwap sets the theoretical arrival time of the key to value if it's still equal to old.
+	// A zero old time matches unknown or expired keys. The key expires after the ttl.
+	CompareAndSwap(ctx context.Context, key string, old, value time.Time, ttl time.Duration) (swapped bool, err error)
+}
+
+// sweepInterval is the number of writes after which the MemoryStorage removes expired keys
+const sweepInterval = 1024
+
+// MemoryStorage is a Storage for a single instance
+type MemoryStorage struct {
+	mu     sync.Mutex
+	keys   map[string]memoryEntry
+	writes int
+	now    func() time.Time
+}
+
+type memoryEntry struct {
+	tat     time.Time
+	expires time.Time
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		keys: map[string]memoryEntry{},
+		now:  time.Now,
+	}
+}
+
+func (s *MemoryStorage) Get(_ context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key), nil
+}
+
+func (s *MemoryStorage) CompareAndSwap(_ context.Context, key string, old, value time.Time, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.get(key).Equal(old) {
+		return false, nil
+	}
+	s.keys[key] = memoryEntry{tat: value, expires: s.now().Add(ttl)}
+	s.writes++
+	if s.writes >= sweepInterval {
+		s.sweep()
+	}
+	return true, nil
+}
+
+func (s *MemoryStorage) get(key string) time.Time {
+	entry, ok := s.keys[key]
+	if !ok {
+		return time.Time{}
+	}
+	if !s.now().Before(entry.expires) {
+		delete(s.keys, key)
+		return time.Time{}
+	}
+	return entry.tat
+}
+
+func (s *MemoryStorage) sweep() {
+	s.writes = 0
+	now := s.now()
+	for key, entry := range s.keys {
+		if !now.Before(entry.expires) {
+			delete(s.keys, key)
+		}
+	}
+}
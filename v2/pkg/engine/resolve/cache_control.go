@@ -0,0 +1,109 @@
+package resolve
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CacheControlScope string
+
+const (
+	CacheControlScopePublic  CacheControlScope = "PUBLIC"
+	CacheControlScopePrivate CacheControlScope = "PRIVATE"
+)
+
+// CacheControlPolicy is the cache policy of a field, as defined with @cacheControl in the schema,
+// or the cache policy of a whole response
+type CacheControlPolicy struct {
+	// MaxAge is only set when HasMaxAge is true, otherwise the policy doesn't restrict the max age
+	MaxAge    time.Duration
+	HasMaxAge bool
+	// Scope is PRIVATE when the response must not be stored in shared caches
+	Scope CacheControlScope
+}
+
+// Restrict lowers the policy to the lower max age and the more restrictive scope of both policies
+func (p *CacheControlPolicy) Restrict(other *CacheControlPolicy) {
+	if other.HasMaxAge && (!p.HasMaxAge || other.MaxAge < p.MaxAge) {
+		p.MaxAge = other.MaxAge
+		p.HasMaxAge = true
+	}
+	if other.Scope == CacheControlScopePrivate {
+		p.Scope = CacheControlScopePrivate
+	}
+}
+
+// Cacheable returns true if the policy allows to cache the response
+func (p *CacheControlPolicy) Cacheable() bool {
+	return p.HasMaxAge && p.MaxAge > 0
+}
+
+// HeaderValue renders the policy as value of a Cache-Control response header
+func (p *CacheControlPolicy) HeaderValue() string {
+	if !p.Cacheable() {
+		return "no-store"
+	}
+	scope := "public"
+	if p.Scope == CacheControlScopePrivate {
+		scope = "private"
+	}
+	return "max-age=" + strconv.FormatInt(int64(p.MaxAge/time.Second), 10) + ", " + scope
+}
+
+// ParseCacheControlHeader converts the Cache-Control header of a subgraph response into a policy
+// ok is false when the header contains no directive which restricts caching
+func ParseCacheControlHeader(header string) (policy CacheControlPolicy, ok bool) {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			policy.Restrict(&CacheControlPolicy{HasMaxAge: true})
+			ok = true
+		case "max-age":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			policy.Restrict(&CacheControlPolicy{MaxAge: time.Duration(seconds) * time.Second, HasMaxAge: true})
+			ok = true
+		case "private":
+			policy.Scope = CacheControlScopePrivate
+			ok = true
+		}
+	}
+	return policy, ok
+}
+
+func (r *Resolvable) restrictCacheControl(policy *CacheControlPolicy) {
+	r.cacheControl.Restrict(policy)
+	r.hasCacheControl = true
+}
+
+// CacheControl returns the cache policy of the resolved response
+// It's nil when neither the resolved fields have cache hints nor the subgraphs returned Cache-Control headers
+func (r *Resolvable) CacheControl() *CacheControlPolicy {
+	if !r.hasCacheControl {
+		return nil
+	}
+	policy := r.cacheControl
+	if r.hasErrors() || r.hadIncrementalErrors {
+		// responses with errors must not be cached
+		policy.Restrict(&CacheControlPolicy{HasMaxAge: true})
+	}
+	return &policy
+}
+
+// restrictCacheControl applies the Cache-Control header of the subgraph response to the policy of the response
+func (l *Loader) restrictCacheControl(res *result) {
+	if res.httpResponseContext == nil || res.httpResponseContext.Response == nil {
+		return
+	}
+	header := res.httpResponseContext.Response.Header.Get("Cache-Control")
+	if header == "" {
+		return
+	}
+	if policy, ok := ParseCacheControlHeader(header); ok {
+		l.resolvable.restrictCacheControl(&policy)
+	}
+}
@@ -0,0 +1,181 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+)
+
+type httpTestDataSource struct {
+	url string
+}
+
+func (h *httpTestDataSource) Load(ctx context.Context, _ []byte, out *bytes.Buffer) error {
+	return httpclient.Do(http.DefaultClient, ctx, []byte(fmt.Sprintf(`{"method":"POST","url":"%s","body":{"query":"{a}"}}`, h.url)), out)
+}
+
+func (h *httpTestDataSource) LoadWithFiles(ctx context.Context, input []byte, _ []httpclient.File, out *bytes.Buffer) error {
+	return h.Load(ctx, input, out)
+}
+
+func TestParseCacheControlHeader(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		policy CacheControlPolicy
+		ok     bool
+	}{
+		{header: "max-age=60", policy: CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true}, ok: true},
+		{header: "public, max-age=60", policy: CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true}, ok: true},
+		{header: "private, max-age=60", policy: CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true, Scope: CacheControlScopePrivate}, ok: true},
+		{header: "no-store", policy: CacheControlPolicy{HasMaxAge: true}, ok: true},
+		{header: "max-age=60, no-cache", policy: CacheControlPolicy{HasMaxAge: true}, ok: true},
+		{header: "max-age=invalid", ok: false},
+		{header: "must-revalidate", ok: false},
+	} {
+		t.Run(tc.header, func(t *testing.T) {
+			policy, ok := ParseCacheControlHeader(tc.header)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.policy, policy)
+		})
+	}
+}
+
+func TestCacheControlPolicy_HeaderValue(t *testing.T) {
+	assert.Equal(t, "max-age=60, public", (&CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true}).HeaderValue())
+	assert.Equal(t, "max-age=60, private", (&CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true, Scope: CacheControlScopePrivate}).HeaderValue())
+	assert.Equal(t, "no-store", (&CacheControlPolicy{HasMaxAge: true}).HeaderValue())
+	assert.Equal(t, "no-store", (&CacheControlPolicy{}).HeaderValue())
+}
+
+func TestResolver_ResolveGraphQLResponse_CacheControl(t *testing.T) {
+	maxAge := func(d time.Duration) *CacheControlPolicy {
+		return &CacheControlPolicy{MaxAge: d, HasMaxAge: true}
+	}
+
+	response := func(dataSource DataSource) *GraphQLResponse {
+		return &GraphQLResponse{
+			Info: &GraphQLResponseInfo{
+				OperationType: ast.OperationTypeQuery,
+			},
+			Fetches: Single(&SingleFetch{
+				FetchConfiguration: FetchConfiguration{
+					DataSource: dataSource,
+					PostProcessing: PostProcessingConfiguration{
+						SelectResponseDataPath:   []string{"data"},
+						SelectResponseErrorsPath: []string{"errors"},
+					},
+				},
+			}),
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name:         []byte("product"),
+						CacheControl: maxAge(time.Minute),
+						Value: &Object{
+							Path:     []string{"product"},
+							Nullable: true,
+							Fields: []*Field{
+								{
+									Name:         []byte("price"),
+									CacheControl: maxAge(30 * time.Second),
+									Value:        &Integer{Path: []string{"price"}, Nullable: true},
+								},
+							},
+						},
+					},
+					{
+						Name:         []byte("me"),
+						CacheControl: &CacheControlPolicy{MaxAge: 2 * time.Minute, HasMaxAge: true, Scope: CacheControlScopePrivate},
+						Value: &Object{
+							Path:     []string{"me"},
+							Nullable: true,
+							Fields: []*Field{
+								{
+									Name:         []byte("cart"),
+									CacheControl: maxAge(0),
+									Value: &Object{
+										Path:     []string{"cart"},
+										Nullable: true,
+										Fields: []*Field{
+											{
+												Name:  []byte("total"),
+												Value: &Integer{Path: []string{"total"}, Nullable: true},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	resolve := func(t *testing.T, dataSource DataSource) *GraphQLResolveInfo {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(ctx)
+		buf := &bytes.Buffer{}
+		info, err := resolver.ResolveGraphQLResponse(&Context{ctx: ctx}, response(dataSource), nil, buf)
+		require.NoError(t, err)
+		return info
+	}
+
+	t.Run("minimum of the resolved fields", func(t *testing.T) {
+		info := resolve(t, FakeDataSource(`{"data":{"product":{"price":10},"me":null}}`))
+		// cart isn't resolved because me is null
+		assert.Equal(t, &CacheControlPolicy{MaxAge: 30 * time.Second, HasMaxAge: true, Scope: CacheControlScopePrivate}, info.CacheControl)
+	})
+
+	t.Run("uncacheable nested field", func(t *testing.T) {
+		info := resolve(t, FakeDataSource(`{"data":{"product":{"price":10},"me":{"cart":{"total":1}}}}`))
+		assert.Equal(t, "no-store", info.CacheControl.HeaderValue())
+	})
+
+	t.Run("errors are not cacheable", func(t *testing.T) {
+		info := resolve(t, FakeDataSource(`{"data":{"product":{"price":10},"me":null},"errors":[{"message":"unauthorized"}]}`))
+		assert.False(t, info.CacheControl.Cacheable())
+	})
+
+	t.Run("subgraph Cache-Control header", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=10")
+			_, _ = w.Write([]byte(`{"data":{"product":{"price":10},"me":null}}`))
+		}))
+		defer server.Close()
+
+		info := resolve(t, &httpTestDataSource{url: server.URL})
+		assert.Equal(t, &CacheControlPolicy{MaxAge: 10 * time.Second, HasMaxAge: true, Scope: CacheControlScopePrivate}, info.CacheControl)
+	})
+
+	t.Run("no hints", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(ctx)
+		info, err := resolver.ResolveGraphQLResponse(&Context{ctx: ctx}, &GraphQLResponse{
+			Info: &GraphQLResponseInfo{OperationType: ast.OperationTypeQuery},
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name:  []byte("name"),
+						Value: &String{Path: []string{"name"}},
+					},
+				},
+			},
+		}, []byte(`{"name":"a"}`), &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Nil(t, info.CacheControl)
+	})
+}
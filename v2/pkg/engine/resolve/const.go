@@ -25,6 +25,10 @@ var (
 	literalValueCompletion    = []byte("valueCompletion")
 	literalRateLimit          = []byte("rateLimit")
 	literalAuthorization      = []byte("authorization")
+	literalIncremental        = []byte("incremental")
+	literalHasNext            = []byte("hasNext")
+	literalLabel              = []byte("label")
+	literalItems              = []byte("items")
 
 	emptyArray  = []byte("[]")
 	emptyObject = []byte("{}")
@@ -24,6 +24,12 @@ type Context struct {
 	InitialPayload   []byte
 	Extensions       []byte
 	LoaderHooks      LoaderHooks
+	// OverrideLabels are the labels of progressive @override directives which are active for the request, e.g. percent(10)
+	// The labels are used to plan the operation, see plan.WithOverrideLabels
+	OverrideLabels []string
+	// Claims are the verified claims of the client, e.g. the payload of a validated JWT. Nil for anonymous clients.
+	// The claims are evaluated by authorizers, e.g. authorization.DirectiveAuthorizer
+	Claims map[string]any
 
 	authorizer  Authorizer
 	rateLimiter RateLimiter
@@ -35,6 +41,9 @@ type ExecutionOptions struct {
 	SkipLoader                 bool
 	IncludeQueryPlanInResponse bool
 	SendHeartbeat              bool
+	// EnableIncrementalDelivery flushes @defer and @stream responses as separate payloads to writers that can flush
+	// Otherwise, deferred fragments and streamed lists are resolved inline into a single response
+	EnableIncrementalDelivery bool
 }
 
 type AuthorizationDeny struct {
@@ -139,6 +148,7 @@ func (c *Context) clone(ctx context.Context) *Context {
 	cpy.Files = append([]httpclient.File(nil), c.Files...)
 	cpy.Request.Header = c.Request.Header.Clone()
 	cpy.RenameTypeNames = append([]RenameTypeName(nil), c.RenameTypeNames...)
+	cpy.OverrideLabels = append([]string(nil), c.OverrideLabels...)
 	return &cpy
 }
 
@@ -148,6 +158,8 @@ func (c *Context) Free() {
 	c.Files = nil
 	c.Request.Header = nil
 	c.RenameTypeNames = nil
+	c.OverrideLabels = nil
+	c.Claims = nil
 	c.TracingOptions.DisableAll()
 	c.Extensions = nil
 	c.subgraphErrors = nil
@@ -0,0 +1,205 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/tidwall/gjson"
+	"github.com/wundergraph/astjson"
+)
+
+// EntityCache caches entities resolved by the _entities field of subgraphs
+// The cache is best effort, errors of Get are treated as misses and errors of Set are ignored
+// Implementations must be safe for concurrent use
+type EntityCache interface {
+	// Get returns the cached entities for the keys
+	// The returned slice must have the same length as keys, with nil values for keys that are not cached
+	Get(ctx context.Context, keys []string) ([][]byte, error)
+	// Set stores the entities, each entry expires after its TTL
+	Set(ctx context.Context, entries []*EntityCacheEntry) error
+}
+
+type EntityCacheEntry struct {
+	Key   string
+	Value []byte
+	TTL   time.Duration
+}
+
+// EntityCacheConfiguration configures which entities of an entity fetch are cached
+type EntityCacheConfiguration struct {
+	// MaxAge is the TTL of the cached entities by typename, entities of other types are always fetched
+	MaxAge map[string]time.Duration
+}
+
+// EntityCacheKey returns the key of an entity
+// The key consists of the subgraph, the typename and the key fields of the representation,
+// the selectionHash makes sure that different selection sets of the same entity are cached independently
+func EntityCacheKey(subgraph, typeName string, selectionHash uint64, representation []byte) string {
+	key := make([]byte, 0, len(subgraph)+len(typeName)+len(representation)+19)
+	key = append(key, subgraph...)
+	key = append(key, ':')
+	key = append(key, typeName...)
+	key = append(key, ':')
+	key = strconv.AppendUint(key, selectionHash, 16)
+	key = append(key, ':')
+	key = append(key, representation...)
+	return string(key)
+}
+
+// entityCacheLoad tracks the cache state of the unique representations of an entity fetch
+type entityCacheLoad struct {
+	cfg *EntityCacheConfiguration
+	// representations are the rendered unique representations of the fetch
+	representations [][]byte
+	keys            []string
+	ttls            []time.Duration
+	// hits are the cached entities of the representations, nil for misses
+	hits   [][]byte
+	misses int
+}
+
+func (l *Loader) newEntityCacheLoad(cfg *EntityCacheConfiguration) *entityCacheLoad {
+	if l.entityCache == nil || cfg == nil || len(cfg.MaxAge) == 0 {
+		return nil
+	}
+	return &entityCacheLoad{
+		cfg: cfg,
+	}
+}
+
+func (e *entityCacheLoad) addRepresentation(representation []byte) {
+	e.representations = append(e.representations, bytes.Clone(representation))
+}
+
+// loadCachedEntities looks up all representations of cacheable types
+// header and footer are the rendered input around the representations, they define the selection set of the fetch
+func (l *Loader) loadCachedEntities(ctx context.Context, load *entityCacheLoad, info *FetchInfo, header, footer []byte, undefinedVariables []string) {
+	hash := xxhash.New()
+	_, _ = hash.Write(header)
+	_, _ = hash.Write(footer)
+	for i := range undefinedVariables {
+		_, _ = hash.WriteString(undefinedVariables[i])
+	}
+	selectionHash := hash.Sum64()
+
+	subgraph := ""
+	if info != nil {
+		subgraph = info.DataSourceID
+	}
+
+	load.keys = make([]string, len(load.representations))
+	load.ttls = make([]time.Duration, len(load.representations))
+	load.hits = make([][]byte, len(load.representations))
+	load.misses = len(load.representations)
+
+	lookupKeys := make([]string, 0, len(load.representations))
+	lookupIndexes := make([]int, 0, len(load.representations))
+	for i, representation := range load.representations {
+		typeName := gjson.GetBytes(representation, "__typename").String()
+		ttl, ok := load.cfg.MaxAge[typeName]
+		if !ok {
+			continue
+		}
+		load.keys[i] = EntityCacheKey(subgraph, typeName, selectionHash, representation)
+		load.ttls[i] = ttl
+		lookupKeys = append(lookupKeys, load.keys[i])
+		lookupIndexes = append(lookupIndexes, i)
+	}
+	if len(lookupKeys) == 0 {
+		return
+	}
+
+	cached, err := l.entityCache.Get(ctx, lookupKeys)
+	if err != nil || len(cached) != len(lookupKeys) {
+		return
+	}
+	for i, value := range cached {
+		if value == nil {
+			continue
+		}
+		load.hits[lookupIndexes[i]] = value
+		load.misses--
+	}
+}
+
+// writeMissingRepresentations writes the representations that were not found in the cache to the fetch input
+// When all entities are cached, all representations are written, because the input is still authorized
+func (l *Loader) writeMissingRepresentations(load *entityCacheLoad, separator *InputTemplate, out *bytes.Buffer) error {
+	addSeparator := false
+	for i, representation := range load.representations {
+		if load.hits[i] != nil && load.misses != 0 {
+			continue
+		}
+		if addSeparator {
+			err := separator.Render(l.ctx, nil, out)
+			if err != nil {
+				return err
+			}
+		}
+		_, _ = out.Write(representation)
+		addSeparator = true
+	}
+	return nil
+}
+
+// entityCacheValues returns the entities of all unique representations of the fetch
+// fetched contains the entities returned by the subgraph for the missing representations
+// Fetched entities are stored in the cache unless the subgraph returned errors
+func (l *Loader) entityCacheValues(load *entityCacheLoad, fetched []*astjson.Value, storeFetched bool) ([]*astjson.Value, bool) {
+	if len(fetched) != load.misses {
+		return nil, false
+	}
+	values := make([]*astjson.Value, len(load.representations))
+	var entries []*EntityCacheEntry
+	next := 0
+	for i := range load.representations {
+		if load.hits[i] != nil {
+			value, err := astjson.ParseBytesWithoutCache(load.hits[i])
+			if err != nil {
+				return nil, false
+			}
+			values[i] = value
+			continue
+		}
+		values[i] = fetched[next]
+		next++
+		if !storeFetched || load.keys[i] == "" || !astjson.ValueIsNonNull(values[i]) {
+			continue
+		}
+		entries = append(entries, &EntityCacheEntry{
+			Key:   load.keys[i],
+			Value: values[i].MarshalTo(nil),
+			TTL:   load.ttls[i],
+		})
+	}
+	if len(entries) != 0 {
+		_ = l.entityCache.Set(l.ctx.ctx, entries)
+	}
+	return values, true
+}
+
+func (l *Loader) mergeEntities(res *result, items []*astjson.Value, entities []*astjson.Value) {
+	if res.batchStats == nil {
+		astjson.MergeValuesWithPath(items[0], entities[0], res.postProcessing.MergePath...)
+		return
+	}
+	for i, stats := range res.batchStats {
+		for _, item := range stats {
+			if item == -1 {
+				continue
+			}
+			astjson.MergeValuesWithPath(items[i], entities[item], res.postProcessing.MergePath...)
+		}
+	}
+}
+
+func (e *entityCacheLoad) trace(trace *DataSourceLoadTrace) {
+	if trace == nil {
+		return
+	}
+	trace.EntityCacheHits = len(e.representations) - e.misses
+	trace.EntityCacheMisses = e.misses
+}
@@ -0,0 +1,281 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/fastjsonext"
+)
+
+type testEntityCache struct {
+	mu      sync.Mutex
+	entries map[string]*EntityCacheEntry
+}
+
+func newTestEntityCache() *testEntityCache {
+	return &testEntityCache{
+		entries: map[string]*EntityCacheEntry{},
+	}
+}
+
+func (c *testEntityCache) Get(_ context.Context, keys []string) ([][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		if entry, ok := c.entries[key]; ok {
+			values[i] = entry.Value
+		}
+	}
+	return values, nil
+}
+
+func (c *testEntityCache) Set(_ context.Context, entries []*EntityCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range entries {
+		c.entries[entry.Key] = entry
+	}
+	return nil
+}
+
+func (c *testEntityCache) values() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make([]string, 0, len(c.entries))
+	for _, entry := range c.entries {
+		values = append(values, string(entry.Value))
+	}
+	return values
+}
+
+func TestLoader_EntityCache(t *testing.T) {
+	const (
+		productsInput = `{"method":"POST","url":"http://products","body":{"query":"query{topProducts{name __typename upc}}"}}`
+		stockHeader   = `{"method":"POST","url":"http://stock","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){__typename ... on Product {stock}}}","variables":{"representations":[`
+	)
+
+	productsService := func(ctrl *gomock.Controller, products string) DataSource {
+		return mockedDS(t, ctrl, productsInput, `{"topProducts":`+products+`}`)
+	}
+
+	stockService := func(ctrl *gomock.Controller, representations, entities string) DataSource {
+		service := NewMockDataSource(ctrl)
+		service.EXPECT().
+			Load(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&bytes.Buffer{})).
+			DoAndReturn(func(ctx context.Context, input []byte, w io.Writer) (err error) {
+				require.Equal(t, stockHeader+representations+`]}}}`, string(input))
+				pair := NewBufPair()
+				pair.Data.WriteString(`{"_entities":` + entities + `}`)
+				return writeGraphqlResponse(pair, w, false)
+			}).Times(1)
+		return service
+	}
+
+	representation := InputTemplate{
+		Segments: []TemplateSegment{
+			{
+				SegmentType:  VariableSegmentType,
+				VariableKind: ResolvableObjectVariableKind,
+				Renderer: NewGraphQLVariableResolveRenderer(&Object{
+					Fields: []*Field{
+						{
+							Name:  []byte("__typename"),
+							Value: &String{Path: []string{"__typename"}},
+						},
+						{
+							Name:  []byte("upc"),
+							Value: &String{Path: []string{"upc"}},
+						},
+					},
+				}),
+			},
+		},
+	}
+
+	batchResponse := func(products, stock DataSource) *GraphQLResponse {
+		return &GraphQLResponse{
+			Fetches: Sequence(
+				Single(&SingleFetch{
+					InputTemplate: InputTemplate{
+						Segments: []TemplateSegment{
+							{
+								Data:        []byte(productsInput),
+								SegmentType: StaticSegmentType,
+							},
+						},
+					},
+					FetchConfiguration: FetchConfiguration{
+						DataSource: products,
+						PostProcessing: PostProcessingConfiguration{
+							SelectResponseDataPath: []string{"data"},
+						},
+					},
+				}),
+				Single(&BatchEntityFetch{
+					Input: BatchInput{
+						Header: InputTemplate{
+							Segments: []TemplateSegment{
+								{
+									Data:        []byte(stockHeader),
+									SegmentType: StaticSegmentType,
+								},
+							},
+						},
+						Items: []InputTemplate{representation},
+						Separator: InputTemplate{
+							Segments: []TemplateSegment{
+								{
+									Data:        []byte(`,`),
+									SegmentType: StaticSegmentType,
+								},
+							},
+						},
+						Footer: InputTemplate{
+							Segments: []TemplateSegment{
+								{
+									Data:        []byte(`]}}}`),
+									SegmentType: StaticSegmentType,
+								},
+							},
+						},
+					},
+					DataSource: stock,
+					PostProcessing: PostProcessingConfiguration{
+						SelectResponseDataPath:   []string{"data", "_entities"},
+						SelectResponseErrorsPath: []string{"errors"},
+					},
+					Info: &FetchInfo{
+						DataSourceID: "stock",
+					},
+					Cache: &EntityCacheConfiguration{
+						MaxAge: map[string]time.Duration{
+							"Product": time.Minute,
+						},
+					},
+				}, ArrayPath("topProducts")),
+			),
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name: []byte("topProducts"),
+						Value: &Array{
+							Path: []string{"topProducts"},
+							Item: &Object{
+								Fields: []*Field{
+									{
+										Name:  []byte("name"),
+										Value: &String{Path: []string{"name"}},
+									},
+									{
+										Name:  []byte("stock"),
+										Value: &Integer{Path: []string{"stock"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	load := func(t *testing.T, cache EntityCache, response *GraphQLResponse) string {
+		t.Helper()
+		ctx := &Context{
+			ctx: context.Background(),
+		}
+		resolvable := NewResolvable(ResolvableOptions{})
+		loader := &Loader{
+			entityCache: cache,
+		}
+		require.NoError(t, resolvable.Init(ctx, nil, ast.OperationTypeQuery))
+		require.NoError(t, loader.LoadGraphQLResponseData(ctx, response, resolvable))
+		return fastjsonext.PrintGraphQLResponse(resolvable.data, resolvable.errors)
+	}
+
+	const (
+		threeProducts = `[{"name":"Table","__typename":"Product","upc":"1"},{"name":"Couch","__typename":"Product","upc":"2"},{"name":"Chair","__typename":"Product","upc":"3"}]`
+		expected      = `{"errors":[],"data":{"topProducts":[{"name":"Table","__typename":"Product","upc":"1","stock":8},{"name":"Couch","__typename":"Product","upc":"2","stock":2},{"name":"Chair","__typename":"Product","upc":"3","stock":5}]}}`
+	)
+
+	t.Run("misses are fetched and cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		cache := newTestEntityCache()
+
+		out := load(t, cache, batchResponse(
+			productsService(ctrl, threeProducts),
+			stockService(ctrl, `{"__typename":"Product","upc":"1"},{"__typename":"Product","upc":"2"},{"__typename":"Product","upc":"3"}`, `[{"stock":8},{"stock":2},{"stock":5}]`),
+		))
+		assert.Equal(t, expected, out)
+		assert.ElementsMatch(t, []string{`{"stock":8}`, `{"stock":2}`, `{"stock":5}`}, cache.values())
+	})
+
+	t.Run("only misses are fetched", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		cache := newTestEntityCache()
+
+		load(t, cache, batchResponse(
+			productsService(ctrl, `[{"name":"Table","__typename":"Product","upc":"1"},{"name":"Couch","__typename":"Product","upc":"2"}]`),
+			stockService(ctrl, `{"__typename":"Product","upc":"1"},{"__typename":"Product","upc":"2"}`, `[{"stock":8},{"stock":2}]`),
+		))
+
+		out := load(t, cache, batchResponse(
+			productsService(ctrl, threeProducts),
+			stockService(ctrl, `{"__typename":"Product","upc":"3"}`, `[{"stock":5}]`),
+		))
+		assert.Equal(t, expected, out)
+	})
+
+	t.Run("subgraph is not called when all entities are cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		cache := newTestEntityCache()
+
+		load(t, cache, batchResponse(
+			productsService(ctrl, threeProducts),
+			stockService(ctrl, `{"__typename":"Product","upc":"1"},{"__typename":"Product","upc":"2"},{"__typename":"Product","upc":"3"}`, `[{"stock":8},{"stock":2},{"stock":5}]`),
+		))
+
+		out := load(t, cache, batchResponse(
+			productsService(ctrl, threeProducts),
+			NewMockDataSource(ctrl),
+		))
+		assert.Equal(t, expected, out)
+	})
+
+	t.Run("entities are not cached when the subgraph returns errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		cache := newTestEntityCache()
+
+		stock := NewMockDataSource(ctrl)
+		stock.EXPECT().
+			Load(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&bytes.Buffer{})).
+			DoAndReturn(func(ctx context.Context, input []byte, w io.Writer) (err error) {
+				_, err = w.Write([]byte(`{"data":{"_entities":[{"stock":8},null,{"stock":5}]},"errors":[{"message":"out of stock"}]}`))
+				return err
+			}).Times(1)
+
+		load(t, cache, batchResponse(productsService(ctrl, threeProducts), stock))
+		assert.Empty(t, cache.values())
+	})
+
+	t.Run("selection sets are cached independently", func(t *testing.T) {
+		a := EntityCacheKey("stock", "Product", 1, []byte(`{"__typename":"Product","upc":"1"}`))
+		b := EntityCacheKey("stock", "Product", 2, []byte(`{"__typename":"Product","upc":"1"}`))
+		assert.NotEqual(t, a, b)
+		assert.Equal(t, `stock:Product:1:{"__typename":"Product","upc":"1"}`, a)
+	})
+}
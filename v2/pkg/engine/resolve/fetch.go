@@ -3,6 +3,7 @@ package resolve
 import (
 	"encoding/json"
 	"slices"
+	"time"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
 )
@@ -147,6 +148,10 @@ type BatchEntityFetch struct {
 	DataSourceIdentifier []byte
 	Trace                *DataSourceLoadTrace
 	Info                 *FetchInfo
+	// Cache enables the entity cache for the cacheable types of the representations
+	Cache *EntityCacheConfiguration
+	// Timeout limits the duration of the fetch, see FetchConfiguration.Timeout
+	Timeout time.Duration
 }
 
 func (b *BatchEntityFetch) Dependencies() FetchDependencies {
@@ -189,6 +194,10 @@ type EntityFetch struct {
 	DataSourceIdentifier []byte
 	Trace                *DataSourceLoadTrace
 	Info                 *FetchInfo
+	// Cache enables the entity cache for the cacheable type of the representation
+	Cache *EntityCacheConfiguration
+	// Timeout limits the duration of the fetch, see FetchConfiguration.Timeout
+	Timeout time.Duration
 }
 
 func (e *EntityFetch) Dependencies() FetchDependencies {
@@ -270,6 +279,12 @@ type FetchConfiguration struct {
 	// Returning null in this case tells the batch implementation to skip this item
 	SetTemplateOutputToNullOnVariableNull bool
 	QueryPlan                             *QueryPlan
+	// EntityCache is set by the planner for entity fetches of subgraphs with cacheable entity types
+	// After post-processing, it's the cache configuration of the EntityFetch or BatchEntityFetch
+	EntityCache *EntityCacheConfiguration
+	// Timeout limits the duration of the fetch including retries of the DataSource
+	// If zero, the fetch is only limited by the deadline of the request
+	Timeout time.Duration
 }
 
 func (fc *FetchConfiguration) Equals(other *FetchConfiguration) bool {
@@ -299,6 +314,9 @@ func (fc *FetchConfiguration) Equals(other *FetchConfiguration) bool {
 	if fc.SetTemplateOutputToNullOnVariableNull != other.SetTemplateOutputToNullOnVariableNull {
 		return false
 	}
+	if fc.Timeout != other.Timeout {
+		return false
+	}
 
 	return true
 }
@@ -329,8 +347,20 @@ type DataSourceLoadTrace struct {
 	SingleFlightUsed           bool            `json:"single_flight_used"`
 	SingleFlightSharedResponse bool            `json:"single_flight_shared_response"`
 	LoadSkipped                bool            `json:"load_skipped"`
+	EntityCacheHits            int             `json:"entity_cache_hits,omitempty"`
+	EntityCacheMisses          int             `json:"entity_cache_misses,omitempty"`
 	LoadStats                  *LoadStats      `json:"load_stats,omitempty"`
-	Path                       string          `json:"-"`
+	// Attempts are recorded when the datasource retries failed requests
+	Attempts           []DataSourceLoadAttempt `json:"attempts,omitempty"`
+	CircuitBreakerOpen bool                    `json:"circuit_breaker_open,omitempty"`
+	Path               string                  `json:"-"`
+}
+
+type DataSourceLoadAttempt struct {
+	StatusCode    int    `json:"status_code,omitempty"`
+	Error         string `json:"error,omitempty"`
+	BackoffNano   int64  `json:"backoff_nanoseconds,omitempty"`
+	BackoffPretty string `json:"backoff_pretty,omitempty"`
 }
 
 type LoadStats struct {
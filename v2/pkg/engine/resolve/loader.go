@@ -21,6 +21,7 @@ import (
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	"github.com/wundergraph/astjson"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
@@ -90,6 +91,8 @@ type result struct {
 	statusCode int
 	err        error
 	ds         DataSourceInfo
+	// timeout is the timeout of the fetch, it's used to explain errors caused by the timeout
+	timeout time.Duration
 
 	authorizationRejected        bool
 	authorizationRejectedReasons []string
@@ -102,6 +105,12 @@ type result struct {
 	loaderHookContext context.Context
 
 	httpResponseContext *httpclient.ResponseContext
+
+	// entityCache is set when the entities of an entity fetch are looked up in the entity cache
+	entityCache *entityCacheLoad
+
+	// singleFlightStats are recorded by the transport of the datasource when tracing or metrics are enabled
+	singleFlightStats *SingleFlightStats
 }
 
 func (r *result) init(postProcessing PostProcessingConfiguration, info *FetchInfo) {
@@ -133,6 +142,11 @@ type Loader struct {
 	allowedErrorExtensionFields       map[string]struct{}
 	defaultErrorExtensionCode         string
 	allowedSubgraphErrorFields        map[string]struct{}
+	entityCache                       EntityCache
+	subgraphDeadlineHeader            string
+	subgraphHandler                   SubgraphHandler
+	tracer                            trace.Tracer
+	metrics                           Metrics
 }
 
 func (l *Loader) Free() {
@@ -148,6 +162,24 @@ func (l *Loader) LoadGraphQLResponseData(ctx *Context, response *GraphQLResponse
 	return l.resolveFetchNode(response.Fetches)
 }
 
+// LoadDeferredFragmentData loads the fetches of a deferred fragment
+// It must be called after LoadGraphQLResponseData, as the fetches might depend on data of the initial fetch tree
+func (l *Loader) LoadDeferredFragmentData(ctx *Context, response *GraphQLResponse, fragment *DeferredFragment, resolvable *Resolvable) (err error) {
+	l.resolvable = resolvable
+	l.ctx = ctx
+	l.info = response.Info
+	return l.resolveFetchNode(fragment.Fetches)
+}
+
+// LoadStreamedData loads the fetches which are only required by the items of @stream fields
+// It must be called after LoadGraphQLResponseData, as the fetches depend on data of the initial fetch tree
+func (l *Loader) LoadStreamedData(ctx *Context, response *GraphQLResponse, resolvable *Resolvable) (err error) {
+	l.resolvable = resolvable
+	l.ctx = ctx
+	l.info = response.Info
+	return l.resolveFetchNode(response.StreamedFetches)
+}
+
 func (l *Loader) resolveFetchNode(node *FetchTreeNode) error {
 	if node == nil {
 		return nil
@@ -487,8 +519,34 @@ func (l *Loader) loadFetch(ctx context.Context, fetch Fetch, fetchItem *FetchIte
 	return nil
 }
 
+// withFetchTimeout limits the duration of a fetch to its timeout
+func (l *Loader) withFetchTimeout(ctx context.Context, timeout time.Duration, res *result) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	res.timeout = timeout
+	return context.WithTimeout(ctx, timeout)
+}
+
+// setDeadlineHeader forwards the time remaining until the deadline of the fetch to the subgraph, so it can abort early
+func (l *Loader) setDeadlineHeader(ctx context.Context, input []byte) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return input, nil
+	}
+	// only requests to http upstreams have headers
+	if _, _, _, err := jsonparser.Get(input, "url"); err != nil {
+		return input, nil
+	}
+	remaining := max(time.Until(deadline).Milliseconds(), 0)
+	return jsonparser.Set(input, []byte(`["`+strconv.FormatInt(remaining, 10)+`"]`), "header", l.subgraphDeadlineHeader)
+}
+
 func (l *Loader) mergeResult(fetchItem *FetchItem, res *result, items []*astjson.Value) error {
 	if res.err != nil {
+		if res.timeout > 0 && goerrors.Is(res.err, context.DeadlineExceeded) {
+			return l.renderErrorsFailedToFetch(fetchItem, res, fmt.Sprintf(fetchTimeoutExceeded, res.timeout))
+		}
 		return l.renderErrorsFailedToFetch(fetchItem, res, failedToFetchNoReason)
 	}
 	if res.authorizationRejected {
@@ -519,9 +577,18 @@ func (l *Loader) mergeResult(fetchItem *FetchItem, res *result, items []*astjson
 		}
 		return nil
 	}
+	if res.entityCache != nil && res.entityCache.misses == 0 {
+		entities, ok := l.entityCacheValues(res.entityCache, nil, false)
+		if !ok {
+			return l.renderErrorsFailedToFetch(fetchItem, res, invalidGraphQLResponse)
+		}
+		l.mergeEntities(res, items, entities)
+		return nil
+	}
 	if res.fetchSkipped {
 		return nil
 	}
+	l.restrictCacheControl(res)
 	if res.out.Len() == 0 {
 		return l.renderErrorsFailedToFetch(fetchItem, res, emptyGraphQLResponse)
 	}
@@ -579,6 +646,18 @@ func (l *Loader) mergeResult(fetchItem *FetchItem, res *result, items []*astjson
 		l.resolvable.data = value
 		return nil
 	}
+	if res.entityCache != nil {
+		fetched := []*astjson.Value{value}
+		if res.batchStats != nil {
+			fetched = value.GetArray()
+		}
+		entities, ok := l.entityCacheValues(res.entityCache, fetched, !hasErrors)
+		if !ok {
+			return l.renderErrorsFailedToFetch(fetchItem, res, invalidGraphQLResponseShape)
+		}
+		l.mergeEntities(res, items, entities)
+		return nil
+	}
 	if len(items) == 1 && res.batchStats == nil {
 		astjson.MergeValuesWithPath(items[0], value, res.postProcessing.MergePath...)
 		return nil
@@ -912,6 +991,7 @@ const (
 	emptyGraphQLResponse        = "empty response"
 	invalidGraphQLResponse      = "invalid JSON"
 	invalidGraphQLResponseShape = "no data or errors in response"
+	fetchTimeoutExceeded        = "timeout of %s exceeded"
 )
 
 func (l *Loader) renderAtPathErrorPart(path string) string {
@@ -1087,6 +1167,11 @@ func (l *Loader) validatePreFetch(input []byte, info *FetchInfo, res *result) (a
 }
 
 func (l *Loader) loadSingleFetch(ctx context.Context, fetch *SingleFetch, fetchItem *FetchItem, items []*astjson.Value, res *result) error {
+	ctx, cancel := l.withFetchTimeout(ctx, fetch.Timeout, res)
+	defer cancel()
+	ctx, span := l.startFetchSpan(ctx, fetchKindSingle, fetch.Info)
+	defer l.endFetchSpan(span, res)
+	defer l.reportFetch(fetch.Info, time.Now(), res)
 	res.init(fetch.PostProcessing, fetch.Info)
 	buf := &bytes.Buffer{}
 	inputData := l.itemsData(items)
@@ -1118,6 +1203,7 @@ var (
 			return &entityFetchBuffer{
 				item:          &bytes.Buffer{},
 				preparedInput: &bytes.Buffer{},
+				footer:        &bytes.Buffer{},
 			}
 		},
 	}
@@ -1126,6 +1212,7 @@ var (
 type entityFetchBuffer struct {
 	item          *bytes.Buffer
 	preparedInput *bytes.Buffer
+	footer        *bytes.Buffer
 }
 
 func acquireEntityFetchBuffer() *entityFetchBuffer {
@@ -1135,10 +1222,16 @@ func acquireEntityFetchBuffer() *entityFetchBuffer {
 func releaseEntityFetchBuffer(buf *entityFetchBuffer) {
 	buf.item.Reset()
 	buf.preparedInput.Reset()
+	buf.footer.Reset()
 	entityFetchPool.Put(buf)
 }
 
 func (l *Loader) loadEntityFetch(ctx context.Context, fetchItem *FetchItem, fetch *EntityFetch, items []*astjson.Value, res *result) error {
+	ctx, cancel := l.withFetchTimeout(ctx, fetch.Timeout, res)
+	defer cancel()
+	ctx, span := l.startFetchSpan(ctx, fetchKindEntity, fetch.Info)
+	defer l.endFetchSpan(span, res)
+	defer l.reportFetch(fetch.Info, time.Now(), res)
 	res.init(fetch.PostProcessing, fetch.Info)
 	buf := acquireEntityFetchBuffer()
 	defer releaseEntityFetchBuffer(buf)
@@ -1170,6 +1263,10 @@ func (l *Loader) loadEntityFetch(ctx context.Context, fetchItem *FetchItem, fetc
 		return errors.WithStack(err)
 	}
 	renderedItem := buf.item.Bytes()
+	span.SetAttributes(AttributeFetchEntityCount.Int(1))
+	if l.metrics != nil && !bytes.Equal(renderedItem, null) {
+		l.metrics.EntityBatchSize(fetch.Info, 1)
+	}
 	if bytes.Equal(renderedItem, null) {
 		// skip fetch if item is null
 		res.fetchSkipped = true
@@ -1188,11 +1285,19 @@ func (l *Loader) loadEntityFetch(ctx context.Context, fetchItem *FetchItem, fetc
 			return nil
 		}
 	}
-	_, _ = buf.item.WriteTo(buf.preparedInput)
-	err = fetch.Input.Footer.RenderAndCollectUndefinedVariables(l.ctx, nil, buf.preparedInput, &undefinedVariables)
+	// the footer is rendered separately, the header and footer identify the selection set for the entity cache
+	err = fetch.Input.Footer.RenderAndCollectUndefinedVariables(l.ctx, nil, buf.footer, &undefinedVariables)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	if cacheLoad := l.newEntityCacheLoad(fetch.Cache); cacheLoad != nil && !res.fetchSkipped {
+		cacheLoad.addRepresentation(renderedItem)
+		l.loadCachedEntities(ctx, cacheLoad, fetch.Info, buf.preparedInput.Bytes(), buf.footer.Bytes(), undefinedVariables)
+		cacheLoad.trace(fetch.Trace)
+		res.entityCache = cacheLoad
+	}
+	_, _ = buf.item.WriteTo(buf.preparedInput)
+	_, _ = buf.footer.WriteTo(buf.preparedInput)
 
 	err = SetInputUndefinedVariables(buf.preparedInput, undefinedVariables)
 	if err != nil {
@@ -1205,6 +1310,12 @@ func (l *Loader) loadEntityFetch(ctx context.Context, fetchItem *FetchItem, fetc
 		return nil
 	}
 
+	if res.entityCache != nil && res.entityCache.misses == 0 {
+		// all entities are cached, the subgraph isn't called but the fetch still has to be authorized
+		_, err = l.isFetchAuthorized(fetchInput, fetch.Info, res)
+		return err
+	}
+
 	allowed, err := l.validatePreFetch(fetchInput, fetch.Info, res)
 	if err != nil {
 		return err
@@ -1223,6 +1334,7 @@ var (
 type batchEntityFetchBuffer struct {
 	preparedInput *bytes.Buffer
 	itemInput     *bytes.Buffer
+	footer        *bytes.Buffer
 	keyGen        *xxhash.Digest
 }
 
@@ -1232,6 +1344,7 @@ func acquireBatchEntityFetchBuffer() *batchEntityFetchBuffer {
 		return &batchEntityFetchBuffer{
 			preparedInput: &bytes.Buffer{},
 			itemInput:     &bytes.Buffer{},
+			footer:        &bytes.Buffer{},
 			keyGen:        xxhash.New(),
 		}
 	}
@@ -1241,11 +1354,17 @@ func acquireBatchEntityFetchBuffer() *batchEntityFetchBuffer {
 func releaseBatchEntityFetchBuffer(buf *batchEntityFetchBuffer) {
 	buf.preparedInput.Reset()
 	buf.itemInput.Reset()
+	buf.footer.Reset()
 	buf.keyGen.Reset()
 	batchEntityFetchPool.Put(buf)
 }
 
 func (l *Loader) loadBatchEntityFetch(ctx context.Context, fetchItem *FetchItem, fetch *BatchEntityFetch, items []*astjson.Value, res *result) error {
+	ctx, cancel := l.withFetchTimeout(ctx, fetch.Timeout, res)
+	defer cancel()
+	ctx, span := l.startFetchSpan(ctx, fetchKindBatchEntity, fetch.Info)
+	defer l.endFetchSpan(span, res)
+	defer l.reportFetch(fetch.Info, time.Now(), res)
 	res.init(fetch.PostProcessing, fetch.Info)
 
 	buf := acquireBatchEntityFetchBuffer()
@@ -1271,6 +1390,7 @@ func (l *Loader) loadBatchEntityFetch(ctx context.Context, fetchItem *FetchItem,
 	itemHashes := make([]uint64, 0, len(items))
 	batchItemIndex := 0
 	addSeparator := false
+	cacheLoad := l.newEntityCacheLoad(fetch.Cache)
 
 WithNextItem:
 	for i, item := range items {
@@ -1307,6 +1427,13 @@ WithNextItem:
 				}
 			}
 			itemHashes = append(itemHashes, itemHash)
+			if cacheLoad != nil {
+				// representations are written once the cached entities are known
+				cacheLoad.addRepresentation(buf.itemInput.Bytes())
+				res.batchStats[i] = append(res.batchStats[i], batchItemIndex)
+				batchItemIndex++
+				continue
+			}
 			if addSeparator {
 				err = fetch.Input.Separator.Render(l.ctx, nil, buf.preparedInput)
 				if err != nil {
@@ -1320,6 +1447,10 @@ WithNextItem:
 		}
 	}
 
+	span.SetAttributes(AttributeFetchEntityCount.Int(len(itemHashes)))
+	if l.metrics != nil && len(itemHashes) != 0 {
+		l.metrics.EntityBatchSize(fetch.Info, len(itemHashes))
+	}
 	if len(itemHashes) == 0 {
 		// all items were skipped - discard fetch
 		res.fetchSkipped = true
@@ -1330,10 +1461,21 @@ WithNextItem:
 		}
 	}
 
-	err = fetch.Input.Footer.RenderAndCollectUndefinedVariables(l.ctx, nil, buf.preparedInput, &undefinedVariables)
+	// the footer is rendered separately, the header and footer identify the selection set for the entity cache
+	err = fetch.Input.Footer.RenderAndCollectUndefinedVariables(l.ctx, nil, buf.footer, &undefinedVariables)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	if cacheLoad != nil && len(itemHashes) != 0 {
+		l.loadCachedEntities(ctx, cacheLoad, fetch.Info, buf.preparedInput.Bytes(), buf.footer.Bytes(), undefinedVariables)
+		cacheLoad.trace(fetch.Trace)
+		res.entityCache = cacheLoad
+		err = l.writeMissingRepresentations(cacheLoad, &fetch.Input.Separator, buf.preparedInput)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	_, _ = buf.footer.WriteTo(buf.preparedInput)
 
 	err = SetInputUndefinedVariables(buf.preparedInput, undefinedVariables)
 	if err != nil {
@@ -1346,6 +1488,12 @@ WithNextItem:
 		return nil
 	}
 
+	if res.entityCache != nil && res.entityCache.misses == 0 {
+		// all entities are cached, the subgraph isn't called but the fetch still has to be authorized
+		_, err = l.isFetchAuthorized(fetchInput, fetch.Info, res)
+		return err
+	}
+
 	allowed, err := l.validatePreFetch(fetchInput, fetch.Info, res)
 	if err != nil {
 		return err
@@ -1432,7 +1580,33 @@ func (l *Loader) setTracingInput(fetchItem *FetchItem, input []byte, trace *Data
 	}
 }
 
+func (l *Loader) setTracingAttempts(attempts []httpclient.Attempt, trace *DataSourceLoadTrace) {
+	if len(attempts) == 0 {
+		return
+	}
+	trace.Attempts = make([]DataSourceLoadAttempt, len(attempts))
+	for i := range attempts {
+		trace.Attempts[i].StatusCode = attempts[i].StatusCode
+		if attempts[i].Err != nil {
+			trace.Attempts[i].Error = attempts[i].Err.Error()
+		}
+		if attempts[i].Backoff == 0 || l.ctx.TracingOptions.EnablePredictableDebugTimings {
+			continue
+		}
+		trace.Attempts[i].BackoffNano = attempts[i].Backoff.Nanoseconds()
+		trace.Attempts[i].BackoffPretty = attempts[i].Backoff.String()
+	}
+}
+
 func (l *Loader) loadByContext(ctx context.Context, source DataSource, input []byte, res *result) error {
+	if l.subgraphHandler != nil {
+		return l.subgraphHandler(ctx, &SubgraphRequest{
+			DataSource: res.ds,
+			Input:      input,
+			source:     source,
+			files:      l.ctx.Files,
+		}, res.out)
+	}
 	if l.ctx.Files != nil {
 		return source.LoadWithFiles(ctx, input, l.ctx.Files, res.out)
 	}
@@ -1447,8 +1621,18 @@ func (l *Loader) executeSourceLoad(ctx context.Context, fetchItem *FetchItem, so
 			return
 		}
 	}
+	if l.subgraphDeadlineHeader != "" {
+		input, res.err = l.setDeadlineHeader(ctx, input)
+		if res.err != nil {
+			res.err = errors.WithStack(res.err)
+			return
+		}
+	}
+	if l.ctx.TracingOptions.Enable || l.metrics != nil {
+		res.singleFlightStats = &SingleFlightStats{}
+		ctx = setSingleFlightStats(ctx, res.singleFlightStats)
+	}
 	if l.ctx.TracingOptions.Enable {
-		ctx = setSingleFlightStats(ctx, &SingleFlightStats{})
 		trace.Path = fetchItem.ResponsePath
 		if !l.ctx.TracingOptions.ExcludeInput {
 			trace.Input = make([]byte, len(input))
@@ -1465,6 +1649,9 @@ func (l *Loader) executeSourceLoad(ctx context.Context, fetchItem *FetchItem, so
 			copy(inputCopy, input)
 			input, _ = jsonparser.Set(inputCopy, []byte("true"), "__trace__")
 		}
+		if l.ctx.TracingOptions.EnablePredictableDebugTimings {
+			ctx = httpclient.WithPredictableTrace(ctx)
+		}
 		if !l.ctx.TracingOptions.ExcludeLoadStats {
 			trace.DurationSinceStartNano = GetDurationNanoSinceTraceStart(ctx)
 			trace.DurationSinceStartPretty = time.Duration(trace.DurationSinceStartNano).String()
@@ -1577,11 +1764,10 @@ func (l *Loader) executeSourceLoad(ctx context.Context, fetchItem *FetchItem, so
 	res.httpResponseContext = responseContext
 
 	if l.ctx.TracingOptions.Enable {
-		stats := GetSingleFlightStats(ctx)
-		if stats != nil {
-			trace.SingleFlightUsed = stats.SingleFlightUsed
-			trace.SingleFlightSharedResponse = stats.SingleFlightSharedResponse
-		}
+		trace.SingleFlightUsed = res.singleFlightStats.SingleFlightUsed
+		trace.SingleFlightSharedResponse = res.singleFlightStats.SingleFlightSharedResponse
+		trace.CircuitBreakerOpen = responseContext.CircuitOpen
+		l.setTracingAttempts(responseContext.Attempts, trace)
 		if !l.ctx.TracingOptions.ExcludeOutput && res.out.Len() > 0 {
 			trace.Output, _ = l.compactJSON(res.out.Bytes())
 			if l.ctx.TracingOptions.EnablePredictableDebugTimings {
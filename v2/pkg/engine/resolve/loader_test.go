@@ -1,14 +1,22 @@
 package resolve
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/buger/jsonparser"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/fastjsonext"
 )
 
@@ -969,3 +977,183 @@ func TestLoader_RedactHeaders(t *testing.T) {
 		t.Errorf("Incorrect fetch type")
 	}
 }
+
+type retryingDataSource struct{}
+
+func (retryingDataSource) Load(ctx context.Context, input []byte, out *bytes.Buffer) error {
+	responseContext, _ := httpclient.ResponseContextFromContext(ctx)
+	responseContext.StatusCode = http.StatusOK
+	responseContext.Attempts = []httpclient.Attempt{
+		{StatusCode: http.StatusServiceUnavailable},
+		{Err: errors.New("connection reset by peer"), Backoff: time.Millisecond},
+		{StatusCode: http.StatusOK, Backoff: 2 * time.Millisecond},
+	}
+	_, err := out.WriteString(`{"data":{"name":"Table"}}`)
+	return err
+}
+
+func (retryingDataSource) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) error {
+	return errors.New("not implemented")
+}
+
+func TestLoader_TraceAttempts(t *testing.T) {
+	response := &GraphQLResponse{
+		Fetches: Single(&SingleFetch{
+			InputTemplate: InputTemplate{
+				Segments: []TemplateSegment{
+					{
+						Data:        []byte(`{"method":"POST","url":"http://products","body":{"query":"query{product{name}}"}}`),
+						SegmentType: StaticSegmentType,
+					},
+				},
+			},
+			FetchConfiguration: FetchConfiguration{
+				DataSource: retryingDataSource{},
+				PostProcessing: PostProcessingConfiguration{
+					SelectResponseDataPath: []string{"data"},
+				},
+			},
+		}),
+		Data: &Object{
+			Fields: []*Field{
+				{
+					Name: []byte("product"),
+					Value: &Object{
+						Path: []string{"product"},
+						Fields: []*Field{
+							{
+								Name: []byte("name"),
+								Value: &String{
+									Path: []string{"name"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ctx := &Context{
+		ctx: context.Background(),
+		TracingOptions: TraceOptions{
+			Enable: true,
+		},
+	}
+	resolvable := NewResolvable(ResolvableOptions{})
+	loader := &Loader{}
+
+	err := resolvable.Init(ctx, nil, ast.OperationTypeQuery)
+	require.NoError(t, err)
+
+	err = loader.LoadGraphQLResponseData(ctx, response, resolvable)
+	require.NoError(t, err)
+
+	trace := response.Fetches.Item.Fetch.(*SingleFetch).Trace
+	assert.Equal(t, []DataSourceLoadAttempt{
+		{StatusCode: http.StatusServiceUnavailable},
+		{Error: "connection reset by peer", BackoffNano: int64(time.Millisecond), BackoffPretty: "1ms"},
+		{StatusCode: http.StatusOK, BackoffNano: int64(2 * time.Millisecond), BackoffPretty: "2ms"},
+	}, trace.Attempts)
+	assert.False(t, trace.CircuitBreakerOpen)
+}
+
+// deadlineDataSource waits for the deadline of the fetch unless the input contains data
+type deadlineDataSource struct {
+	input []byte
+}
+
+func (d *deadlineDataSource) Load(ctx context.Context, input []byte, out *bytes.Buffer) error {
+	d.input = input
+	if data, _, _, err := jsonparser.Get(input, "data"); err == nil {
+		_, err = out.Write(data)
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (d *deadlineDataSource) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) error {
+	return errors.New("not implemented")
+}
+
+func TestLoader_FetchTimeout(t *testing.T) {
+	response := func(dataSource DataSource, input string, timeout time.Duration) *GraphQLResponse {
+		return &GraphQLResponse{
+			Info: &GraphQLResponseInfo{
+				OperationType: ast.OperationTypeQuery,
+			},
+			Fetches: SingleWithPath(&SingleFetch{
+				InputTemplate: InputTemplate{
+					Segments: []TemplateSegment{
+						{
+							Data:        []byte(input),
+							SegmentType: StaticSegmentType,
+						},
+					},
+				},
+				FetchConfiguration: FetchConfiguration{
+					DataSource: dataSource,
+					PostProcessing: PostProcessingConfiguration{
+						SelectResponseDataPath: []string{"data"},
+					},
+					Timeout: timeout,
+				},
+				Info: &FetchInfo{
+					DataSourceID:   "reports",
+					DataSourceName: "reports",
+				},
+			}, "query"),
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name: []byte("report"),
+						Value: &String{
+							Path:     []string{"report"},
+							Nullable: true,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("exceeded timeout is rendered as reason", func(t *testing.T) {
+		resolver := newResolver(context.Background())
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), response(&deadlineDataSource{}, `{"url":"http://reports"}`, 10*time.Millisecond), nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, `{"errors":[{"message":"Failed to fetch from Subgraph 'reports' at Path 'query', Reason: timeout of 10ms exceeded."}],"data":{"report":null}}`, out.String())
+	})
+
+	t.Run("deadline is forwarded to the subgraph", func(t *testing.T) {
+		resolver := New(context.Background(), ResolverOptions{
+			MaxConcurrency:         1024,
+			SubgraphDeadlineHeader: "X-Request-Timeout-Ms",
+		})
+		dataSource := &deadlineDataSource{}
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), response(dataSource, `{"url":"http://reports","data":{"data":{"report":"done"}}}`, time.Minute), nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"report":"done"}}`, out.String())
+
+		header, err := jsonparser.GetString(dataSource.input, "header", "X-Request-Timeout-Ms", "[0]")
+		require.NoError(t, err)
+		remaining, err := strconv.ParseInt(header, 10, 64)
+		require.NoError(t, err)
+		assert.Greater(t, remaining, int64(59_000))
+		assert.LessOrEqual(t, remaining, int64(60_000))
+	})
+
+	t.Run("deadline is not forwarded without a deadline", func(t *testing.T) {
+		resolver := New(context.Background(), ResolverOptions{
+			MaxConcurrency:         1024,
+			SubgraphDeadlineHeader: "X-Request-Timeout-Ms",
+		})
+		dataSource := &deadlineDataSource{}
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), response(dataSource, `{"url":"http://reports","data":{"data":{"report":"done"}}}`, 0), nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, `{"url":"http://reports","data":{"data":{"report":"done"}}}`, string(dataSource.input))
+	})
+}
@@ -0,0 +1,51 @@
+package resolve
+
+import (
+	"time"
+)
+
+// Metrics receives the metrics of the engine, it complements the Reporter of the subscriptions
+// All methods are called concurrently, implementations must be safe for concurrent use
+type Metrics interface {
+	// FetchDuration is called when a fetch of a datasource is done, skipped fetches are not reported
+	// info is nil if the planner didn't add the FetchInfo to the fetch
+	FetchDuration(info *FetchInfo, duration time.Duration, err error)
+	// EntityBatchSize is called with the number of entities requested by an _entities fetch
+	EntityBatchSize(info *FetchInfo, size int)
+	// SingleFlight is called after a fetch with the SingleFlightStats the transport of the datasource recorded in the context
+	SingleFlight(info *FetchInfo, stats SingleFlightStats)
+	// PlanCacheLookup is called with the outcome of a lookup in the execution plan cache
+	PlanCacheLookup(hit bool)
+	// PlanningDuration is called with the time it took to plan an operation that wasn't cached
+	PlanningDuration(duration time.Duration)
+	// ResponseSize is called with the number of bytes written for a response
+	ResponseSize(size int)
+	// Errors is called with the number of errors of a response that share the same code, e.g. errorcodes.InvalidGraphql
+	// Errors without a code are reported with an empty code
+	Errors(code string, count int)
+}
+
+// reportFetch reports the duration, and if the datasource recorded them, the single flight stats of a fetch
+func (l *Loader) reportFetch(info *FetchInfo, start time.Time, res *result) {
+	if l.metrics == nil || res.fetchSkipped {
+		return
+	}
+	l.metrics.FetchDuration(info, time.Since(start), res.err)
+	if res.singleFlightStats != nil && res.singleFlightStats.SingleFlightUsed {
+		l.metrics.SingleFlight(info, *res.singleFlightStats)
+	}
+}
+
+// reportErrors reports the errors of the response grouped by their extensions code
+func (r *Resolvable) reportErrors(metrics Metrics) {
+	if metrics == nil || !r.hasErrors() {
+		return
+	}
+	counts := make(map[string]int)
+	for _, value := range r.errors.GetArray() {
+		counts[string(value.GetStringBytes("extensions", "code"))]++
+	}
+	for code, count := range counts {
+		metrics.Errors(code, count)
+	}
+}
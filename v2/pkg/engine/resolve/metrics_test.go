@@ -0,0 +1,337 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+)
+
+type testMetrics struct {
+	mu               sync.Mutex
+	fetches          []string
+	entityBatchSizes map[string][]int
+	singleFlight     []SingleFlightStats
+	responseSizes    []int
+	errors           map[string]int
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{
+		entityBatchSizes: map[string][]int{},
+		errors:           map[string]int{},
+	}
+}
+
+func (m *testMetrics) FetchDuration(info *FetchInfo, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches = append(m.fetches, info.DataSourceName)
+}
+
+func (m *testMetrics) EntityBatchSize(info *FetchInfo, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entityBatchSizes[info.DataSourceName] = append(m.entityBatchSizes[info.DataSourceName], size)
+}
+
+func (m *testMetrics) SingleFlight(info *FetchInfo, stats SingleFlightStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.singleFlight = append(m.singleFlight, stats)
+}
+
+func (m *testMetrics) PlanCacheLookup(hit bool) {}
+
+func (m *testMetrics) PlanningDuration(duration time.Duration) {}
+
+func (m *testMetrics) ResponseSize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responseSizes = append(m.responseSizes, size)
+}
+
+func (m *testMetrics) Errors(code string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[code] += count
+}
+
+// singleFlightDataSource records single flight stats the way a deduplicating transport would
+type singleFlightDataSource struct {
+	DataSource
+}
+
+func (s *singleFlightDataSource) Load(ctx context.Context, input []byte, out *bytes.Buffer) error {
+	if stats := GetSingleFlightStats(ctx); stats != nil {
+		stats.SingleFlightUsed = true
+		stats.SingleFlightSharedResponse = true
+	}
+	return s.DataSource.Load(ctx, input, out)
+}
+
+func TestResolver_Metrics(t *testing.T) {
+	t.Run("fetches, response size and errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		metrics := newTestMetrics()
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := New(rCtx, ResolverOptions{
+			MaxConcurrency: 32,
+			Metrics:        metrics,
+		})
+
+		limiter := &testRateLimiter{
+			allowFn: func(ctx *Context, info *FetchInfo, input json.RawMessage) (*RateLimitDeny, error) {
+				if info.DataSourceID == "products" {
+					return &RateLimitDeny{Reason: "rate limit exceeded"}, nil
+				}
+				return nil, nil
+			},
+		}
+		ctx := NewContext(context.Background())
+		ctx.RateLimitOptions = RateLimitOptions{Enable: true}
+		ctx.SetRateLimiter(limiter)
+
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(ctx, generateTestFederationGraphQLResponse(t, ctrl), nil, out)
+		require.NoError(t, err)
+
+		// the products fetch is rejected by the rate limiter, so it's skipped
+		assert.ElementsMatch(t, []string{"users", "reviews"}, metrics.fetches)
+		assert.Empty(t, metrics.entityBatchSizes)
+		assert.Empty(t, metrics.singleFlight)
+		assert.Equal(t, []int{out.Len()}, metrics.responseSizes)
+		assert.Equal(t, map[string]int{"": 1}, metrics.errors)
+	})
+
+	t.Run("incremental responses", func(t *testing.T) {
+		metrics := newTestMetrics()
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := New(rCtx, ResolverOptions{
+			MaxConcurrency: 32,
+			Metrics:        metrics,
+		})
+
+		nameDefer := &DeferField{Label: "name", FetchIDs: []int{1}}
+		response := &GraphQLResponse{
+			Info: &GraphQLResponseInfo{OperationType: ast.OperationTypeQuery},
+			Fetches: Single(&SingleFetch{
+				FetchConfiguration: FetchConfiguration{
+					DataSource: FakeDataSource(`{"data":{"user":{"id":"1"}}}`),
+					PostProcessing: PostProcessingConfiguration{
+						SelectResponseDataPath: []string{"data"},
+					},
+				},
+				Info: &FetchInfo{DataSourceID: "users", DataSourceName: "users"},
+			}),
+			DeferredFragments: []*DeferredFragment{
+				{
+					Defer: nameDefer,
+					Path:  []FetchItemPathElement{ObjectPath("user")},
+					Data: &Object{
+						Fields: []*Field{
+							{
+								Name:  []byte("name"),
+								Value: &String{Path: []string{"name"}, Nullable: true},
+								Defer: nameDefer,
+							},
+						},
+					},
+					Fetches: Single(&SingleFetch{
+						FetchConfiguration: FetchConfiguration{
+							DataSource: FakeDataSource(`{"errors":[{"message":"unavailable"}]}`),
+							PostProcessing: PostProcessingConfiguration{
+								SelectResponseDataPath:   []string{"data"},
+								SelectResponseErrorsPath: []string{"errors"},
+							},
+						},
+						Info: &FetchInfo{DataSourceID: "names", DataSourceName: "names"},
+					}, ObjectPath("user")),
+				},
+			},
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name: []byte("user"),
+						Value: &Object{
+							Path: []string{"user"},
+							Fields: []*Field{
+								{
+									Name:  []byte("id"),
+									Value: &String{Path: []string{"id"}},
+								},
+								{
+									Name:  []byte("name"),
+									Value: &String{Path: []string{"name"}, Nullable: true},
+									Defer: nameDefer,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		ctx := NewContext(context.Background())
+		ctx.ExecutionOptions.EnableIncrementalDelivery = true
+		out := &SubscriptionRecorder{buf: &bytes.Buffer{}}
+		_, err := resolver.ResolveGraphQLResponse(ctx, response, nil, out)
+		require.NoError(t, err)
+
+		messages := out.Messages()
+		require.Len(t, messages, 2)
+		assert.Contains(t, messages[1], `"errors"`)
+		assert.ElementsMatch(t, []string{"users", "names"}, metrics.fetches)
+		// the size of the response is the size of all payloads
+		assert.Equal(t, []int{len(messages[0]) + len(messages[1])}, metrics.responseSizes)
+		assert.Equal(t, map[string]int{"": 1}, metrics.errors)
+	})
+
+	t.Run("entity batch sizes and single flight stats", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		metrics := newTestMetrics()
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := New(rCtx, ResolverOptions{
+			MaxConcurrency: 32,
+			Metrics:        metrics,
+		})
+
+		reviewsService := mockedDS(t, ctrl,
+			`{"method":"POST","url":"http://reviews","body":{"query":"{reviews {author {__typename id}}}"}}`,
+			`{"reviews":[{"author":{"__typename":"User","id":"1"}},{"author":{"__typename":"User","id":"2"}},{"author":{"__typename":"User","id":"1"}}]}`)
+		usersService := mockedDS(t, ctrl,
+			`{"method":"POST","url":"http://users","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on User {name}}}","variables":{"representations":[{"__typename":"User","id":"1"},{"__typename":"User","id":"2"}]}}}`,
+			`{"_entities":[{"name":"Jens"},{"name":"Nithin"}]}`)
+
+		response := &GraphQLResponse{
+			Info: &GraphQLResponseInfo{OperationType: ast.OperationTypeQuery},
+			Fetches: Sequence(
+				Single(&SingleFetch{
+					InputTemplate: InputTemplate{
+						Segments: []TemplateSegment{
+							{
+								Data:        []byte(`{"method":"POST","url":"http://reviews","body":{"query":"{reviews {author {__typename id}}}"}}`),
+								SegmentType: StaticSegmentType,
+							},
+						},
+					},
+					FetchConfiguration: FetchConfiguration{
+						DataSource: &singleFlightDataSource{DataSource: reviewsService},
+						PostProcessing: PostProcessingConfiguration{
+							SelectResponseDataPath: []string{"data"},
+						},
+					},
+					Info: &FetchInfo{DataSourceID: "reviews", DataSourceName: "reviews"},
+				}),
+				Single(&BatchEntityFetch{
+					Input: BatchInput{
+						Header: InputTemplate{
+							Segments: []TemplateSegment{
+								{
+									Data:        []byte(`{"method":"POST","url":"http://users","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on User {name}}}","variables":{"representations":[`),
+									SegmentType: StaticSegmentType,
+								},
+							},
+						},
+						Items: []InputTemplate{
+							{
+								Segments: []TemplateSegment{
+									{
+										SegmentType:  VariableSegmentType,
+										VariableKind: ResolvableObjectVariableKind,
+										Renderer: NewGraphQLVariableResolveRenderer(&Object{
+											Fields: []*Field{
+												{
+													Name:  []byte("__typename"),
+													Value: &String{Path: []string{"__typename"}},
+												},
+												{
+													Name:  []byte("id"),
+													Value: &String{Path: []string{"id"}},
+												},
+											},
+										}),
+									},
+								},
+							},
+						},
+						Separator: InputTemplate{
+							Segments: []TemplateSegment{
+								{
+									Data:        []byte(`,`),
+									SegmentType: StaticSegmentType,
+								},
+							},
+						},
+						Footer: InputTemplate{
+							Segments: []TemplateSegment{
+								{
+									Data:        []byte(`]}}}`),
+									SegmentType: StaticSegmentType,
+								},
+							},
+						},
+					},
+					DataSource: usersService,
+					PostProcessing: PostProcessingConfiguration{
+						SelectResponseDataPath: []string{"data", "_entities"},
+					},
+					Info: &FetchInfo{DataSourceID: "users", DataSourceName: "users"},
+				}, ArrayPath("reviews"), ObjectPath("author")),
+			),
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name: []byte("reviews"),
+						Value: &Array{
+							Path: []string{"reviews"},
+							Item: &Object{
+								Fields: []*Field{
+									{
+										Name: []byte("author"),
+										Value: &Object{
+											Path: []string{"author"},
+											Fields: []*Field{
+												{
+													Name:  []byte("name"),
+													Value: &String{Path: []string{"name"}},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), response, nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"reviews":[{"author":{"name":"Jens"}},{"author":{"name":"Nithin"}},{"author":{"name":"Jens"}}]}}`, out.String())
+
+		assert.Equal(t, []string{"reviews", "users"}, metrics.fetches)
+		// the duplicated representation of the user is only sent once
+		assert.Equal(t, map[string][]int{"users": {2}}, metrics.entityBatchSizes)
+		assert.Equal(t, []SingleFlightStats{{SingleFlightUsed: true, SingleFlightSharedResponse: true}}, metrics.singleFlight)
+		assert.Equal(t, []int{out.Len()}, metrics.responseSizes)
+		assert.Empty(t, metrics.errors)
+	})
+}
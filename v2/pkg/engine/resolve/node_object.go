@@ -90,11 +90,13 @@ func (_ *EmptyObject) Copy() Node {
 }
 
 type Field struct {
-	Name              []byte
-	Value             Node
-	Position          Position
-	Defer             *DeferField
-	Stream            *StreamField
+	Name     []byte
+	Value    Node
+	Position Position
+	Defer    *DeferField
+	Stream   *StreamField
+	// CacheControl is the cache hint of the field, it's nil when the field inherits the max age of its parent
+	CacheControl      *CacheControlPolicy
 	OnTypeNames       [][]byte
 	ParentOnTypeNames []ParentOnTypeNames
 	Info              *FieldInfo
@@ -107,13 +109,14 @@ type ParentOnTypeNames struct {
 
 func (f *Field) Copy() *Field {
 	return &Field{
-		Name:        f.Name,
-		Value:       f.Value.Copy(),
-		Position:    f.Position,
-		Defer:       f.Defer,
-		Stream:      f.Stream,
-		OnTypeNames: f.OnTypeNames,
-		Info:        f.Info,
+		Name:         f.Name,
+		Value:        f.Value.Copy(),
+		Position:     f.Position,
+		Defer:        f.Defer,
+		Stream:       f.Stream,
+		CacheControl: f.CacheControl,
+		OnTypeNames:  f.OnTypeNames,
+		Info:         f.Info,
 	}
 }
 
@@ -179,7 +182,21 @@ type Position struct {
 }
 
 type StreamField struct {
+	// InitialBatchSize is the initialCount argument of the @stream directive
+	// The first InitialBatchSize items are rendered with the initial payload, the remaining items are streamed
 	InitialBatchSize int
+	// Label is the optional label argument of the @stream directive
+	Label string
+	// FetchIDs are the ids of the fetches which are only needed to resolve the streamed items
+	// They are only set when InitialBatchSize is 0, otherwise the initial items depend on the same fetches
+	FetchIDs []int
 }
 
-type DeferField struct{}
+type DeferField struct {
+	// Label is the optional label argument of the @defer directive
+	// It is rendered alongside the incremental payload of the deferred fragment
+	Label string
+	// FetchIDs are the ids of the fetches which are only needed to resolve the deferred fragment
+	// The planner moves these fetches out of the initial fetch tree, so they are loaded after the initial payload was flushed
+	FetchIDs []int
+}
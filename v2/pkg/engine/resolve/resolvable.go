@@ -7,6 +7,7 @@ import (
 	goerrors "errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 
 	"github.com/cespare/xxhash/v2"
@@ -56,6 +57,22 @@ type Resolvable struct {
 	marshalBuf []byte
 
 	enclosingTypeNames []string
+
+	// incremental is set when deferred fields are rendered as incremental payloads instead of inline
+	incremental bool
+	// currentDefer is the deferred fragment which is currently rendered
+	// fields of other deferred fragments are skipped
+	currentDefer *DeferField
+	// streams are the @stream lists of the initial payload with items left to stream
+	streams []streamedList
+	// currentStream is set while a streamed item is rendered, nested streams are rendered inline
+	currentStream *StreamField
+
+	// cacheControl is the policy of all resolved fields with a cache hint and all subgraph responses
+	cacheControl    CacheControlPolicy
+	hasCacheControl bool
+	// hadIncrementalErrors is set when a flushed incremental payload had errors
+	hadIncrementalErrors bool
 }
 
 type ResolvableOptions struct {
@@ -94,6 +111,13 @@ func (r *Resolvable) Reset() {
 	r.operationType = ast.OperationTypeUnknown
 	r.renameTypeNames = r.renameTypeNames[:0]
 	r.authorizationError = nil
+	r.incremental = false
+	r.currentDefer = nil
+	r.streams = r.streams[:0]
+	r.currentStream = nil
+	r.cacheControl = CacheControlPolicy{}
+	r.hasCacheControl = false
+	r.hadIncrementalErrors = false
 	r.astjsonArena.Reset()
 	r.xxh.Reset()
 	for k := range r.authorizationAllow {
@@ -220,10 +244,300 @@ func (r *Resolvable) Resolve(ctx context.Context, rootData *Object, fetchTree *F
 		r.printBytes(comma)
 		r.printErr = r.printExtensions(ctx, fetchTree)
 	}
+	if r.incremental {
+		r.printBytes(comma)
+		r.printHasNext(true)
+	}
+	r.printBytes(rBrace)
+	return r.printErr
+}
+
+// EnableIncrementalDelivery makes Resolve skip all deferred fields and streamed list items
+// The deferred fields are rendered afterwards with ResolveDeferredFragment,
+// the streamed items with ResolveStreamedItem
+func (r *Resolvable) EnableIncrementalDelivery() {
+	r.incremental = true
+}
+
+// resetIncrementalErrors clears the errors of the flushed incremental payload before the next payload is loaded
+func (r *Resolvable) resetIncrementalErrors() {
+	r.hadIncrementalErrors = r.hadIncrementalErrors || r.hasErrors()
+	r.errors = r.astjsonArena.NewArray()
+}
+
+type streamedList struct {
+	stream    *StreamField
+	array     *Array
+	value     *astjson.Value
+	path      []fastjsonext.PathElement
+	typeNames [][]byte
+	depth     int
+}
+
+// StreamedItemCount returns the number of items which have to be rendered with ResolveStreamedItem
+// It's only valid after Resolve was called with incremental delivery enabled
+func (r *Resolvable) StreamedItemCount() int {
+	count := 0
+	for i := range r.streams {
+		count += len(r.streams[i].value.GetArray()) - r.streams[i].stream.InitialBatchSize
+	}
+	return count
+}
+
+// ResolveStreamedItem renders the incremental payload of the n-th streamed item
+// The items of all streams are numbered in the order the streams appear in the initial payload
+func (r *Resolvable) ResolveStreamedItem(n int, hasNext bool, out io.Writer) error {
+	r.out = out
+	r.print = false
+	r.printErr = nil
+	r.authorizationError = nil
+	r.skipAddingNullErrors = false
+
+	var list streamedList
+	index := n
+	for i := range r.streams {
+		remaining := len(r.streams[i].value.GetArray()) - r.streams[i].stream.InitialBatchSize
+		if index < remaining {
+			list = r.streams[i]
+			index += r.streams[i].stream.InitialBatchSize
+			break
+		}
+		index -= remaining
+	}
+	if list.stream == nil {
+		return fmt.Errorf("streamed item %d out of range", n)
+	}
+
+	r.currentStream = list.stream
+	r.path = append(r.path[:0], list.path...)
+	r.typeNames = append(r.typeNames[:0], list.typeNames...)
+	r.depth = list.depth
+	defer func() {
+		r.currentStream = nil
+		r.path = r.path[:0]
+		r.typeNames = r.typeNames[:0]
+		r.depth = 0
+	}()
+
+	item := list.value.GetArray()[index]
+	r.pushArrayPathElement(index)
+	hasErrors := r.walkNode(list.array.Item, item)
+	if r.authorizationError != nil {
+		return r.authorizationError
+	}
+	if hasErrors && list.array.Item.NodeNullable() {
+		list.value.SetArrayItem(index, astjson.NullValue)
+		item = astjson.NullValue
+		hasErrors = false
+	}
+
+	r.printBytes(lBrace)
+	r.printBytes(quote)
+	r.printBytes(literalIncremental)
+	r.printBytes(quote)
+	r.printBytes(colon)
+	r.printBytes(lBrack)
+	r.printBytes(lBrace)
+	if r.hasErrors() {
+		r.printErrors()
+	}
+	r.printBytes(quote)
+	r.printBytes(literalItems)
+	r.printBytes(quote)
+	r.printBytes(colon)
+	if hasErrors {
+		r.printBytes(null)
+	} else {
+		r.printBytes(lBrack)
+		r.print = true
+		_ = r.walkNode(list.array.Item, item)
+		r.print = false
+		r.printBytes(rBrack)
+	}
+	r.printBytes(comma)
+	r.printIncrementalPath(r.path)
+	r.printIncrementalLabel(list.stream.Label)
+	r.printBytes(rBrace)
+	r.printBytes(rBrack)
+	r.printBytes(comma)
+	r.printHasNext(hasNext)
 	r.printBytes(rBrace)
 	return r.printErr
 }
 
+// streamEnabled returns the stream of a field, when only the initial items of the list are rendered with the current payload
+func (r *Resolvable) streamEnabled(field *Field) *StreamField {
+	if !r.incremental || field.Stream == nil || r.currentDefer != nil || r.currentStream != nil {
+		return nil
+	}
+	if field.Value.NodeKind() != NodeKindArray {
+		return nil
+	}
+	return field.Stream
+}
+
+type deferredItem struct {
+	value     *astjson.Value
+	path      []fastjsonext.PathElement
+	typeNames [][]byte
+}
+
+// ResolveDeferredFragment renders the incremental payload of a deferred fragment
+// It renders one incremental entry per parent object selected by the path of the fragment
+// Errors added while loading the fragment are attached to the first entry
+func (r *Resolvable) ResolveDeferredFragment(fragment *DeferredFragment, hasNext bool, out io.Writer) error {
+	r.out = out
+	r.print = false
+	r.printErr = nil
+	r.authorizationError = nil
+	r.skipAddingNullErrors = false
+	r.currentDefer = fragment.Defer
+	defer func() {
+		r.currentDefer = nil
+		r.path = r.path[:0]
+		r.typeNames = r.typeNames[:0]
+	}()
+
+	items := r.selectDeferredItems(fragment.Path)
+
+	r.printBytes(lBrace)
+	if len(items) != 0 {
+		r.printBytes(quote)
+		r.printBytes(literalIncremental)
+		r.printBytes(quote)
+		r.printBytes(colon)
+		r.printBytes(lBrack)
+		for i := range items {
+			if i != 0 {
+				r.printBytes(comma)
+				r.errors = r.astjsonArena.NewArray()
+			}
+			err := r.resolveDeferredItem(fragment, items[i])
+			if err != nil {
+				return err
+			}
+		}
+		r.printBytes(rBrack)
+		r.printBytes(comma)
+	}
+	r.printHasNext(hasNext)
+	r.printBytes(rBrace)
+	return r.printErr
+}
+
+func (r *Resolvable) resolveDeferredItem(fragment *DeferredFragment, item deferredItem) error {
+	r.path = append(r.path[:0], item.path...)
+	r.typeNames = append(r.typeNames[:0], item.typeNames...)
+	r.print = false
+	hasErrors := r.walkObject(fragment.Data, item.value)
+	if r.authorizationError != nil {
+		return r.authorizationError
+	}
+	r.printBytes(lBrace)
+	if r.hasErrors() {
+		r.printErrors()
+	}
+	r.printBytes(quote)
+	r.printBytes(literalData)
+	r.printBytes(quote)
+	r.printBytes(colon)
+	if hasErrors {
+		r.printBytes(null)
+	} else {
+		r.printBytes(lBrace)
+		r.print = true
+		_ = r.walkObject(fragment.Data, item.value)
+		r.print = false
+		r.printBytes(rBrace)
+	}
+	r.printBytes(comma)
+	r.printIncrementalPath(item.path)
+	r.printIncrementalLabel(fragment.Defer.Label)
+	r.printBytes(rBrace)
+	return nil
+}
+
+func (r *Resolvable) printIncrementalPath(path []fastjsonext.PathElement) {
+	r.printBytes(quote)
+	r.printBytes(literalPath)
+	r.printBytes(quote)
+	r.printBytes(colon)
+	r.printBytes(lBrack)
+	for i := range path {
+		if i != 0 {
+			r.printBytes(comma)
+		}
+		if path[i].Name != "" {
+			r.printBytes(quote)
+			r.printBytes(unsafebytes.StringToBytes(path[i].Name))
+			r.printBytes(quote)
+			continue
+		}
+		r.printBytes(unsafebytes.StringToBytes(strconv.Itoa(path[i].Idx)))
+	}
+	r.printBytes(rBrack)
+}
+
+func (r *Resolvable) printIncrementalLabel(label string) {
+	if label == "" {
+		return
+	}
+	r.printBytes(comma)
+	r.printBytes(quote)
+	r.printBytes(literalLabel)
+	r.printBytes(quote)
+	r.printBytes(colon)
+	r.marshalBuf = r.astjsonArena.NewString(label).MarshalTo(r.marshalBuf[:0])
+	r.printBytes(r.marshalBuf)
+}
+
+func (r *Resolvable) printHasNext(hasNext bool) {
+	r.printBytes(quote)
+	r.printBytes(literalHasNext)
+	r.printBytes(quote)
+	r.printBytes(colon)
+	if hasNext {
+		r.printBytes(literalTrue)
+		return
+	}
+	r.printBytes(literalFalse)
+}
+
+// selectDeferredItems returns the parent objects of a deferred fragment together with their response path
+// and the __typename of all enclosing objects, which is required to evaluate parent type conditions
+func (r *Resolvable) selectDeferredItems(path []FetchItemPathElement) []deferredItem {
+	items := []deferredItem{{value: r.data}}
+	for i := range path {
+		selected := make([]deferredItem, 0, len(items))
+		for _, item := range items {
+			value := item.value.Get(path[i].Path...)
+			if value == nil {
+				continue
+			}
+			itemPath := make([]fastjsonext.PathElement, len(item.path), len(item.path)+len(path[i].Path)+1)
+			copy(itemPath, item.path)
+			for _, name := range path[i].Path {
+				itemPath = append(itemPath, fastjsonext.PathElement{Name: name})
+			}
+			typeNames := append(item.typeNames[:len(item.typeNames):len(item.typeNames)], item.value.GetStringBytes("__typename"))
+			switch value.Type() {
+			case astjson.TypeObject:
+				selected = append(selected, deferredItem{value: value, path: itemPath, typeNames: typeNames})
+			case astjson.TypeArray:
+				for j, arrayItem := range value.GetArray() {
+					if arrayItem.Type() != astjson.TypeObject {
+						continue
+					}
+					arrayItemPath := append(itemPath[:len(itemPath):len(itemPath)], fastjsonext.PathElement{Idx: j})
+					selected = append(selected, deferredItem{value: arrayItem, path: arrayItemPath, typeNames: typeNames})
+				}
+			}
+		}
+		items = selected
+	}
+	return items
+}
+
 func (r *Resolvable) enclosingTypeName() string {
 	if len(r.enclosingTypeNames) > 0 {
 		return r.enclosingTypeNames[len(r.enclosingTypeNames)-1]
@@ -559,6 +873,10 @@ func (r *Resolvable) walkObject(obj *Object, parent *astjson.Value) bool {
 		r.typeNames = r.typeNames[:len(r.typeNames)-1]
 	}()
 	for i := range obj.Fields {
+		if r.incremental && obj.Fields[i].Defer != nil && obj.Fields[i].Defer != r.currentDefer {
+			// the field is part of a deferred fragment which is rendered in a separate payload
+			continue
+		}
 		if obj.Fields[i].ParentOnTypeNames != nil {
 			if r.skipFieldOnParentTypeNames(obj.Fields[i]) {
 				continue
@@ -602,7 +920,15 @@ func (r *Resolvable) walkObject(obj *Object, parent *astjson.Value) bool {
 			r.printBytes(quote)
 			r.printBytes(colon)
 		}
-		err := r.walkNode(obj.Fields[i].Value, value)
+		if obj.Fields[i].CacheControl != nil {
+			r.restrictCacheControl(obj.Fields[i].CacheControl)
+		}
+		var err bool
+		if stream := r.streamEnabled(obj.Fields[i]); stream != nil {
+			err = r.walkStreamedArray(obj.Fields[i].Value.(*Array), value, stream)
+		} else {
+			err = r.walkNode(obj.Fields[i].Value, value)
+		}
 		if err {
 			if obj.Nullable {
 				if len(obj.Path) > 0 {
@@ -745,6 +1071,12 @@ func (r *Resolvable) skipFieldOnTypeNames(field *Field) bool {
 }
 
 func (r *Resolvable) walkArray(arr *Array, value *astjson.Value) bool {
+	return r.walkStreamedArray(arr, value, nil)
+}
+
+// walkStreamedArray walks an array, if stream is set only the initial items are walked
+// The remaining items are recorded during the print walk to be rendered with ResolveStreamedItem
+func (r *Resolvable) walkStreamedArray(arr *Array, value *astjson.Value, stream *StreamField) bool {
 	parent := value
 	value = value.Get(arr.Path...)
 	if astjson.ValueIsNull(value) {
@@ -764,6 +1096,19 @@ func (r *Resolvable) walkArray(arr *Array, value *astjson.Value) bool {
 		r.printBytes(lBrack)
 	}
 	values := value.GetArray()
+	if stream != nil && stream.InitialBatchSize < len(values) {
+		if r.print {
+			r.streams = append(r.streams, streamedList{
+				stream:    stream,
+				array:     arr,
+				value:     value,
+				path:      slices.Clone(r.path),
+				typeNames: slices.Clone(r.typeNames),
+				depth:     r.depth,
+			})
+		}
+		values = values[:stream.InitialBatchSize]
+	}
 	for i, arrayValue := range values {
 		if r.print && i != 0 {
 			r.printBytes(comma)
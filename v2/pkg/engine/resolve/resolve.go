@@ -14,6 +14,7 @@ import (
 
 	"github.com/buger/jsonparser"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/xcontext"
@@ -68,6 +69,8 @@ type Resolver struct {
 	propagateSubgraphErrors       bool
 	propagateSubgraphStatusCodes  bool
 	multipartSubHeartbeatInterval time.Duration
+
+	tracer trace.Tracer
 }
 
 func (r *Resolver) SetAsyncErrorWriter(w AsyncErrorWriter) {
@@ -145,6 +148,22 @@ type ResolverOptions struct {
 	AllowedSubgraphErrorFields []string
 	// MultipartSubHeartbeatInterval defines the interval in which a heartbeat is sent to all multipart subscriptions
 	MultipartSubHeartbeatInterval time.Duration
+	// EntityCache caches the entities of entity fetches with an EntityCacheConfiguration
+	// If nil, entities are always fetched from the subgraphs
+	EntityCache EntityCache
+	// SubgraphDeadlineHeader is the name of the header which forwards the time remaining until the deadline of a fetch
+	// in milliseconds to the subgraphs, e.g. X-Request-Timeout-Ms. The deadline is the earlier of the deadline of the
+	// client request and the timeout of the fetch. If empty, the deadline is not forwarded.
+	SubgraphDeadlineHeader string
+	// SubgraphMiddlewares wrap the loading of every fetch in the given order, the first middleware is the outermost
+	// They can rewrite the datasource input, respond with a synthetic response or transform the response before it's merged
+	SubgraphMiddlewares []SubgraphMiddleware
+	// TracerProvider creates the OpenTelemetry spans of the fetches and the subscription triggers
+	// If nil, the global provider is used, see otel.SetTracerProvider
+	TracerProvider trace.TracerProvider
+	// Metrics receives the fetch latencies, entity batch sizes, response sizes and error counts of the resolver
+	// The ExecutionEngine additionally reports the plan cache lookups and planning durations
+	Metrics Metrics
 }
 
 // New returns a new Resolver, ctx.Done() is used to cancel all active subscriptions & streams
@@ -196,6 +215,7 @@ func New(ctx context.Context, options ResolverOptions) *Resolver {
 		allowedErrorExtensionFields:   allowedExtensionFields,
 		allowedErrorFields:            allowedErrorFields,
 		multipartSubHeartbeatInterval: options.MultipartSubHeartbeatInterval,
+		tracer:                        newTracer(options.TracerProvider),
 	}
 	resolver.maxConcurrency = make(chan struct{}, options.MaxConcurrency)
 	for i := 0; i < options.MaxConcurrency; i++ {
@@ -216,7 +236,7 @@ func New(ctx context.Context, options ResolverOptions) *Resolver {
 	return resolver
 }
 
-func newTools(options ResolverOptions, allowedExtensionFields map[string]struct{}, allowedErrorFields map[string]struct{}) *tools {
+func newTools(options ResolverOptions, allowedExtensionFields map[string]struct{}, allowedErrorFields map[string]struct{}, tracer trace.Tracer) *tools {
 	return &tools{
 		resolvable: NewResolvable(options.ResolvableOptions),
 		loader: &Loader{
@@ -230,12 +250,22 @@ func newTools(options ResolverOptions, allowedExtensionFields map[string]struct{
 			attachServiceNameToErrorExtension: options.AttachServiceNameToErrorExtensions,
 			defaultErrorExtensionCode:         options.DefaultErrorExtensionCode,
 			allowedSubgraphErrorFields:        allowedErrorFields,
+			entityCache:                       options.EntityCache,
+			subgraphDeadlineHeader:            options.SubgraphDeadlineHeader,
+			subgraphHandler:                   newSubgraphHandler(options.SubgraphMiddlewares),
+			tracer:                            tracer,
+			metrics:                           options.Metrics,
 		},
 	}
 }
 
 type GraphQLResolveInfo struct {
 	ResolveAcquireWaitTime time.Duration
+	// CacheControl is the cache policy of the response, computed from the @cacheControl hints of the resolved fields
+	// and the Cache-Control headers of the subgraph responses
+	// It's nil when no policy applies, in which case no Cache-Control header should be emitted
+	// For incremental responses, it covers all payloads and is only known after the last payload was flushed
+	CacheControl *CacheControlPolicy
 }
 
 func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLResponse, data []byte, writer io.Writer) (*GraphQLResolveInfo, error) {
@@ -249,7 +279,7 @@ func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLRespons
 		r.maxConcurrency <- struct{}{}
 	}()
 
-	t := newTools(r.options, r.allowedErrorExtensionFields, r.allowedErrorFields)
+	t := newTools(r.options, r.allowedErrorExtensionFields, r.allowedErrorFields, r.tracer)
 
 	err := t.resolvable.Init(ctx, data, response.Info.OperationType)
 	if err != nil {
@@ -263,22 +293,141 @@ func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLRespons
 		}
 	}
 
+	if len(response.DeferredFragments) != 0 || response.HasStreamedFields {
+		if flushWriter, ok := writer.(SubscriptionResponseWriter); ok && ctx.ExecutionOptions.EnableIncrementalDelivery {
+			err = r.resolveIncremental(ctx, t, response, flushWriter)
+			resp.CacheControl = t.resolvable.CacheControl()
+			return resp, err
+		}
+		// incremental delivery is disabled or the writer can't flush, so we resolve the deferred fragments and streamed lists inline
+		if !ctx.ExecutionOptions.SkipLoader {
+			err = t.loader.LoadStreamedData(ctx, response, t.resolvable)
+			if err != nil {
+				return nil, err
+			}
+			for _, fragment := range response.DeferredFragments {
+				err = t.loader.LoadDeferredFragmentData(ctx, response, fragment, t.resolvable)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	buf := &bytes.Buffer{}
 	err = t.resolvable.Resolve(ctx.ctx, response.Data, response.Fetches, buf)
 	if err != nil {
 		return nil, err
 	}
+	resp.CacheControl = t.resolvable.CacheControl()
+	t.resolvable.reportErrors(r.options.Metrics)
 
-	_, err = buf.WriteTo(writer)
+	n, err := buf.WriteTo(writer)
+	if r.options.Metrics != nil {
+		r.options.Metrics.ResponseSize(int(n))
+	}
 	return resp, err
 }
 
+// resolveIncremental writes the initial payload without the deferred fields and streamed items and flushes it
+// Afterward, each streamed item and each deferred fragment is flushed as a separate incremental payload
+// It's the responsibility of the writer to frame the payloads, e.g. as multipart/mixed
+func (r *Resolver) resolveIncremental(ctx *Context, t *tools, response *GraphQLResponse, writer SubscriptionResponseWriter) error {
+	t.resolvable.EnableIncrementalDelivery()
+
+	// size is the size of all flushed payloads
+	var size int
+	if r.options.Metrics != nil {
+		defer func() {
+			r.options.Metrics.ResponseSize(size)
+		}()
+	}
+
+	buf := &bytes.Buffer{}
+	err := t.resolvable.Resolve(ctx.ctx, response.Data, response.Fetches, buf)
+	if err != nil {
+		return err
+	}
+	if err = r.flushIncrementalPayload(t, buf, writer, &size); err != nil {
+		return err
+	}
+
+	t.resolvable.resetIncrementalErrors()
+	if !ctx.ExecutionOptions.SkipLoader {
+		err = t.loader.LoadStreamedData(ctx, response, t.resolvable)
+		if err != nil {
+			return err
+		}
+	}
+	streamedItems := t.resolvable.StreamedItemCount()
+	if streamedItems == 0 && len(response.DeferredFragments) == 0 {
+		// all streamed lists fit into the initial payload
+		buf.WriteString(`{"hasNext":false}`)
+		return r.flushIncrementalPayload(t, buf, writer, &size)
+	}
+	for i := 0; i < streamedItems; i++ {
+		if ctx.ctx.Err() != nil {
+			return ctx.ctx.Err()
+		}
+		if i != 0 {
+			t.resolvable.resetIncrementalErrors()
+		}
+		hasNext := i < streamedItems-1 || len(response.DeferredFragments) != 0
+		err = t.resolvable.ResolveStreamedItem(i, hasNext, buf)
+		if err != nil {
+			return err
+		}
+		if err = r.flushIncrementalPayload(t, buf, writer, &size); err != nil {
+			return err
+		}
+	}
+
+	for i, fragment := range response.DeferredFragments {
+		if ctx.ctx.Err() != nil {
+			// the client went away, there's no point in loading the remaining fragments
+			return ctx.ctx.Err()
+		}
+		t.resolvable.resetIncrementalErrors()
+		if !ctx.ExecutionOptions.SkipLoader {
+			err = t.loader.LoadDeferredFragmentData(ctx, response, fragment, t.resolvable)
+			if err != nil {
+				return err
+			}
+		}
+		hasNext := i < len(response.DeferredFragments)-1
+		err = t.resolvable.ResolveDeferredFragment(fragment, hasNext, buf)
+		if err != nil {
+			return err
+		}
+		if err = r.flushIncrementalPayload(t, buf, writer, &size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushIncrementalPayload reports the errors of the payload, adds its size to size and flushes it
+func (r *Resolver) flushIncrementalPayload(t *tools, buf *bytes.Buffer, writer SubscriptionResponseWriter, size *int) error {
+	t.resolvable.reportErrors(r.options.Metrics)
+	n, err := buf.WriteTo(writer)
+	*size += int(n)
+	if err != nil {
+		return err
+	}
+	buf.Reset()
+	return writer.Flush()
+}
+
 type trigger struct {
 	id            uint64
 	cancel        context.CancelFunc
 	subscriptions map[*Context]*sub
 	inFlight      *sync.WaitGroup
 	initialized   bool
+	// span lasts from the creation of the trigger until it's done or shut down
+	span              trace.Span
+	subscriptionCount int
+	updateCount       int
 }
 
 type sub struct {
@@ -294,16 +443,21 @@ type sub struct {
 	executor chan func()
 }
 
-func (r *Resolver) executeSubscriptionUpdate(ctx *Context, sub *sub, sharedInput []byte) {
+func (r *Resolver) executeSubscriptionUpdate(ctx *Context, sub *sub, sharedInput []byte, trigger trace.SpanContext) {
 	if r.options.Debug {
 		fmt.Printf("resolver:trigger:subscription:update:%d\n", sub.id.SubscriptionID)
 	}
-	t := newTools(r.options, r.allowedErrorExtensionFields, r.allowedErrorFields)
+	spanCtx, span := r.tracer.Start(ctx.ctx, SpanNameSubscriptionUpdate, trace.WithLinks(trace.Link{SpanContext: trigger}))
+	defer span.End()
+	ctx = ctx.WithContext(spanCtx)
+
+	t := newTools(r.options, r.allowedErrorExtensionFields, r.allowedErrorFields, r.tracer)
 
 	input := make([]byte, len(sharedInput))
 	copy(input, sharedInput)
 
 	if err := t.resolvable.InitSubscription(ctx, input, sub.resolve.Trigger.PostProcessing); err != nil {
+		recordSpanError(span, err)
 		sub.mux.Lock()
 		r.asyncErrorWriter.WriteError(ctx, err, sub.resolve.Response, sub.writer)
 		sub.mux.Unlock()
@@ -317,6 +471,7 @@ func (r *Resolver) executeSubscriptionUpdate(ctx *Context, sub *sub, sharedInput
 	}
 
 	if err := t.loader.LoadGraphQLResponseData(ctx, sub.resolve.Response, t.resolvable); err != nil {
+		recordSpanError(span, err)
 		sub.mux.Lock()
 		r.asyncErrorWriter.WriteError(ctx, err, sub.resolve.Response, sub.writer)
 		sub.mux.Unlock()
@@ -336,6 +491,7 @@ func (r *Resolver) executeSubscriptionUpdate(ctx *Context, sub *sub, sharedInput
 	}()
 
 	if err := t.resolvable.Resolve(ctx.ctx, sub.resolve.Response.Data, sub.resolve.Response.Fetches, sub.writer); err != nil {
+		recordSpanError(span, err)
 		r.asyncErrorWriter.WriteError(ctx, err, sub.resolve.Response, sub.writer)
 		if r.options.Debug {
 			fmt.Printf("resolver:trigger:subscription:resolve:failed:%d\n", sub.id.SubscriptionID)
@@ -461,6 +617,7 @@ func (r *Resolver) handleTriggerInitialized(triggerID uint64) {
 		return
 	}
 	trig.initialized = true
+	trig.span.AddEvent("initialized")
 
 	if r.reporter != nil {
 		r.reporter.TriggerCountInc(1)
@@ -477,6 +634,7 @@ func (r *Resolver) handleTriggerDone(triggerID uint64) {
 	subscriptionCount := len(trig.subscriptions)
 
 	delete(r.triggers, triggerID)
+	r.endTriggerSpan(trig)
 
 	go func() {
 		if wg != nil {
@@ -515,6 +673,8 @@ func (r *Resolver) handleAddSubscription(triggerID uint64, add *addSubscription)
 	trig, ok := r.triggers[triggerID]
 	if ok {
 		trig.subscriptions[add.ctx] = s
+		trig.subscriptionCount++
+		trig.span.AddEvent("subscription added")
 		if r.reporter != nil {
 			r.reporter.SubscriptionCountInc(1)
 		}
@@ -527,7 +687,8 @@ func (r *Resolver) handleAddSubscription(triggerID uint64, add *addSubscription)
 	if r.options.Debug {
 		fmt.Printf("resolver:create:trigger:%d\n", triggerID)
 	}
-	ctx, cancel := context.WithCancel(xcontext.Detach(add.ctx.Context()))
+	spanCtx, span := r.startTriggerSpan(xcontext.Detach(add.ctx.Context()), triggerID, add.resolve)
+	ctx, cancel := context.WithCancel(spanCtx)
 	updater := &subscriptionUpdater{
 		debug:     r.options.Debug,
 		triggerID: triggerID,
@@ -537,9 +698,11 @@ func (r *Resolver) handleAddSubscription(triggerID uint64, add *addSubscription)
 	}
 	cloneCtx := add.ctx.clone(ctx)
 	trig = &trigger{
-		id:            triggerID,
-		subscriptions: make(map[*Context]*sub),
-		cancel:        cancel,
+		id:                triggerID,
+		subscriptions:     make(map[*Context]*sub),
+		cancel:            cancel,
+		span:              span,
+		subscriptionCount: 1,
 	}
 	r.triggers[triggerID] = trig
 	trig.subscriptions[add.ctx] = s
@@ -568,6 +731,7 @@ func (r *Resolver) handleAddSubscription(triggerID uint64, add *addSubscription)
 			err = add.resolve.Trigger.Source.Start(cloneCtx, add.input, updater)
 		}
 		if err != nil {
+			recordSpanError(span, err)
 			if r.options.Debug {
 				fmt.Printf("resolver:trigger:failed:%d\n", triggerID)
 			}
@@ -698,6 +862,8 @@ func (r *Resolver) handleTriggerUpdate(id uint64, data []byte) {
 	}
 	wg := &sync.WaitGroup{}
 	trig.inFlight = wg
+	trig.updateCount++
+	triggerSpan := trig.span.SpanContext()
 	for c, s := range trig.subscriptions {
 		c, s := c, s
 		if err := c.ctx.Err(); err != nil {
@@ -713,7 +879,7 @@ func (r *Resolver) handleTriggerUpdate(id uint64, data []byte) {
 		}
 		wg.Add(1)
 		fn := func() {
-			r.executeSubscriptionUpdate(c, s, data)
+			r.executeSubscriptionUpdate(c, s, data, triggerSpan)
 		}
 		go func(fn func()) {
 			defer wg.Done()
@@ -754,6 +920,7 @@ func (r *Resolver) shutdownTrigger(id uint64) {
 	}
 	trig.cancel()
 	delete(r.triggers, id)
+	r.endTriggerSpan(trig)
 	if r.options.Debug {
 		fmt.Printf("resolver:trigger:done:%d\n", trig.id)
 	}
@@ -838,7 +1005,7 @@ func (r *Resolver) ResolveGraphQLSubscription(ctx *Context, subscription *GraphQ
 	// If SkipLoader is enabled, we skip retrieving actual data. For example, this is useful when requesting a query plan.
 	// By returning early, we avoid starting a subscription and resolve with empty data instead.
 	if ctx.ExecutionOptions.SkipLoader {
-		t := newTools(r.options, r.allowedErrorExtensionFields, r.allowedErrorFields)
+		t := newTools(r.options, r.allowedErrorExtensionFields, r.allowedErrorFields, r.tracer)
 
 		err = t.resolvable.InitSubscription(ctx, nil, subscription.Trigger.PostProcessing)
 		if err != nil {
@@ -953,7 +1120,7 @@ func (r *Resolver) AsyncResolveGraphQLSubscription(ctx *Context, subscription *G
 	// If SkipLoader is enabled, we skip retrieving actual data. For example, this is useful when requesting a query plan.
 	// By returning early, we avoid starting a subscription and resolve with empty data instead.
 	if ctx.ExecutionOptions.SkipLoader {
-		t := newTools(r.options, r.allowedErrorExtensionFields, r.allowedErrorFields)
+		t := newTools(r.options, r.allowedErrorExtensionFields, r.allowedErrorFields, r.tracer)
 
 		err = t.resolvable.InitSubscription(ctx, nil, subscription.Trigger.PostProcessing)
 		if err != nil {
@@ -6455,3 +6455,240 @@ func Benchmark_NestedBatchingWithoutChecks(b *testing.B) {
 		}
 	})
 }
+
+func TestResolver_ResolveGraphQLResponse_Defer(t *testing.T) {
+	setup := func() *GraphQLResponse {
+		nameDefer := &DeferField{Label: "name", FetchIDs: []int{1}}
+		return &GraphQLResponse{
+			Info: &GraphQLResponseInfo{
+				OperationType: ast.OperationTypeQuery,
+			},
+			Fetches: Sequence(
+				Single(&SingleFetch{
+					FetchConfiguration: FetchConfiguration{
+						DataSource: FakeDataSource(`{"data":{"users":[{"id":"1"},{"id":"2"}]}}`),
+						PostProcessing: PostProcessingConfiguration{
+							SelectResponseDataPath: []string{"data"},
+						},
+					},
+				}),
+			),
+			DeferredFragments: []*DeferredFragment{
+				{
+					Defer: nameDefer,
+					Path: []FetchItemPathElement{
+						ArrayPath("users"),
+					},
+					Data: &Object{
+						Fields: []*Field{
+							{
+								Name:  []byte("name"),
+								Value: &String{Path: []string{"name"}},
+								Defer: nameDefer,
+							},
+						},
+					},
+					Fetches: Sequence(
+						Single(&SingleFetch{
+							FetchConfiguration: FetchConfiguration{
+								DataSource: FakeDataSource(`{"data":{"_entities":[{"name":"Jens"},{"name":"Stefan"}]}}`),
+								PostProcessing: PostProcessingConfiguration{
+									SelectResponseDataPath: []string{"data", "_entities"},
+								},
+							},
+						}, ArrayPath("users")),
+					),
+				},
+			},
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name: []byte("users"),
+						Value: &Array{
+							Path: []string{"users"},
+							Item: &Object{
+								Fields: []*Field{
+									{
+										Name:  []byte("id"),
+										Value: &String{Path: []string{"id"}},
+									},
+									{
+										Name:  []byte("name"),
+										Value: &String{Path: []string{"name"}},
+										Defer: nameDefer,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("incremental delivery", func(t *testing.T) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx)
+
+		ctx := NewContext(context.Background())
+		ctx.ExecutionOptions.EnableIncrementalDelivery = true
+		out := &SubscriptionRecorder{buf: &bytes.Buffer{}}
+		_, err := resolver.ResolveGraphQLResponse(ctx, setup(), nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`{"data":{"users":[{"id":"1"},{"id":"2"}]},"hasNext":true}`,
+			`{"incremental":[{"data":{"name":"Jens"},"path":["users",0],"label":"name"},{"data":{"name":"Stefan"},"path":["users",1],"label":"name"}],"hasNext":false}`,
+		}, out.Messages())
+	})
+
+	t.Run("writer without flush resolves deferred fields inline", func(t *testing.T) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx)
+
+		ctx := NewContext(context.Background())
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(ctx, setup(), nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"users":[{"id":"1","name":"Jens"},{"id":"2","name":"Stefan"}]}}`, out.String())
+	})
+
+	t.Run("disabled incremental delivery resolves deferred fields inline", func(t *testing.T) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx)
+
+		ctx := NewContext(context.Background())
+		out := &SubscriptionRecorder{buf: &bytes.Buffer{}}
+		_, err := resolver.ResolveGraphQLResponse(ctx, setup(), nil, out)
+		require.NoError(t, err)
+		assert.Empty(t, out.Messages())
+		assert.Equal(t, `{"data":{"users":[{"id":"1","name":"Jens"},{"id":"2","name":"Stefan"}]}}`, out.buf.String())
+	})
+
+	t.Run("cache control covers the deferred fields", func(t *testing.T) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx)
+
+		response := setup()
+		response.Data.Fields[0].CacheControl = &CacheControlPolicy{MaxAge: time.Minute, HasMaxAge: true}
+		response.DeferredFragments[0].Data.Fields[0].CacheControl = &CacheControlPolicy{MaxAge: 30 * time.Second, HasMaxAge: true, Scope: CacheControlScopePrivate}
+
+		ctx := NewContext(context.Background())
+		ctx.ExecutionOptions.EnableIncrementalDelivery = true
+		out := &SubscriptionRecorder{buf: &bytes.Buffer{}}
+		info, err := resolver.ResolveGraphQLResponse(ctx, response, nil, out)
+		require.NoError(t, err)
+		assert.Len(t, out.Messages(), 2)
+		assert.Equal(t, &CacheControlPolicy{MaxAge: 30 * time.Second, HasMaxAge: true, Scope: CacheControlScopePrivate}, info.CacheControl)
+	})
+}
+
+func TestResolver_ResolveGraphQLResponse_Stream(t *testing.T) {
+	setup := func(initialBatchSize int) *GraphQLResponse {
+		stream := &StreamField{InitialBatchSize: initialBatchSize, Label: "users"}
+		return &GraphQLResponse{
+			Info: &GraphQLResponseInfo{
+				OperationType: ast.OperationTypeQuery,
+			},
+			HasStreamedFields: true,
+			Fetches: Sequence(
+				Single(&SingleFetch{
+					FetchConfiguration: FetchConfiguration{
+						DataSource: FakeDataSource(`{"data":{"users":[{"id":"1"},{"id":"2"},{"id":"3"}]}}`),
+						PostProcessing: PostProcessingConfiguration{
+							SelectResponseDataPath: []string{"data"},
+						},
+					},
+				}),
+			),
+			StreamedFetches: Sequence(
+				Single(&SingleFetch{
+					FetchConfiguration: FetchConfiguration{
+						DataSource: FakeDataSource(`{"data":{"_entities":[{"name":"Jens"},{"name":"Stefan"},{"name":null}]}}`),
+						PostProcessing: PostProcessingConfiguration{
+							SelectResponseDataPath: []string{"data", "_entities"},
+						},
+					},
+				}, ArrayPath("users")),
+			),
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name:   []byte("users"),
+						Stream: stream,
+						Value: &Array{
+							Path: []string{"users"},
+							Item: &Object{
+								Nullable: true,
+								Fields: []*Field{
+									{
+										Name:  []byte("id"),
+										Value: &String{Path: []string{"id"}},
+									},
+									{
+										Name:  []byte("name"),
+										Value: &String{Path: []string{"name"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("stream all items", func(t *testing.T) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx)
+
+		ctx := NewContext(context.Background())
+		ctx.ExecutionOptions.EnableIncrementalDelivery = true
+		out := &SubscriptionRecorder{buf: &bytes.Buffer{}}
+		_, err := resolver.ResolveGraphQLResponse(ctx, setup(0), nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`{"data":{"users":[]},"hasNext":true}`,
+			`{"incremental":[{"items":[{"id":"1","name":"Jens"}],"path":["users",0],"label":"users"}],"hasNext":true}`,
+			`{"incremental":[{"items":[{"id":"2","name":"Stefan"}],"path":["users",1],"label":"users"}],"hasNext":true}`,
+			`{"incremental":[{"errors":[{"message":"Cannot return null for non-nullable field 'Query.users.name'.","path":["users",2,"name"]}],"items":[null],"path":["users",2],"label":"users"}],"hasNext":false}`,
+		}, out.Messages())
+	})
+
+	t.Run("initial items fit into the initial payload", func(t *testing.T) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx)
+
+		response := setup(5)
+		// with initial items, the entity fetch has to be loaded before the initial payload
+		response.Fetches.ChildNodes = append(response.Fetches.ChildNodes, response.StreamedFetches.ChildNodes...)
+		response.StreamedFetches = nil
+
+		ctx := NewContext(context.Background())
+		ctx.ExecutionOptions.EnableIncrementalDelivery = true
+		out := &SubscriptionRecorder{buf: &bytes.Buffer{}}
+		_, err := resolver.ResolveGraphQLResponse(ctx, response, nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`{"errors":[{"message":"Cannot return null for non-nullable field 'Query.users.name'.","path":["users",2,"name"]}],"data":{"users":[{"id":"1","name":"Jens"},{"id":"2","name":"Stefan"},null]},"hasNext":true}`,
+			`{"hasNext":false}`,
+		}, out.Messages())
+	})
+
+	t.Run("writer without flush resolves streamed items inline", func(t *testing.T) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx)
+
+		ctx := NewContext(context.Background())
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(ctx, setup(1), nil, out)
+		require.NoError(t, err)
+		assert.Equal(t, `{"errors":[{"message":"Cannot return null for non-nullable field 'Query.users.name'.","path":["users",2,"name"]}],"data":{"users":[{"id":"1","name":"Jens"},{"id":"2","name":"Stefan"},null]}}`, out.String())
+	})
+}
@@ -27,6 +27,27 @@ type GraphQLResponse struct {
 	Info            *GraphQLResponseInfo
 	Fetches         *FetchTreeNode
 	DataSources     []DataSourceInfo
+	// DeferredFragments are the fragments annotated with @defer, in the order they have to be resolved
+	// The fields of a deferred fragment are still part of Data, but marked with Field.Defer
+	DeferredFragments []*DeferredFragment
+	// HasStreamedFields is true when Data contains list fields annotated with @stream
+	HasStreamedFields bool
+	// StreamedFetches are loaded after the initial payload was flushed, before the streamed items are rendered
+	StreamedFetches *FetchTreeNode
+}
+
+// DeferredFragment describes the fields of a single @defer fragment and the fetches needed to resolve them.
+// If the writer supports flushing, the fragment is resolved after the initial payload was written
+// and rendered as an incremental payload, otherwise it's resolved inline with the initial response.
+type DeferredFragment struct {
+	Defer *DeferField
+	// Path selects the parent objects of the deferred fields in the response data
+	// It follows the same rules as the FetchPath of a FetchItem
+	Path []FetchItemPathElement
+	// Data is the parent object of the deferred fields, it only contains the deferred fields
+	Data *Object
+	// Fetches are loaded after the fetches of all previous fragments, including the initial fetch tree
+	Fetches *FetchTreeNode
 }
 
 type GraphQLResponseInfo struct {
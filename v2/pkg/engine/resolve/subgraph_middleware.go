@@ -0,0 +1,100 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/buger/jsonparser"
+	"github.com/pkg/errors"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+)
+
+// SubgraphRequest is the request of a single fetch as it is passed through the SubgraphMiddleware chain
+type SubgraphRequest struct {
+	// DataSource identifies the datasource of the fetch
+	DataSource DataSourceInfo
+	// Input is the rendered input of the datasource, e.g. {"method":"POST","url":"...","header":{...},"body":{...}}
+	// Middlewares may replace the Input, the terminal handler loads the datasource with the final Input
+	Input []byte
+
+	source DataSource
+	files  []httpclient.File
+}
+
+// Header returns the values of the header with the given name
+func (r *SubgraphRequest) Header(name string) []string {
+	var values []string
+	_, _ = jsonparser.ArrayEach(r.Input, func(value []byte, dataType jsonparser.ValueType, _ int, _ error) {
+		if dataType == jsonparser.String {
+			values = append(values, string(value))
+		}
+	}, httpclient.HEADER, name)
+	return values
+}
+
+// SetHeader sets the header with the given name, replacing existing values
+func (r *SubgraphRequest) SetHeader(name string, values ...string) error {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	input, err := jsonparser.Set(r.Input, encoded, httpclient.HEADER, name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.Input = input
+	return nil
+}
+
+// DeleteHeader removes the header with the given name
+func (r *SubgraphRequest) DeleteHeader(name string) {
+	r.Input = jsonparser.Delete(r.Input, httpclient.HEADER, name)
+}
+
+// Body returns the body of the request, e.g. {"query":"...","variables":{...}} for GraphQL subgraphs
+func (r *SubgraphRequest) Body() []byte {
+	body, _, _, _ := jsonparser.Get(r.Input, httpclient.BODY)
+	return body
+}
+
+// SetBody replaces the body of the request, the body must be valid JSON
+func (r *SubgraphRequest) SetBody(body []byte) error {
+	input, err := jsonparser.Set(r.Input, body, httpclient.BODY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.Input = input
+	return nil
+}
+
+// SubgraphHandler loads the response of a SubgraphRequest into out
+type SubgraphHandler func(ctx context.Context, request *SubgraphRequest, out *bytes.Buffer) error
+
+// SubgraphMiddleware wraps the loading of a fetch. A middleware may
+//   - rewrite the request before calling next, e.g. to sign requests or redact payloads
+//   - write a synthetic response to out without calling next, e.g. to mock subgraphs or inject faults
+//   - transform out after next returned, before the response is merged
+//
+// The status code of a synthetic response can be set on the httpclient.ResponseContext of ctx.
+type SubgraphMiddleware func(next SubgraphHandler) SubgraphHandler
+
+func loadSubgraphRequest(ctx context.Context, request *SubgraphRequest, out *bytes.Buffer) error {
+	if request.files != nil {
+		return request.source.LoadWithFiles(ctx, request.Input, request.files, out)
+	}
+	return request.source.Load(ctx, request.Input, out)
+}
+
+// newSubgraphHandler chains the middlewares in order, the first middleware is the outermost
+func newSubgraphHandler(middlewares []SubgraphMiddleware) SubgraphHandler {
+	if len(middlewares) == 0 {
+		return nil
+	}
+	handler := SubgraphHandler(loadSubgraphRequest)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,150 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/datasource/httpclient"
+)
+
+func TestLoader_SubgraphMiddlewares(t *testing.T) {
+	response := func(dataSource DataSource) *GraphQLResponse {
+		return &GraphQLResponse{
+			Info: &GraphQLResponseInfo{
+				OperationType: ast.OperationTypeQuery,
+			},
+			Fetches: SingleWithPath(&SingleFetch{
+				InputTemplate: InputTemplate{
+					Segments: []TemplateSegment{
+						{
+							Data:        []byte(`{"url":"http://accounts","header":{"Authorization":["secret"]},"body":{"query":"{me}"},"data":{"data":{"me":"upstream"}}}`),
+							SegmentType: StaticSegmentType,
+						},
+					},
+				},
+				FetchConfiguration: FetchConfiguration{
+					DataSource: dataSource,
+					PostProcessing: PostProcessingConfiguration{
+						SelectResponseDataPath:   []string{"data"},
+						SelectResponseErrorsPath: []string{"errors"},
+					},
+				},
+				Info: &FetchInfo{
+					DataSourceID:   "accounts-id",
+					DataSourceName: "accounts",
+				},
+			}, "query"),
+			Data: &Object{
+				Fields: []*Field{
+					{
+						Name: []byte("me"),
+						Value: &String{
+							Path:     []string{"me"},
+							Nullable: true,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	resolveWithOptions := func(t *testing.T, dataSource DataSource, options ResolverOptions) string {
+		t.Helper()
+		options.MaxConcurrency = 1024
+		resolver := New(context.Background(), options)
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), response(dataSource), nil, out)
+		require.NoError(t, err)
+		return out.String()
+	}
+
+	resolve := func(t *testing.T, dataSource DataSource, middlewares ...SubgraphMiddleware) string {
+		t.Helper()
+		return resolveWithOptions(t, dataSource, ResolverOptions{SubgraphMiddlewares: middlewares})
+	}
+
+	t.Run("rewrite request", func(t *testing.T) {
+		dataSource := &deadlineDataSource{}
+		out := resolve(t, dataSource, func(next SubgraphHandler) SubgraphHandler {
+			return func(ctx context.Context, request *SubgraphRequest, out *bytes.Buffer) error {
+				assert.Equal(t, DataSourceInfo{ID: "accounts-id", Name: "accounts"}, request.DataSource)
+				assert.Equal(t, []string{"secret"}, request.Header("Authorization"))
+				assert.Equal(t, `{"query":"{me}"}`, string(request.Body()))
+
+				request.DeleteHeader("Authorization")
+				require.NoError(t, request.SetHeader("X-Signature", "signed"))
+				require.NoError(t, request.SetBody([]byte(`{"query":"{me}","extensions":{"signed":true}}`)))
+				return next(ctx, request, out)
+			}
+		})
+		assert.Equal(t, `{"data":{"me":"upstream"}}`, out)
+		assert.Equal(t, `{"url":"http://accounts","header":{"X-Signature":["signed"]},"body":{"query":"{me}","extensions":{"signed":true}},"data":{"data":{"me":"upstream"}}}`, string(dataSource.input))
+	})
+
+	t.Run("short circuit with synthetic response", func(t *testing.T) {
+		dataSource := &deadlineDataSource{}
+		out := resolve(t, dataSource, func(next SubgraphHandler) SubgraphHandler {
+			return func(ctx context.Context, request *SubgraphRequest, out *bytes.Buffer) error {
+				_, err := out.WriteString(`{"data":{"me":"mocked"}}`)
+				return err
+			}
+		})
+		assert.Equal(t, `{"data":{"me":"mocked"}}`, out)
+		assert.Nil(t, dataSource.input)
+	})
+
+	t.Run("inject fault", func(t *testing.T) {
+		out := resolveWithOptions(t, &deadlineDataSource{}, ResolverOptions{
+			PropagateSubgraphErrors:      true,
+			PropagateSubgraphStatusCodes: true,
+			SubgraphMiddlewares: []SubgraphMiddleware{func(next SubgraphHandler) SubgraphHandler {
+				return func(ctx context.Context, request *SubgraphRequest, out *bytes.Buffer) error {
+					if responseContext, ok := httpclient.ResponseContextFromContext(ctx); ok {
+						responseContext.StatusCode = http.StatusServiceUnavailable
+					}
+					_, err := out.WriteString(`{"errors":[{"message":"injected"}]}`)
+					return err
+				}
+			}},
+		})
+		assert.Equal(t, `{"errors":[{"message":"Failed to fetch from Subgraph 'accounts' at Path 'query'.","extensions":{"errors":[{"message":"injected"}],"statusCode":503}}],"data":{"me":null}}`, out)
+	})
+
+	t.Run("transform response", func(t *testing.T) {
+		out := resolve(t, &deadlineDataSource{}, func(next SubgraphHandler) SubgraphHandler {
+			return func(ctx context.Context, request *SubgraphRequest, out *bytes.Buffer) error {
+				if err := next(ctx, request, out); err != nil {
+					return err
+				}
+				redacted := bytes.ReplaceAll(out.Bytes(), []byte("upstream"), []byte("redacted"))
+				out.Reset()
+				_, err := out.Write(redacted)
+				return err
+			}
+		})
+		assert.Equal(t, `{"data":{"me":"redacted"}}`, out)
+	})
+
+	t.Run("middlewares are called in order", func(t *testing.T) {
+		var calls []string
+		middleware := func(name string) SubgraphMiddleware {
+			return func(next SubgraphHandler) SubgraphHandler {
+				return func(ctx context.Context, request *SubgraphRequest, out *bytes.Buffer) error {
+					calls = append(calls, name+" before")
+					err := next(ctx, request, out)
+					calls = append(calls, name+" after")
+					return err
+				}
+			}
+		}
+		out := resolve(t, &deadlineDataSource{}, middleware("first"), middleware("second"))
+		assert.Equal(t, `{"data":{"me":"upstream"}}`, out)
+		assert.Equal(t, []string{"first before", "second before", "second after", "first after"}, calls)
+	})
+}
@@ -0,0 +1,121 @@
+package resolve
+
+import (
+	"context"
+
+	"github.com/buger/jsonparser"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName is the name of the OpenTelemetry tracer of the engine
+const TracerName = "github.com/wundergraph/graphql-go-tools/v2/pkg/engine"
+
+// Span names and attributes of the OpenTelemetry spans of the resolver
+const (
+	SpanNameFetch               = "graphql.fetch"
+	SpanNameSubscriptionTrigger = "graphql.subscription.trigger"
+	SpanNameSubscriptionUpdate  = "graphql.subscription.update"
+
+	AttributeDataSourceID      = attribute.Key("graphql.datasource.id")
+	AttributeDataSourceName    = attribute.Key("graphql.datasource.name")
+	AttributeFetchKind         = attribute.Key("graphql.fetch.kind")
+	AttributeFetchEntityCount  = attribute.Key("graphql.fetch.entity_count")
+	AttributeFetchErrorCount   = attribute.Key("graphql.fetch.error_count")
+	AttributeFetchSkipped      = attribute.Key("graphql.fetch.skipped")
+	AttributeStatusCode        = attribute.Key("http.response.status_code")
+	AttributeTriggerID         = attribute.Key("graphql.subscription.trigger_id")
+	AttributeSubscriptionCount = attribute.Key("graphql.subscription.count")
+	AttributeUpdateCount       = attribute.Key("graphql.subscription.update_count")
+)
+
+const (
+	fetchKindSingle      = "single"
+	fetchKindEntity      = "entity"
+	fetchKindBatchEntity = "batch_entity"
+)
+
+// newTracer returns the tracer of the provider, the global provider is used if it's nil
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(TracerName)
+}
+
+// startFetchSpan starts the span of a fetch, it's ended by endFetchSpan
+func (l *Loader) startFetchSpan(ctx context.Context, kind string, info *FetchInfo) (context.Context, trace.Span) {
+	tracer := l.tracer
+	if tracer == nil {
+		tracer = noop.Tracer{}
+	}
+	ctx, span := tracer.Start(ctx, SpanNameFetch, trace.WithSpanKind(trace.SpanKindClient))
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	span.SetAttributes(AttributeFetchKind.String(kind))
+	if info != nil {
+		span.SetAttributes(
+			AttributeDataSourceID.String(info.DataSourceID),
+			AttributeDataSourceName.String(info.DataSourceName),
+		)
+	}
+	return ctx, span
+}
+
+// endFetchSpan records the outcome of the fetch and ends its span
+func (l *Loader) endFetchSpan(span trace.Span, res *result) {
+	defer span.End()
+	if !span.IsRecording() {
+		return
+	}
+	if res.fetchSkipped {
+		span.SetAttributes(AttributeFetchSkipped.Bool(true))
+	}
+	if res.statusCode != 0 {
+		span.SetAttributes(AttributeStatusCode.Int(res.statusCode))
+	}
+	if res.out != nil && res.out.Len() != 0 {
+		errorCount := 0
+		_, _ = jsonparser.ArrayEach(res.out.Bytes(), func(_ []byte, _ jsonparser.ValueType, _ int, _ error) {
+			errorCount++
+		}, "errors")
+		span.SetAttributes(AttributeFetchErrorCount.Int(errorCount))
+	}
+	recordSpanError(span, res.err)
+}
+
+// recordSpanError marks the span as failed if err is not nil
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// startTriggerSpan starts the span of a subscription trigger, it's ended by endTriggerSpan when the trigger is done or shut down
+func (r *Resolver) startTriggerSpan(ctx context.Context, triggerID uint64, subscription *GraphQLSubscription) (context.Context, trace.Span) {
+	ctx, span := r.tracer.Start(ctx, SpanNameSubscriptionTrigger, trace.WithAttributes(AttributeTriggerID.Int64(int64(triggerID))))
+	if !span.IsRecording() || subscription.Response == nil || subscription.Response.Fetches == nil || subscription.Response.Fetches.Trigger == nil || subscription.Response.Fetches.Trigger.Item == nil {
+		return ctx, span
+	}
+	if fetch, ok := subscription.Response.Fetches.Trigger.Item.Fetch.(*SingleFetch); ok && fetch.Info != nil {
+		span.SetAttributes(
+			AttributeDataSourceID.String(fetch.Info.DataSourceID),
+			AttributeDataSourceName.String(fetch.Info.DataSourceName),
+		)
+	}
+	return ctx, span
+}
+
+func (r *Resolver) endTriggerSpan(trig *trigger) {
+	trig.span.SetAttributes(
+		AttributeSubscriptionCount.Int(trig.subscriptionCount),
+		AttributeUpdateCount.Int(trig.updateCount),
+	)
+	trig.span.End()
+}
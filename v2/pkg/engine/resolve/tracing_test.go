@@ -0,0 +1,196 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[string]any {
+	out := map[string]any{}
+	for _, attr := range span.Attributes() {
+		out[string(attr.Key)] = attr.Value.AsInterface()
+	}
+	return out
+}
+
+func spansByName(spans []sdktrace.ReadOnlySpan, name string) []sdktrace.ReadOnlySpan {
+	var out []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.Name() == name {
+			out = append(out, span)
+		}
+	}
+	return out
+}
+
+func TestResolver_Tracing(t *testing.T) {
+	t.Run("fetch spans", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := New(rCtx, ResolverOptions{
+			MaxConcurrency: 32,
+			TracerProvider: provider,
+		})
+
+		requestCtx, requestSpan := provider.Tracer("test").Start(context.Background(), "request")
+		limiter := &testRateLimiter{
+			allowFn: func(ctx *Context, info *FetchInfo, input json.RawMessage) (*RateLimitDeny, error) {
+				if info.DataSourceID == "products" {
+					return &RateLimitDeny{Reason: "rate limit exceeded"}, nil
+				}
+				return nil, nil
+			},
+		}
+		ctx := NewContext(requestCtx)
+		ctx.RateLimitOptions = RateLimitOptions{Enable: true}
+		ctx.SetRateLimiter(limiter)
+
+		out := &bytes.Buffer{}
+		_, err := resolver.ResolveGraphQLResponse(ctx, generateTestFederationGraphQLResponse(t, ctrl), nil, out)
+		require.NoError(t, err)
+		requestSpan.End()
+
+		fetches := spansByName(recorder.Ended(), SpanNameFetch)
+		require.Len(t, fetches, 3)
+		attributes := map[string]map[string]any{}
+		for _, span := range fetches {
+			assert.Equal(t, requestSpan.SpanContext().SpanID(), span.Parent().SpanID())
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+			spanAttributes := spanAttributes(span)
+			attributes[spanAttributes["graphql.datasource.name"].(string)] = spanAttributes
+		}
+		assert.Equal(t, map[string]any{
+			"graphql.fetch.kind":        "single",
+			"graphql.datasource.id":     "users",
+			"graphql.datasource.name":   "users",
+			"graphql.fetch.error_count": int64(0),
+		}, attributes["users"])
+		assert.Equal(t, map[string]any{
+			"graphql.fetch.kind":      "single",
+			"graphql.datasource.id":   "products",
+			"graphql.datasource.name": "products",
+			"graphql.fetch.skipped":   true,
+		}, attributes["products"])
+	})
+
+	t.Run("subscription trigger spans", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := New(rCtx, ResolverOptions{
+			MaxConcurrency:   32,
+			AsyncErrorWriter: &TestErrorWriter{},
+			TracerProvider:   provider,
+		})
+
+		var startSpan atomic.Value
+		stream := createFakeStream(func(counter int) (message string, done bool) {
+			return fmt.Sprintf(`{"data":{"counter":%d}}`, counter), counter == 1
+		}, 0, nil)
+		source := &spanCapturingSubscription{SubscriptionDataSource: stream, span: &startSpan}
+
+		fetches := Sequence()
+		fetches.Trigger = &FetchTreeNode{
+			Kind: FetchTreeNodeKindTrigger,
+			Item: &FetchItem{
+				Fetch: &SingleFetch{
+					Info: &FetchInfo{
+						DataSourceID:   "0",
+						DataSourceName: "counter",
+					},
+				},
+				ResponsePath: "counter",
+			},
+		}
+		subscription := &GraphQLSubscription{
+			Trigger: GraphQLSubscriptionTrigger{
+				Source: source,
+				InputTemplate: InputTemplate{
+					Segments: []TemplateSegment{
+						{
+							SegmentType: StaticSegmentType,
+							Data:        []byte(`{"method":"POST","url":"http://localhost:4000","body":{"query":"subscription { counter }"}}`),
+						},
+					},
+				},
+				PostProcessing: PostProcessingConfiguration{
+					SelectResponseDataPath:   []string{"data"},
+					SelectResponseErrorsPath: []string{"errors"},
+				},
+			},
+			Response: &GraphQLResponse{
+				Data: &Object{
+					Fields: []*Field{
+						{
+							Name:  []byte("counter"),
+							Value: &Integer{Path: []string{"counter"}},
+						},
+					},
+				},
+				Fetches: fetches,
+			},
+		}
+
+		out := &SubscriptionRecorder{
+			buf:      &bytes.Buffer{},
+			messages: []string{},
+		}
+		requestCtx, requestSpan := provider.Tracer("test").Start(context.Background(), "request")
+		err := resolver.AsyncResolveGraphQLSubscription(NewContext(requestCtx), subscription, out, SubscriptionIdentifier{ConnectionID: 1, SubscriptionID: 1})
+		require.NoError(t, err)
+		out.AwaitComplete(t, 10*time.Second)
+		requestSpan.End()
+
+		assert.Eventually(t, func() bool {
+			return len(spansByName(recorder.Ended(), SpanNameSubscriptionTrigger)) == 1 &&
+				len(spansByName(recorder.Ended(), SpanNameSubscriptionUpdate)) == 2
+		}, 10*time.Second, 10*time.Millisecond)
+
+		triggerSpan := spansByName(recorder.Ended(), SpanNameSubscriptionTrigger)[0]
+		assert.Equal(t, requestSpan.SpanContext().SpanID(), triggerSpan.Parent().SpanID())
+		attributes := spanAttributes(triggerSpan)
+		assert.Equal(t, "counter", attributes["graphql.datasource.name"])
+		assert.Equal(t, int64(1), attributes["graphql.subscription.count"])
+		assert.Equal(t, int64(2), attributes["graphql.subscription.update_count"])
+
+		// the upstream subscription is started within the trigger span, so it's propagated to the upstream
+		assert.Equal(t, triggerSpan.SpanContext().SpanID(), startSpan.Load().(trace.SpanContext).SpanID())
+
+		for _, update := range spansByName(recorder.Ended(), SpanNameSubscriptionUpdate) {
+			assert.Equal(t, requestSpan.SpanContext().SpanID(), update.Parent().SpanID())
+			require.Len(t, update.Links(), 1)
+			assert.Equal(t, triggerSpan.SpanContext().SpanID(), update.Links()[0].SpanContext.SpanID())
+		}
+	})
+}
+
+// spanCapturingSubscription captures the span of the context the subscription is started with
+type spanCapturingSubscription struct {
+	SubscriptionDataSource
+	span *atomic.Value
+}
+
+func (s *spanCapturingSubscription) Start(ctx *Context, input []byte, updater SubscriptionUpdater) error {
+	s.span.Store(trace.SpanContextFromContext(ctx.Context()))
+	return s.SubscriptionDataSource.Start(ctx, input, updater)
+}
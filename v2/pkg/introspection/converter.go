@@ -37,6 +37,10 @@ func (j *JsonConverter) GraphQLDocument(introspectionJSON io.Reader) (*ast.Docum
 
 func (j *JsonConverter) importSchema() error {
 	j.doc.ImportSchemaDefinition(j.schema.TypeNames())
+	if j.schema.Description != nil {
+		schemaRef := j.doc.SchemaDefinitionRef()
+		j.doc.SchemaDefinitions[schemaRef].Description = j.doc.ImportDescription(*j.schema.Description)
+	}
 
 	for i := 0; i < len(j.schema.Types); i++ {
 		if err := j.importFullType(j.schema.Types[i]); err != nil {
@@ -56,7 +60,7 @@ func (j *JsonConverter) importSchema() error {
 func (j *JsonConverter) importFullType(fullType *FullType) (err error) {
 	switch fullType.Kind {
 	case SCALAR:
-		j.doc.ImportScalarTypeDefinition(fullType.Name, fullType.Description)
+		j.importScalar(fullType)
 	case OBJECT:
 		err = j.importObject(fullType)
 	case ENUM:
@@ -71,6 +75,18 @@ func (j *JsonConverter) importFullType(fullType *FullType) (err error) {
 	return
 }
 
+func (j *JsonConverter) importScalar(fullType *FullType) {
+	var directiveRefs []int
+	if fullType.SpecifiedByURL != nil {
+		directiveRefs = append(directiveRefs, j.importSpecifiedByDirective(*fullType.SpecifiedByURL))
+	}
+
+	j.doc.ImportScalarTypeDefinitionWithDirectives(
+		fullType.Name,
+		fullType.Description,
+		directiveRefs)
+}
+
 func (j *JsonConverter) importObject(fullType *FullType) error {
 	fieldRefs, err := j.importFields(fullType.Fields)
 	if err != nil {
@@ -126,10 +142,16 @@ func (j *JsonConverter) importInputObject(fullType *FullType) error {
 		return err
 	}
 
-	j.doc.ImportInputObjectTypeDefinition(
+	var directiveRefs []int
+	if fullType.IsOneOf != nil && *fullType.IsOneOf {
+		directiveRefs = append(directiveRefs, j.doc.ImportDirective(OneOfDirectiveName, nil))
+	}
+
+	j.doc.ImportInputObjectTypeDefinitionWithDirectives(
 		fullType.Name,
 		fullType.Description,
-		argRefs)
+		argRefs,
+		directiveRefs)
 
 	return nil
 }
@@ -219,8 +241,13 @@ func (j *JsonConverter) importInputField(field InputValue) (ref int, err error)
 		return -1, err
 	}
 
-	return j.doc.ImportInputValueDefinition(
-		field.Name, field.Description, typeRef, defaultValue), nil
+	var directiveRefs []int
+	if field.IsDeprecated {
+		directiveRefs = append(directiveRefs, j.importDeprecatedDirective(field.DeprecationReason))
+	}
+
+	return j.doc.ImportInputValueDefinitionWithDirectives(
+		field.Name, field.Description, typeRef, defaultValue, directiveRefs), nil
 }
 
 func (j *JsonConverter) importType(typeRef TypeRef) (ref int) {
@@ -273,3 +300,14 @@ func (j *JsonConverter) importDeprecatedDirective(reason *string) (ref int) {
 
 	return j.doc.ImportDirective(DeprecatedDirectiveName, args)
 }
+
+func (j *JsonConverter) importSpecifiedByDirective(url string) (ref int) {
+	valueRef := j.doc.ImportStringValue([]byte(url), false)
+	value := ast.Value{
+		Kind: ast.ValueKindString,
+		Ref:  valueRef,
+	}
+	j.doc.AddValue(value)
+
+	return j.doc.ImportDirective(SpecifiedByDirectiveName, []int{j.doc.ImportArgument(SpecifiedByURLArgName, value)})
+}
@@ -12,6 +12,9 @@ import (
 const (
 	DeprecatedDirectiveName  = "deprecated"
 	DeprecationReasonArgName = "reason"
+	OneOfDirectiveName       = "oneOf"
+	SpecifiedByDirectiveName = "specifiedBy"
+	SpecifiedByURLArgName    = "url"
 )
 
 type Generator struct {
@@ -73,6 +76,10 @@ func (i *introspectionVisitor) EnterDocument(operation, definition *ast.Document
 }
 
 func (i *introspectionVisitor) LeaveDocument(operation, definition *ast.Document) {
+	if schemaRef := i.definition.SchemaDefinitionRef(); schemaRef != ast.InvalidRef && i.definition.SchemaDefinitions[schemaRef].Description.IsDefined {
+		description := i.definition.SchemaDefinitionDescriptionString(schemaRef)
+		i.data.Schema.Description = &description
+	}
 	if i.queryTypeName != "" {
 		i.data.Schema.QueryType = *i.data.Schema.TypeByName(i.queryTypeName)
 	}
@@ -151,6 +158,14 @@ func (i *introspectionVisitor) EnterInputValueDefinition(ref int) {
 		TypeName:     "__InputValue",
 	}
 
+	if i.definition.InputValueDefinitions[ref].HasDirectives {
+		directiveRef, exists := i.definition.InputValueDefinitionDirectiveByName(ref, []byte(DeprecatedDirectiveName))
+		if exists {
+			inputValue.IsDeprecated = true
+			inputValue.DeprecationReason = i.deprecationReason(directiveRef)
+		}
+	}
+
 	switch i.Ancestors[len(i.Ancestors)-1].Kind {
 	case ast.NodeKindInputObjectTypeDefinition:
 		i.currentType.InputFields = append(i.currentType.InputFields, inputValue)
@@ -215,6 +230,17 @@ func (i *introspectionVisitor) EnterScalarTypeDefinition(ref int) {
 	typeDefinition.Kind = SCALAR
 	typeDefinition.Name = i.definition.ScalarTypeDefinitionNameString(ref)
 	typeDefinition.Description = i.definition.ScalarTypeDefinitionDescriptionString(ref)
+
+	if i.definition.ScalarTypeDefinitionHasDirectives(ref) {
+		directiveRef, exists := i.definition.ScalarTypeDefinitionDirectiveByName(ref, []byte(SpecifiedByDirectiveName))
+		if exists {
+			if argValue, ok := i.definition.DirectiveArgumentValueByName(directiveRef, []byte(SpecifiedByURLArgName)); ok {
+				url := i.definition.ValueContentString(argValue)
+				typeDefinition.SpecifiedByURL = &url
+			}
+		}
+	}
+
 	i.data.Schema.AddType(typeDefinition)
 }
 
@@ -278,6 +304,8 @@ func (i *introspectionVisitor) EnterInputObjectTypeDefinition(ref int) {
 	i.currentType.Kind = INPUTOBJECT
 	i.currentType.Name = i.definition.InputObjectTypeDefinitionNameString(ref)
 	i.currentType.Description = i.definition.InputObjectTypeDefinitionDescriptionString(ref)
+	isOneOf := i.definition.InputObjectTypeDefinitionIsOneOf(ref)
+	i.currentType.IsOneOf = &isOneOf
 }
 
 func (i *introspectionVisitor) LeaveInputObjectTypeDefinition(ref int) {
@@ -1,11 +1,14 @@
 package introspection
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"testing"
 
 	"github.com/jensneuse/diffview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/astparser"
 	"github.com/wundergraph/graphql-go-tools/v2/pkg/testing/goldie"
@@ -78,3 +81,106 @@ func TestGenerator_Generate_Interfaces_Implementing_Interfaces(t *testing.T) {
 		diffview.NewGoland().DiffViewBytes("interfaces_implements_interfaces", fixture, outputPretty)
 	}
 }
+
+func TestGenerator_Generate_OneOf(t *testing.T) {
+	definition, report := astparser.ParseGraphqlDocumentString(`
+		scalar ID
+		scalar String
+		directive @oneOf on INPUT_OBJECT
+		type Query { pet(by: PetBy!): String }
+		input PetBy @oneOf { id: ID name: String }
+		input PetFilter { name: String }
+	`)
+	if report.HasErrors() {
+		t.Fatal(report)
+	}
+
+	var data Data
+	NewGenerator().Generate(&definition, &report, &data)
+	if report.HasErrors() {
+		t.Fatal(report)
+	}
+
+	assert.Equal(t, true, *data.Schema.TypeByName("PetBy").IsOneOf)
+	assert.Equal(t, false, *data.Schema.TypeByName("PetFilter").IsOneOf)
+	assert.Nil(t, data.Schema.TypeByName("Query").IsOneOf)
+
+	introspectionJSON, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converter := JsonConverter{}
+	doc, err := converter.GraphQLDocument(bytes.NewReader(introspectionJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, ok := doc.Index.FirstNodeByNameStr("PetBy")
+	assert.True(t, ok)
+	assert.True(t, doc.InputObjectTypeDefinitionIsOneOf(node.Ref))
+	node, ok = doc.Index.FirstNodeByNameStr("PetFilter")
+	assert.True(t, ok)
+	assert.False(t, doc.InputObjectTypeDefinitionIsOneOf(node.Ref))
+}
+
+func TestGenerator_Generate_SpecParity(t *testing.T) {
+	definition, report := astparser.ParseGraphqlDocumentString(`
+		"The pets schema"
+		schema { query: Query }
+		scalar String
+		scalar Int
+		scalar URL @specifiedBy(url: "https://url.spec.whatwg.org")
+		directive @deprecated(reason: String = "No longer supported") on FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION | ENUM_VALUE
+		directive @specifiedBy(url: String!) on SCALAR
+		type Query { pets(filter: PetFilter, first: Int @deprecated(reason: "use filter")): URL }
+		input PetFilter { name: String kind: String @deprecated }
+	`)
+	if report.HasErrors() {
+		t.Fatal(report)
+	}
+
+	var data Data
+	NewGenerator().Generate(&definition, &report, &data)
+	if report.HasErrors() {
+		t.Fatal(report)
+	}
+
+	require.NotNil(t, data.Schema.Description)
+	assert.Equal(t, "The pets schema", *data.Schema.Description)
+	require.NotNil(t, data.Schema.TypeByName("URL").SpecifiedByURL)
+	assert.Equal(t, "https://url.spec.whatwg.org", *data.Schema.TypeByName("URL").SpecifiedByURL)
+	assert.Nil(t, data.Schema.TypeByName("String").SpecifiedByURL)
+
+	args := data.Schema.TypeByName("Query").Fields[0].Args
+	assert.False(t, args[0].IsDeprecated)
+	assert.True(t, args[1].IsDeprecated)
+	assert.Equal(t, "use filter", *args[1].DeprecationReason)
+
+	inputFields := data.Schema.TypeByName("PetFilter").InputFields
+	assert.False(t, inputFields[0].IsDeprecated)
+	assert.True(t, inputFields[1].IsDeprecated)
+	assert.Equal(t, "No longer supported", *inputFields[1].DeprecationReason)
+
+	introspectionJSON, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converter := JsonConverter{}
+	doc, err := converter.GraphQLDocument(bytes.NewReader(introspectionJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "The pets schema", doc.SchemaDefinitionDescriptionString(doc.SchemaDefinitionRef()))
+	node, ok := doc.Index.FirstNodeByNameStr("URL")
+	require.True(t, ok)
+	_, ok = doc.ScalarTypeDefinitionDirectiveByName(node.Ref, []byte(SpecifiedByDirectiveName))
+	assert.True(t, ok)
+	node, ok = doc.Index.FirstNodeByNameStr("PetFilter")
+	require.True(t, ok)
+	inputFieldRefs := doc.InputObjectTypeDefinitions[node.Ref].InputFieldsDefinition.Refs
+	assert.False(t, doc.InputValueDefinitionHasDirective(inputFieldRefs[0], []byte(DeprecatedDirectiveName)))
+	assert.True(t, doc.InputValueDefinitionHasDirective(inputFieldRefs[1], []byte(DeprecatedDirectiveName)))
+}
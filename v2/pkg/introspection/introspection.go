@@ -12,6 +12,7 @@ type Data struct {
 }
 
 type Schema struct {
+	Description      *string     `json:"description"`
 	QueryType        FullType    `json:"queryType"`
 	MutationType     *FullType   `json:"mutationType"`
 	SubscriptionType *FullType   `json:"subscriptionType"`
@@ -66,7 +67,11 @@ type FullType struct {
 	EnumValues []EnumValue `json:"enumValues,omitempty"`
 	// not empty for __TypeKind INTERFACE and UNION only
 	PossibleTypes []TypeRef `json:"possibleTypes"`
-	TypeName      string    `json:"__typename"`
+	// not nil for __TypeKind INPUT_OBJECT only
+	IsOneOf *bool `json:"isOneOf,omitempty"`
+	// not nil for __TypeKind SCALAR annotated with @specifiedBy only
+	SpecifiedByURL *string `json:"specifiedByURL"`
+	TypeName       string  `json:"__typename"`
 }
 
 func NewFullType() *FullType {
@@ -148,11 +153,13 @@ type EnumValue struct {
 }
 
 type InputValue struct {
-	Name         string  `json:"name"`
-	Description  string  `json:"description"`
-	Type         TypeRef `json:"type"`
-	DefaultValue *string `json:"defaultValue"`
-	TypeName     string  `json:"__typename"`
+	Name              string  `json:"name"`
+	Description       string  `json:"description"`
+	Type              TypeRef `json:"type"`
+	DefaultValue      *string `json:"defaultValue"`
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
+	TypeName          string  `json:"__typename"`
 }
 
 type Directive struct {
@@ -112,6 +112,8 @@ var (
 	OP                            = []byte("op")
 	REPLACE                       = []byte("replace")
 	INITIAL_BATCH_SIZE            = []byte("initialBatchSize")
+	INITIAL_COUNT                 = []byte("initialCount")
+	LABEL                         = []byte("label")
 	MILLISECONDS                  = []byte("milliSeconds")
 	PATH                          = []byte("path")
 	VALUE                         = []byte("value")
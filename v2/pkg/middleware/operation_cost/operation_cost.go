@@ -0,0 +1,673 @@
+/*
+Package operation_cost implements demand control based on the GraphQL Cost Directive specification.
+
+The Calculator estimates the cost of an operation before it gets planned and computes the actual cost
+of an operation from its resolved response. The calculation can be tuned with two directives:
+
+	directive @cost(weight: Int!) on ARGUMENT_DEFINITION | ENUM | FIELD_DEFINITION | INPUT_FIELD_DEFINITION | OBJECT | SCALAR
+	directive @listSize(assumedSize: Int, slicingArguments: [String!], sizedFields: [String!], requireOneSlicingArgument: Boolean = true) on FIELD_DEFINITION
+
+cost:
+The weight of a field, argument, input field or of every field returning the annotated type.
+Without @cost, object, interface and union types weigh 1 and scalars and enums weigh 0.
+
+listSize:
+The size of the list returned by a field. The value of the slicing arguments takes precedence over assumedSize.
+When sizedFields are defined, the size applies to the listed child fields instead of the field itself, e.g. for connections.
+Lists without a known size are assumed to contain Options.DefaultListSize items.
+
+The cost of a field is the cost of its arguments plus the weight of the field and the cost of its selections
+multiplied by the size of the list. Mutations have a base cost of 10, introspection fields are free.
+*/
+package operation_cost
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wundergraph/astjson"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafebytes"
+)
+
+const (
+	CostDirectiveName     = "cost"
+	ListSizeDirectiveName = "listSize"
+
+	DefaultListSize = 10
+
+	mutationOperationWeight = 10
+	compositeTypeWeight     = 1
+	leafTypeWeight          = 0
+)
+
+var (
+	costWeightArg                = []byte("weight")
+	listSizeAssumedSizeArg       = []byte("assumedSize")
+	listSizeSlicingArgumentsArg  = []byte("slicingArguments")
+	listSizeSizedFieldsArg       = []byte("sizedFields")
+	listSizeRequireOneSlicingArg = []byte("requireOneSlicingArgument")
+	dataKey                      = "data"
+	incrementalKey               = "incremental"
+	pathKey                      = "path"
+	itemsKey                     = "items"
+	typeNameKey                  = "__typename"
+	reservedFieldPrefix          = []byte("__")
+)
+
+type Options struct {
+	// DefaultListSize is the assumed size of lists without @listSize, defaults to DefaultListSize
+	DefaultListSize int
+}
+
+// Calculator computes the estimated and actual cost of operations against a schema definition.
+// It is safe for concurrent use.
+type Calculator struct {
+	definition      *ast.Document
+	defaultListSize int
+}
+
+func NewCalculator(definition *ast.Document, options Options) *Calculator {
+	if options.DefaultListSize <= 0 {
+		options.DefaultListSize = DefaultListSize
+	}
+
+	return &Calculator{
+		definition:      definition,
+		defaultListSize: options.DefaultListSize,
+	}
+}
+
+// EstimatedCost returns the cost of the operation calculated from the schema and the arguments of the operation
+func (c *Calculator) EstimatedCost(operation *ast.Document, operationName string, variables []byte) (int, error) {
+	w, operationRef, err := c.newWalker(operation, operationName, variables)
+	if err != nil {
+		return 0, err
+	}
+
+	return w.operationCost(operationRef, nil)
+}
+
+// ActualCost returns the cost of the operation calculated from the resolved response,
+// lists are counted with their actual size
+func (c *Calculator) ActualCost(operation *ast.Document, operationName string, variables []byte, response []byte) (int, error) {
+	w, operationRef, err := c.newWalker(operation, operationName, variables)
+	if err != nil {
+		return 0, err
+	}
+
+	parsed, err := astjson.ParseBytesWithoutCache(response)
+	if err != nil {
+		return 0, err
+	}
+
+	data := parsed.Get(dataKey)
+	if data == nil || data.Type() != astjson.TypeObject {
+		return 0, nil
+	}
+
+	w.actual = true
+	return w.operationCost(operationRef, data)
+}
+
+// ActualIncrementalCost returns the actual cost of a response delivered incrementally with @defer and @stream
+// The data of the deferred fragments and the streamed list items of the subsequent payloads is merged
+// into the data of the initial payload before the cost is calculated
+func (c *Calculator) ActualIncrementalCost(operation *ast.Document, operationName string, variables []byte, payloads [][]byte) (int, error) {
+	w, operationRef, err := c.newWalker(operation, operationName, variables)
+	if err != nil {
+		return 0, err
+	}
+	if len(payloads) == 0 {
+		return 0, nil
+	}
+
+	initial, err := astjson.ParseBytesWithoutCache(payloads[0])
+	if err != nil {
+		return 0, err
+	}
+	data := initial.Get(dataKey)
+	if data == nil || data.Type() != astjson.TypeObject {
+		return 0, nil
+	}
+
+	for _, payload := range payloads[1:] {
+		parsed, err := astjson.ParseBytesWithoutCache(payload)
+		if err != nil {
+			return 0, err
+		}
+		for _, incremental := range parsed.GetArray(incrementalKey) {
+			mergeIncrementalResult(data, incremental)
+		}
+	}
+
+	w.actual = true
+	return w.operationCost(operationRef, data)
+}
+
+// mergeIncrementalResult merges the data of a deferred fragment into the object at its path
+// or appends the streamed items to the list at the path of the first item
+func mergeIncrementalResult(data, incremental *astjson.Value) {
+	path := incremental.GetArray(pathKey)
+	keys := make([]string, 0, len(path))
+	for _, element := range path {
+		switch element.Type() {
+		case astjson.TypeString:
+			keys = append(keys, string(element.GetStringBytes()))
+		case astjson.TypeNumber:
+			keys = append(keys, strconv.Itoa(element.GetInt()))
+		}
+	}
+
+	if items := incremental.Get(itemsKey); items != nil && len(keys) > 0 {
+		list := data.Get(keys[:len(keys)-1]...)
+		if list == nil || list.Type() != astjson.TypeArray {
+			return
+		}
+		for _, item := range items.GetArray() {
+			astjson.AppendToArray(list, item)
+		}
+		return
+	}
+
+	target := data.Get(keys...)
+	fragment := incremental.Get(dataKey)
+	if target == nil || target.Type() != astjson.TypeObject || fragment == nil {
+		return
+	}
+	astjson.MergeValues(target, fragment)
+}
+
+func (c *Calculator) newWalker(operation *ast.Document, operationName string, variables []byte) (*costWalker, int, error) {
+	operationRef := c.operationDefinitionRef(operation, operationName)
+	if operationRef == ast.InvalidRef {
+		return nil, ast.InvalidRef, fmt.Errorf("operation %q not found", operationName)
+	}
+
+	w := &costWalker{
+		Calculator: c,
+		operation:  operation,
+	}
+
+	if len(variables) > 0 {
+		parsed, err := astjson.ParseBytesWithoutCache(variables)
+		if err != nil {
+			return nil, ast.InvalidRef, err
+		}
+		w.variables = parsed
+	}
+
+	return w, operationRef, nil
+}
+
+func (c *Calculator) operationDefinitionRef(operation *ast.Document, operationName string) int {
+	for _, node := range operation.RootNodes {
+		if node.Kind != ast.NodeKindOperationDefinition {
+			continue
+		}
+		if operationName == "" || operation.OperationDefinitionNameString(node.Ref) == operationName {
+			return node.Ref
+		}
+	}
+	return ast.InvalidRef
+}
+
+type costWalker struct {
+	*Calculator
+	operation *ast.Document
+	variables *astjson.Value
+	actual    bool
+}
+
+func (w *costWalker) operationCost(operationRef int, data *astjson.Value) (int, error) {
+	var (
+		cost          int
+		rootTypeName  ast.ByteSlice
+		operationType = w.operation.OperationDefinitions[operationRef].OperationType
+	)
+
+	switch operationType {
+	case ast.OperationTypeMutation:
+		cost = mutationOperationWeight
+		rootTypeName = w.definition.Index.MutationTypeName
+	case ast.OperationTypeSubscription:
+		rootTypeName = w.definition.Index.SubscriptionTypeName
+	default:
+		rootTypeName = w.definition.Index.QueryTypeName
+	}
+
+	if !w.operation.OperationDefinitions[operationRef].HasSelections {
+		return cost, nil
+	}
+
+	selectionsCost, err := w.selectionSetCost(w.operation.OperationDefinitions[operationRef].SelectionSet, rootTypeName, nil, data)
+	if err != nil {
+		return 0, err
+	}
+
+	return cost + selectionsCost, nil
+}
+
+func (w *costWalker) selectionSetCost(selectionSetRef int, enclosingTypeName ast.ByteSlice, sizedFields map[string]int, data *astjson.Value) (cost int, err error) {
+	for _, selectionRef := range w.operation.SelectionSets[selectionSetRef].SelectionRefs {
+		var selectionCost int
+		selection := w.operation.Selections[selectionRef]
+
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			selectionCost, err = w.fieldCost(selection.Ref, enclosingTypeName, sizedFields, data)
+		case ast.SelectionKindInlineFragment:
+			typeName := enclosingTypeName
+			if w.operation.InlineFragmentHasTypeCondition(selection.Ref) {
+				typeName = w.operation.InlineFragmentTypeConditionName(selection.Ref)
+			}
+			if !w.typeConditionMatches(typeName, data) || !w.operation.InlineFragments[selection.Ref].HasSelections {
+				continue
+			}
+			selectionCost, err = w.selectionSetCost(w.operation.InlineFragments[selection.Ref].SelectionSet, typeName, sizedFields, data)
+		case ast.SelectionKindFragmentSpread:
+			fragmentRef, exists := w.operation.FragmentDefinitionRef(w.operation.FragmentSpreadNameBytes(selection.Ref))
+			if !exists {
+				continue
+			}
+			typeName := w.operation.FragmentDefinitionTypeName(fragmentRef)
+			if !w.typeConditionMatches(typeName, data) {
+				continue
+			}
+			selectionCost, err = w.selectionSetCost(w.operation.FragmentDefinitions[fragmentRef].SelectionSet, typeName, sizedFields, data)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+		cost += selectionCost
+	}
+
+	return cost, nil
+}
+
+// typeConditionMatches skips fragments on object types which don't match the __typename of the resolved object
+func (w *costWalker) typeConditionMatches(typeName ast.ByteSlice, data *astjson.Value) bool {
+	if !w.actual || data == nil {
+		return true
+	}
+
+	actualTypeName := data.GetStringBytes(typeNameKey)
+	if actualTypeName == nil {
+		return true
+	}
+
+	node, exists := w.definition.Index.FirstNodeByNameBytes(typeName)
+	if !exists || node.Kind != ast.NodeKindObjectTypeDefinition {
+		return true
+	}
+
+	return bytes.Equal(typeName, actualTypeName)
+}
+
+func (w *costWalker) fieldCost(fieldRef int, enclosingTypeName ast.ByteSlice, sizedFields map[string]int, data *astjson.Value) (int, error) {
+	fieldName := w.operation.FieldNameBytes(fieldRef)
+	if bytes.HasPrefix(fieldName, reservedFieldPrefix) {
+		return 0, nil
+	}
+
+	enclosingNode, exists := w.definition.Index.FirstNodeByNameBytes(enclosingTypeName)
+	if !exists {
+		return 0, nil
+	}
+
+	fieldDefinitionRef, exists := w.definition.NodeFieldDefinitionByName(enclosingNode, fieldName)
+	if !exists {
+		return 0, nil
+	}
+
+	argumentsCost, err := w.argumentsCost(fieldRef, fieldDefinitionRef)
+	if err != nil {
+		return 0, err
+	}
+
+	fieldTypeRef := w.definition.FieldDefinitionType(fieldDefinitionRef)
+	fieldTypeName := w.definition.ResolveTypeNameBytes(fieldTypeRef)
+	weight := w.fieldWeight(fieldDefinitionRef, fieldTypeName)
+
+	if w.actual {
+		value := data.Get(w.operation.FieldAliasOrNameString(fieldRef))
+		valueCost, err := w.actualValueCost(fieldRef, fieldTypeName, weight, value)
+		if err != nil {
+			return 0, err
+		}
+		return argumentsCost + valueCost, nil
+	}
+
+	parentSize, hasParentSize := sizedFields[unsafebytes.BytesToString(fieldName)]
+	size, childSizedFields, err := w.listSize(fieldRef, fieldDefinitionRef, enclosingTypeName, parentSize, hasParentSize, sizedFields)
+	if err != nil {
+		return 0, err
+	}
+
+	var selectionsCost int
+	if w.operation.FieldHasSelections(fieldRef) {
+		selectionsCost, err = w.selectionSetCost(w.operation.Fields[fieldRef].SelectionSet, fieldTypeName, childSizedFields, nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	multiplier := 1
+	if w.definition.TypeIsList(fieldTypeRef) {
+		multiplier = size
+	}
+
+	return argumentsCost + multiplier*(weight+selectionsCost), nil
+}
+
+func (w *costWalker) actualValueCost(fieldRef int, fieldTypeName ast.ByteSlice, weight int, value *astjson.Value) (int, error) {
+	if value == nil {
+		return 0, nil
+	}
+
+	switch value.Type() {
+	case astjson.TypeNull:
+		return 0, nil
+	case astjson.TypeArray:
+		var cost int
+		for _, item := range value.GetArray() {
+			itemCost, err := w.actualValueCost(fieldRef, fieldTypeName, weight, item)
+			if err != nil {
+				return 0, err
+			}
+			cost += itemCost
+		}
+		return cost, nil
+	case astjson.TypeObject:
+		if !w.operation.FieldHasSelections(fieldRef) {
+			return weight, nil
+		}
+		selectionsCost, err := w.selectionSetCost(w.operation.Fields[fieldRef].SelectionSet, fieldTypeName, nil, value)
+		if err != nil {
+			return 0, err
+		}
+		return weight + selectionsCost, nil
+	default:
+		return weight, nil
+	}
+}
+
+// fieldWeight returns the @cost weight of the field definition, of its return type or the default weight of the return type
+func (w *costWalker) fieldWeight(fieldDefinitionRef int, fieldTypeName ast.ByteSlice) int {
+	if weight, ok := w.costWeight(w.definition.FieldDefinitions[fieldDefinitionRef].Directives.Refs); ok {
+		return weight
+	}
+
+	node, exists := w.definition.Index.FirstNodeByNameBytes(fieldTypeName)
+	if !exists {
+		return leafTypeWeight
+	}
+
+	if weight, ok := w.costWeight(w.definition.NodeDirectives(node)); ok {
+		return weight
+	}
+
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
+		return compositeTypeWeight
+	default:
+		return leafTypeWeight
+	}
+}
+
+func (w *costWalker) argumentsCost(fieldRef, fieldDefinitionRef int) (cost int, err error) {
+	for _, argumentRef := range w.operation.Fields[fieldRef].Arguments.Refs {
+		inputValueDefinitionRef := w.argumentDefinition(fieldDefinitionRef, w.operation.ArgumentNameBytes(argumentRef))
+		if inputValueDefinitionRef == ast.InvalidRef {
+			continue
+		}
+
+		value := w.resolveValue(w.operation.ArgumentValue(argumentRef))
+		cost += w.inputValueCost(inputValueDefinitionRef, value)
+	}
+
+	return cost, nil
+}
+
+// inputValueCost returns the weight of a provided argument or input field and of the input fields nested into it
+func (w *costWalker) inputValueCost(inputValueDefinitionRef int, value *astjson.Value) int {
+	if value == nil || value.Type() == astjson.TypeNull {
+		return 0
+	}
+
+	var cost int
+	if weight, ok := w.costWeight(w.definition.InputValueDefinitions[inputValueDefinitionRef].Directives.Refs); ok {
+		cost += weight
+	}
+
+	typeName := w.definition.ResolveTypeNameBytes(w.definition.InputValueDefinitionType(inputValueDefinitionRef))
+	node, exists := w.definition.Index.FirstNodeByNameBytes(typeName)
+	if !exists || node.Kind != ast.NodeKindInputObjectTypeDefinition {
+		return cost
+	}
+
+	return cost + w.inputObjectCost(node.Ref, value)
+}
+
+func (w *costWalker) inputObjectCost(inputObjectRef int, value *astjson.Value) (cost int) {
+	switch value.Type() {
+	case astjson.TypeArray:
+		for _, item := range value.GetArray() {
+			cost += w.inputObjectCost(inputObjectRef, item)
+		}
+	case astjson.TypeObject:
+		for _, inputFieldRef := range w.definition.InputObjectTypeDefinitions[inputObjectRef].InputFieldsDefinition.Refs {
+			cost += w.inputValueCost(inputFieldRef, value.Get(w.definition.InputValueDefinitionNameString(inputFieldRef)))
+		}
+	}
+	return cost
+}
+
+// listSize returns the size of the list returned by the field and the sizes of child fields listed in sizedFields
+func (w *costWalker) listSize(fieldRef, fieldDefinitionRef int, enclosingTypeName ast.ByteSlice, parentSize int, hasParentSize bool, parentSizedFields map[string]int) (size int, sizedFields map[string]int, err error) {
+	size = w.defaultListSize
+	if hasParentSize {
+		size = parentSize
+	}
+	sizedFields = nestedSizedFields(parentSizedFields, w.operation.FieldNameString(fieldRef))
+
+	directiveRef, exists := w.definition.FieldDefinitionDirectiveByName(fieldDefinitionRef, []byte(ListSizeDirectiveName))
+	if !exists {
+		return size, sizedFields, nil
+	}
+
+	childSize := w.defaultListSize
+	if assumedSize, ok := w.directiveIntArgument(directiveRef, listSizeAssumedSizeArg); ok {
+		childSize = assumedSize
+	}
+
+	slicedSize, hasSlicedSize, err := w.slicingArgumentsSize(fieldRef, fieldDefinitionRef, enclosingTypeName, directiveRef)
+	if err != nil {
+		return 0, nil, err
+	}
+	if hasSlicedSize {
+		childSize = slicedSize
+	}
+
+	fieldNames := w.directiveStringListArgument(directiveRef, listSizeSizedFieldsArg)
+	if len(fieldNames) == 0 {
+		if !hasParentSize || hasSlicedSize {
+			size = childSize
+		}
+		return size, sizedFields, nil
+	}
+
+	if sizedFields == nil {
+		sizedFields = make(map[string]int, len(fieldNames))
+	}
+	for _, fieldName := range fieldNames {
+		sizedFields[fieldName] = childSize
+	}
+
+	return size, sizedFields, nil
+}
+
+// nestedSizedFields returns the remaining paths of dotted sizedFields below the field
+func nestedSizedFields(sizedFields map[string]int, fieldName string) map[string]int {
+	var nested map[string]int
+	prefix := fieldName + "."
+	for path, size := range sizedFields {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if nested == nil {
+			nested = make(map[string]int)
+		}
+		nested[strings.TrimPrefix(path, prefix)] = size
+	}
+	return nested
+}
+
+// slicingArgumentsSize returns the largest value of the slicing arguments provided to the field, falling back to their default values
+func (w *costWalker) slicingArgumentsSize(fieldRef, fieldDefinitionRef int, enclosingTypeName ast.ByteSlice, directiveRef int) (size int, ok bool, err error) {
+	slicingArguments := w.directiveStringListArgument(directiveRef, listSizeSlicingArgumentsArg)
+	if len(slicingArguments) == 0 {
+		return 0, false, nil
+	}
+
+	requireOneSlicingArgument := true
+	if value, exists := w.definition.DirectiveArgumentValueByName(directiveRef, listSizeRequireOneSlicingArg); exists && value.Kind == ast.ValueKindBoolean {
+		requireOneSlicingArgument = bool(w.definition.BooleanValue(value.Ref))
+	}
+
+	var provided int
+	for _, argumentName := range slicingArguments {
+		argumentRef, exists := w.operation.FieldArgument(fieldRef, []byte(argumentName))
+		if !exists {
+			continue
+		}
+		value, isInt := intValue(w.resolveValue(w.operation.ArgumentValue(argumentRef)))
+		if !isInt {
+			continue
+		}
+		provided++
+		if !ok || value > size {
+			size, ok = value, true
+		}
+	}
+
+	if provided == 0 {
+		for _, argumentName := range slicingArguments {
+			inputValueDefinitionRef := w.argumentDefinition(fieldDefinitionRef, []byte(argumentName))
+			if inputValueDefinitionRef == ast.InvalidRef || !w.definition.InputValueDefinitionHasDefaultValue(inputValueDefinitionRef) {
+				continue
+			}
+			defaultValue := w.definition.InputValueDefinitionDefaultValue(inputValueDefinitionRef)
+			if defaultValue.Kind != ast.ValueKindInteger {
+				continue
+			}
+			value := int(w.definition.IntValueAsInt(defaultValue.Ref))
+			if !ok || value > size {
+				size, ok = value, true
+			}
+		}
+	}
+
+	if requireOneSlicingArgument && (provided > 1 || !ok) {
+		return 0, false, fmt.Errorf("exactly one slicing argument of %s must be provided to field %s.%s",
+			strings.Join(slicingArguments, ", "), enclosingTypeName, w.operation.FieldNameString(fieldRef))
+	}
+
+	return size, ok, nil
+}
+
+func (w *costWalker) argumentDefinition(fieldDefinitionRef int, argumentName ast.ByteSlice) int {
+	for _, inputValueDefinitionRef := range w.definition.FieldDefinitionArgumentsDefinitions(fieldDefinitionRef) {
+		if bytes.Equal(w.definition.InputValueDefinitionNameBytes(inputValueDefinitionRef), argumentName) {
+			return inputValueDefinitionRef
+		}
+	}
+	return ast.InvalidRef
+}
+
+// resolveValue returns the JSON representation of an argument value, variables are looked up in the request variables
+func (w *costWalker) resolveValue(value ast.Value) *astjson.Value {
+	if value.Kind == ast.ValueKindVariable {
+		if w.variables == nil {
+			return nil
+		}
+		return w.variables.Get(w.operation.VariableValueNameString(value.Ref))
+	}
+
+	content, err := w.operation.ValueToJSON(value)
+	if err != nil {
+		return nil
+	}
+	parsed, err := astjson.ParseBytesWithoutCache(content)
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
+
+func (w *costWalker) costWeight(directiveRefs []int) (int, bool) {
+	for _, directiveRef := range directiveRefs {
+		if w.definition.DirectiveNameString(directiveRef) != CostDirectiveName {
+			continue
+		}
+		return w.directiveIntArgument(directiveRef, costWeightArg)
+	}
+	return 0, false
+}
+
+// directiveIntArgument reads an Int argument of a schema directive, numeric strings are accepted as well
+func (w *costWalker) directiveIntArgument(directiveRef int, argumentName ast.ByteSlice) (int, bool) {
+	value, exists := w.definition.DirectiveArgumentValueByName(directiveRef, argumentName)
+	if !exists {
+		return 0, false
+	}
+
+	switch value.Kind {
+	case ast.ValueKindInteger:
+		return int(w.definition.IntValueAsInt(value.Ref)), true
+	case ast.ValueKindString:
+		parsed, err := strconv.Atoi(w.definition.ValueContentString(value))
+		if err != nil {
+			return 0, false
+		}
+		return parsed, true
+	}
+	return 0, false
+}
+
+func (w *costWalker) directiveStringListArgument(directiveRef int, argumentName ast.ByteSlice) []string {
+	value, exists := w.definition.DirectiveArgumentValueByName(directiveRef, argumentName)
+	if !exists {
+		return nil
+	}
+
+	switch value.Kind {
+	case ast.ValueKindString:
+		return []string{w.definition.ValueContentString(value)}
+	case ast.ValueKindList:
+		out := make([]string, 0, len(w.definition.ListValues[value.Ref].Refs))
+		for _, ref := range w.definition.ListValues[value.Ref].Refs {
+			item := w.definition.Values[ref]
+			if item.Kind == ast.ValueKindString {
+				out = append(out, w.definition.ValueContentString(item))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func intValue(value *astjson.Value) (int, bool) {
+	if value == nil || value.Type() != astjson.TypeNumber {
+		return 0, false
+	}
+	out, err := value.Int()
+	if err != nil {
+		return 0, false
+	}
+	return out, true
+}
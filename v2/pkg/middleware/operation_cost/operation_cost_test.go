@@ -0,0 +1,144 @@
+package operation_cost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/internal/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+func TestCalculator_EstimatedCost(t *testing.T) {
+	run := func(operation, variables string, expectedCost int) func(t *testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			def, op := prepare(t, operation)
+			cost, err := NewCalculator(&def, Options{}).EstimatedCost(&op, "", []byte(variables))
+			require.NoError(t, err)
+			assert.Equal(t, expectedCost, cost)
+		}
+	}
+
+	t.Run("object field", run(`{ user(id: 1) { id name } }`, ``, 1))
+	t.Run("list sized by slicing argument", run(`{ users(first: 5) { id address { city } } }`, ``, 15))
+	t.Run("slicing argument from variables", run(`query Users($n: Int) { users(first: $n) { id } }`, `{"n":2}`, 2))
+	t.Run("assumed size", run(`{ featured { friends { id } } }`, ``, 33))
+	t.Run("sized fields with default slicing argument", run(`{ userConnection { edges { node { id } } } }`, ``, 41))
+	t.Run("sized fields with slicing argument", run(`{ userConnection(first: 2) { edges { node { id } } } }`, ``, 5))
+	t.Run("argument and field weights", run(`{ search(filter: {fullText: "droid"}) { id } expensive }`, ``, 13))
+	t.Run("list of scalars", run(`{ tags }`, ``, 0))
+	t.Run("mutation", run(`mutation { createUser(name: "Jens") { id } }`, ``, 11))
+	t.Run("introspection is free", run(`{ __typename __schema { types { name } } }`, ``, 0))
+
+	t.Run("default list size", func(t *testing.T) {
+		def, op := prepare(t, `{ user(id: 1) { friends { id } } }`)
+		cost, err := NewCalculator(&def, Options{DefaultListSize: 4}).EstimatedCost(&op, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 5, cost)
+	})
+
+	t.Run("missing slicing argument", func(t *testing.T) {
+		def, op := prepare(t, `{ users { id } }`)
+		_, err := NewCalculator(&def, Options{}).EstimatedCost(&op, "", nil)
+		assert.EqualError(t, err, "exactly one slicing argument of first, last must be provided to field Query.users")
+	})
+
+	t.Run("more than one slicing argument", func(t *testing.T) {
+		def, op := prepare(t, `{ users(first: 5, last: 3) { id } }`)
+		_, err := NewCalculator(&def, Options{}).EstimatedCost(&op, "", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestCalculator_ActualCost(t *testing.T) {
+	run := func(operation, response string, expectedCost int) func(t *testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			def, op := prepare(t, operation)
+			cost, err := NewCalculator(&def, Options{}).ActualCost(&op, "", nil, []byte(response))
+			require.NoError(t, err)
+			assert.Equal(t, expectedCost, cost)
+		}
+	}
+
+	t.Run("list with actual size", run(`{ users(first: 5) { id address { city } } }`,
+		`{"data":{"users":[{"id":"1","address":{"city":"Berlin"}},{"id":"2","address":null}]}}`, 4))
+	t.Run("sized fields", run(`{ userConnection { edges { node { id } } } }`,
+		`{"data":{"userConnection":{"edges":[{"node":{"id":"1"}},{"node":{"id":"2"}}]}}}`, 5))
+	t.Run("null root field", run(`{ user(id: 1) { id } }`, `{"data":{"user":null}}`, 0))
+	t.Run("errors without data", run(`{ user(id: 1) { id } }`, `{"errors":[{"message":"boom"}]}`, 0))
+}
+
+func TestCalculator_ActualIncrementalCost(t *testing.T) {
+	def, op := prepare(t, `{ users(first: 5) @stream(initialCount: 1) { id ... @defer { address { city } } } }`)
+	cost, err := NewCalculator(&def, Options{}).ActualIncrementalCost(&op, "", nil, [][]byte{
+		[]byte(`{"data":{"users":[{"id":"1"}]},"hasNext":true}`),
+		[]byte(`{"incremental":[{"items":[{"id":"2"}],"path":["users",1]}],"hasNext":true}`),
+		[]byte(`{"incremental":[{"data":{"address":{"city":"Berlin"}},"path":["users",0]},{"data":{"address":null},"path":["users",1]}],"hasNext":false}`),
+	})
+	require.NoError(t, err)
+	// the same cost as the response resolved at once
+	assert.Equal(t, 4, cost)
+}
+
+func prepare(t *testing.T, operation string) (def, op ast.Document) {
+	t.Helper()
+	def = unsafeparser.ParseGraphqlDocumentStringWithBaseSchema(testDefinition)
+	op = unsafeparser.ParseGraphqlDocumentString(operation)
+	report := operationreport.Report{}
+	astnormalization.NormalizeOperation(&op, &def, &report)
+	require.False(t, report.HasErrors(), report.Error())
+	return def, op
+}
+
+const testDefinition = `
+directive @cost(weight: Int!) on ARGUMENT_DEFINITION | ENUM | FIELD_DEFINITION | INPUT_FIELD_DEFINITION | OBJECT | SCALAR
+directive @listSize(assumedSize: Int, slicingArguments: [String!], sizedFields: [String!], requireOneSlicingArgument: Boolean = true) on FIELD_DEFINITION
+
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+type Query {
+	user(id: ID!): User
+	users(first: Int, last: Int): [User!]! @listSize(slicingArguments: ["first", "last"])
+	tags: [String!]!
+	featured: [User!]! @listSize(assumedSize: 3)
+	search(filter: SearchFilter): [User!]! @listSize(assumedSize: 5)
+	userConnection(first: Int = 20): UserConnection! @listSize(slicingArguments: ["first"], sizedFields: ["edges"])
+	expensive: Int @cost(weight: 5)
+}
+
+type Mutation {
+	createUser(name: String!): User
+}
+
+input SearchFilter {
+	name: String
+	fullText: String @cost(weight: 3)
+}
+
+type User {
+	id: ID!
+	name: String!
+	friends: [User!]!
+	address: Address
+}
+
+type Address @cost(weight: 2) {
+	city: String!
+}
+
+type UserConnection {
+	edges: [UserEdge!]!
+}
+
+type UserEdge {
+	node: User!
+}
+`
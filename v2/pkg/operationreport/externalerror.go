@@ -27,6 +27,9 @@ const (
 	UnknownFieldOfInputObjectErrMsg         = `Field "%s" is not defined by type "%s".`
 	DuplicatedFieldInputObjectErrMsg        = `There can be only one input field named "%s".`
 	ValueIsNotAnInputObjectTypeErrMsg       = `Expected value of type "%s", found %s.`
+	OneOfInputObjectFieldCountErrMsg        = `OneOf Input Object "%s" must specify exactly one key.`
+	OneOfInputObjectNullFieldErrMsg         = `Field "%s.%s" must be non-null.`
+	OneOfInputObjectNullableVariableErrMsg  = `Variable "$%s" must be non-nullable to be used for OneOf Input Object "%s".`
 )
 
 type ExternalError struct {
@@ -204,6 +207,27 @@ func ErrUnknownFieldOfInputObject(objName, fieldName ast.ByteSlice, position pos
 	return err
 }
 
+func ErrOneOfInputObjectFieldCount(objName ast.ByteSlice, position position.Position) (err ExternalError) {
+	err.Message = fmt.Sprintf(OneOfInputObjectFieldCountErrMsg, objName)
+	err.Locations = LocationsFromPosition(position)
+
+	return err
+}
+
+func ErrOneOfInputObjectNullField(objName, fieldName ast.ByteSlice, position position.Position) (err ExternalError) {
+	err.Message = fmt.Sprintf(OneOfInputObjectNullFieldErrMsg, objName, fieldName)
+	err.Locations = LocationsFromPosition(position)
+
+	return err
+}
+
+func ErrOneOfInputObjectNullableVariable(variableName, objName ast.ByteSlice, position position.Position) (err ExternalError) {
+	err.Message = fmt.Sprintf(OneOfInputObjectNullableVariableErrMsg, variableName, objName)
+	err.Locations = LocationsFromPosition(position)
+
+	return err
+}
+
 func ErrDuplicatedFieldInputObject(fieldName ast.ByteSlice, first, duplicated position.Position) (err ExternalError) {
 	err.Message = fmt.Sprintf(DuplicatedFieldInputObjectErrMsg, fieldName)
 
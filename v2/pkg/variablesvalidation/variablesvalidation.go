@@ -332,6 +332,36 @@ func (v *variablesVisitor) renderVariableEnumValueDoesNotExistError(typeName []b
 	)
 }
 
+// traverseOneOfInputObject validates that exactly one field of a @oneOf input object is set to a non-null value
+func (v *variablesVisitor) traverseOneOfInputObject(jsonNodeRef int, typeName []byte) {
+	fields := v.variables.Nodes[jsonNodeRef].ObjectFields
+	if len(fields) != 1 {
+		v.renderVariableOneOfError(fmt.Sprintf(`Exactly one key must be specified for OneOf type "%s".`, string(typeName)))
+		return
+	}
+	if v.variables.Nodes[v.variables.Nodes[fields[0]].ObjectFieldValue].Kind == astjson.NodeKindNull {
+		v.renderVariableOneOfError(fmt.Sprintf(`Field "%s" must be non-null.`, string(v.variables.ObjectFieldKey(fields[0]))))
+	}
+}
+
+func (v *variablesVisitor) renderVariableOneOfError(message string) {
+	buf := &bytes.Buffer{}
+	err := v.variables.PrintNode(v.variables.Nodes[v.currentVariableJsonNodeRef], buf)
+	if err != nil {
+		v.err = err
+		return
+	}
+	invalidValue := buf.String()
+	var path string
+	if len(v.path) > 1 {
+		path = fmt.Sprintf(` at "%s"`, v.renderPath())
+	}
+	v.err = newInvalidVariableError(
+		fmt.Sprintf(`Variable "$%s" got invalid value %s%s; %s`, string(v.currentVariableName), invalidValue, path, message),
+		v.apolloCompatibilityFlags.ReplaceInvalidVarError,
+	)
+}
+
 func (v *variablesVisitor) renderVariableInvalidNullError(variableName []byte, typeRef int) {
 	buf := &bytes.Buffer{}
 	err := v.operation.PrintType(typeRef, buf)
@@ -424,6 +454,9 @@ func (v *variablesVisitor) traverseNamedTypeNode(jsonNodeRef int, typeName []byt
 				return
 			}
 		}
+		if v.definition.InputObjectTypeDefinitionIsOneOf(fieldTypeDefinitionNode.Ref) {
+			v.traverseOneOfInputObject(jsonNodeRef, typeName)
+		}
 	case ast.NodeKindScalarTypeDefinition:
 		switch unsafebytes.BytesToString(typeName) {
 		case "String":
@@ -795,6 +795,63 @@ func TestVariablesValidation(t *testing.T) {
 			Message:       `Variable "$input" got invalid value null; Expected non-nullable type "String!" not to be null.`,
 		}, err)
 	})
+
+	t.Run("oneOf input object", func(t *testing.T) {
+		schema := `
+			type Query { pet(by: PetBy!): String pets(filter: PetFilter): String }
+			input PetBy @oneOf { id: ID name: String }
+			input PetFilter { by: PetBy }
+		`
+
+		t.Run("exactly one field", func(t *testing.T) {
+			err := runTest(t, testCase{
+				schema:    schema,
+				operation: `query Foo($by: PetBy!) { pet(by: $by) }`,
+				variables: `{"by":{"name":"Fido"}}`,
+			})
+			require.NoError(t, err)
+		})
+
+		t.Run("no field", func(t *testing.T) {
+			err := runTest(t, testCase{
+				schema:    schema,
+				operation: `query Foo($by: PetBy!) { pet(by: $by) }`,
+				variables: `{"by":{}}`,
+			})
+			require.Error(t, err)
+			assert.Equal(t, `Variable "$by" got invalid value {}; Exactly one key must be specified for OneOf type "PetBy".`, err.Error())
+		})
+
+		t.Run("more than one field", func(t *testing.T) {
+			err := runTest(t, testCase{
+				schema:    schema,
+				operation: `query Foo($by: PetBy!) { pet(by: $by) }`,
+				variables: `{"by":{"id":"1","name":"Fido"}}`,
+			})
+			require.Error(t, err)
+			assert.Equal(t, `Variable "$by" got invalid value {"id":"1","name":"Fido"}; Exactly one key must be specified for OneOf type "PetBy".`, err.Error())
+		})
+
+		t.Run("null field", func(t *testing.T) {
+			err := runTest(t, testCase{
+				schema:    schema,
+				operation: `query Foo($by: PetBy!) { pet(by: $by) }`,
+				variables: `{"by":{"name":null}}`,
+			})
+			require.Error(t, err)
+			assert.Equal(t, `Variable "$by" got invalid value {"name":null}; Field "name" must be non-null.`, err.Error())
+		})
+
+		t.Run("nested", func(t *testing.T) {
+			err := runTest(t, testCase{
+				schema:    schema,
+				operation: `query Foo($filter: PetFilter) { pets(filter: $filter) }`,
+				variables: `{"filter":{"by":{}}}`,
+			})
+			require.Error(t, err)
+			assert.Equal(t, `Variable "$filter" got invalid value {"by":{}} at "filter.by"; Exactly one key must be specified for OneOf type "PetBy".`, err.Error())
+		})
+	})
 }
 
 type testCase struct {